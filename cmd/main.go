@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	backtest "goquant/internal/backtesting"
 	"goquant/internal/data/clients"
 	"goquant/internal/data/storage"
 	"goquant/internal/strategies"
+	data_types "goquant/pkg/data"
 
 	"time"
 )
@@ -13,7 +15,7 @@ import (
 func main() {
 	// Initialize a DataSource (Yahoo Finance in this case)
 	//dataSource := clients.NewAlphaVantageClient("9EQCLVN4UJLBPQU9")
-	dataSource := clients.NewTwelveDataClient("228c3167a61f4916b63323025b5f2165")
+	var dataSource data_types.BarSource = clients.NewTwelveDataClient("228c3167a61f4916b63323025b5f2165")
 	//dataSource := clients.NewYahooFinanceDataSource()
 	storage := storage.NewInMemoryStorage()
 
@@ -21,8 +23,12 @@ func main() {
 	end := time.Now()
 	start := end.Add(-time.Hour * 24 * 30)
 
+	// Give up on the fetch and the storage write after a minute
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
 	// Fetch data for a symbol (e.g., "AAPL") using the DataSource interface
-	marketData, err := dataSource.FetchMinuteData("AAPL", start.Unix(), end.Unix(), "15min")
+	marketData, err := dataSource.FetchBarsContext(ctx, "AAPL", start.Unix(), end.Unix(), data_types.Interval15m)
 	//marketData, err := dataSource.Fetch("TSLA", start, end)
 	fmt.Println(marketData)
 	//marketData, err := dataSource.Fetch("FIVE", start, end)
@@ -32,7 +38,7 @@ func main() {
 	}
 
 	// Save data to storage
-	err = storage.Save(marketData)
+	err = storage.SaveContext(ctx, marketData)
 	if err != nil {
 		fmt.Printf("Error saving data: %v\n", err)
 		return
@@ -45,19 +51,27 @@ func main() {
 
 	initialInvest := 10000.0
 	// Define individual strategies
-	markovStrategy := strategies.NewMarkovChainStrategy(2)
+	markovStrategy, err := strategies.NewMarkovChainStrategy(2)
+	if err != nil {
+		fmt.Printf("Error creating Markov strategy: %v\n", err)
+		return
+	}
 	markovStrategy.Build(df) // Build the Markov model
 
 	movingAverageStrategy := strategies.MovingAverageCrossoverStrategy
 
 	// Define an ensemble strategy using the individual strategies
-	ensemble := strategies.NewEnsembleStrategy([]strategies.StrategyFunc{
+	ensemble, err := strategies.NewEnsembleStrategy([]strategies.StrategyFunc{
 		markovStrategy.Run,
 		movingAverageStrategy,
 	}, []float64{0.5, 0.5}) // Equal weights
+	if err != nil {
+		fmt.Printf("Error creating ensemble: %v\n", err)
+		return
+	}
 
 	// Run the backtest using the ensemble strategy
-	result, err := backtest.Backtest(df, ensemble.Run, time.Minute*15, initialInvest)
+	result, err := backtest.Backtest(df, ensemble.Run, data_types.Interval15m.Duration(), initialInvest)
 	if err != nil {
 		fmt.Printf("Backtest error: %v\n", err)
 		return
@@ -3,7 +3,18 @@ module goquant
 go 1.23.0
 
 require (
-	github.com/go-gota/gota v0.12.0 // indirect
+	github.com/go-gota/gota v0.12.0
+	github.com/parquet-go/parquet-go v0.25.1
+	gonum.org/v1/gonum v0.9.1
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	golang.org/x/exp v0.0.0-20191002040644-a1355ae1e2c3 // indirect
 	golang.org/x/net v0.0.0-20210423184538-5f58ad60dda6 // indirect
-	gonum.org/v1/gonum v0.9.1 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/tools v0.0.0-20190927191325-030b2cf1153e // indirect
 )
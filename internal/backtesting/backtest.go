@@ -27,6 +27,27 @@ import (
 //	backtest_types.BacktestResult: The result of the backtesting simulation.
 //	error: Any error that occurred during the simulation.
 func Backtest(df dataframe.DataFrame, strategy backtest_types.StrategyFunction, interval time.Duration, initialInvest float64) (backtest_types.BacktestResult, error) {
+	return BacktestSignals(df, backtest_types.SignalFunctionFromStrategy(strategy), interval, initialInvest)
+}
+
+// BacktestSignals runs a backtesting simulation using a confidence-weighted signal function.
+//
+// The position taken on each bar is the signal's exposure times the current investment, so a signal with
+// a strength of 0.5 risks half of the capital. Legacy strategies adapted with SignalFunctionFromStrategy
+// always use full exposure and behave exactly like Backtest.
+//
+// Parameters:
+//
+//	df (dataframe.DataFrame): The input dataframe containing the financial data.
+//	strategy (backtest_types.SignalFunction): The signal function to be applied to the dataframe.
+//	interval (time.Duration): The interval at which the signal function is applied.
+//	initialInvest (float64): The initial investment amount.
+//
+// Returns:
+//
+//	backtest_types.BacktestResult: The result of the backtesting simulation.
+//	error: Any error that occurred during the simulation.
+func BacktestSignals(df dataframe.DataFrame, strategy backtest_types.SignalFunction, interval time.Duration, initialInvest float64) (backtest_types.BacktestResult, error) {
 	// Ensure the dataframe has a "Timestamp" column
 	if !slices.Contains(df.Names(), "Timestamp") {
 		return backtest_types.BacktestResult{}, errors.New("dataframe must have a 'Timestamp' column")
@@ -59,18 +80,14 @@ func Backtest(df dataframe.DataFrame, strategy backtest_types.StrategyFunction,
 		for c := 0; c < i; c++ {
 			subset[c] = c
 		}
-		action := strategy(df.Subset(subset))
+		signal := strategy(df.Subset(subset))
+		action := signal.Action()
+		exposure := signal.Exposure()
 		openPrice := df.Col("Open").Float()[i]
 		closePrice := df.Col("Close").Float()[i]
 
-		profitLoss := 0.0
-
-		// Calculate profit or loss based on the action
-		if action == "Buy" {
-			profitLoss = (closePrice - openPrice) / openPrice * currentInvest
-		} else if action == "Sell" {
-			profitLoss = (openPrice - closePrice) / openPrice * currentInvest
-		}
+		// Calculate profit or loss based on the signed exposure; positive is long, negative is short
+		profitLoss := exposure * (closePrice - openPrice) / openPrice * currentInvest
 
 		// Update total profit/loss and current investment
 		totalProfitLoss += profitLoss
@@ -93,6 +110,7 @@ func Backtest(df dataframe.DataFrame, strategy backtest_types.StrategyFunction,
 		tradeResults = append(tradeResults, map[string]interface{}{
 			"Timestamp":       time.Unix(int64(timestamps[i]), 0).Format(time.RFC3339),
 			"Action":          action,
+			"Exposure":        exposure,
 			"OpenPrice":       openPrice,
 			"ClosePrice":      closePrice,
 			"ProfitLoss":      profitLoss,
@@ -0,0 +1,141 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	backtest_types "goquant/pkg/backtest"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// BacktestPair runs a two-legged backtesting simulation of a spread strategy on two instruments.
+//
+// The dataframes are aligned on their common timestamps. On every bar the strategy sees the aligned bars
+// before it and returns a PairSignal; the position is then held from the open to the close of the bar.
+// The current investment is split across both legs so that the gross notional of A plus |HedgeRatio| units
+// of B equals the current investment at the open.
+//
+// Parameters:
+//
+//	a (dataframe.DataFrame): The dataframe of the first instrument.
+//	b (dataframe.DataFrame): The dataframe of the second instrument.
+//	strategy (backtest_types.PairStrategyFunction): The pair strategy to be applied.
+//	initialInvest (float64): The initial investment amount.
+//
+// Returns:
+//
+//	backtest_types.PairBacktestResult: The result of the backtesting simulation.
+//	error: Any error that occurred during the simulation.
+func BacktestPair(a, b dataframe.DataFrame, strategy backtest_types.PairStrategyFunction, initialInvest float64) (backtest_types.PairBacktestResult, error) {
+	for _, df := range []dataframe.DataFrame{a, b} {
+		for _, col := range []string{"Timestamp", "Open", "Close"} {
+			if !slices.Contains(df.Names(), col) {
+				return backtest_types.PairBacktestResult{}, fmt.Errorf("dataframe must have a '%s' column", col)
+			}
+		}
+	}
+
+	a, b = alignPair(a, b)
+	if a.Nrow() == 0 {
+		return backtest_types.PairBacktestResult{}, errors.New("dataframes have no common timestamps")
+	}
+
+	timestamps := a.Col("Timestamp").Float()
+	openA, closeA := a.Col("Open").Float(), a.Col("Close").Float()
+	openB, closeB := b.Col("Open").Float(), b.Col("Close").Float()
+
+	totalProfitLoss := 0.0
+	maxUp := 0.0
+	maxDown := 0.0
+	currentInvest := initialInvest
+	longCount, shortCount, flatCount := 0, 0, 0
+	tradeResults := []map[string]interface{}{}
+
+	for i := 0; i < len(timestamps); i++ {
+		subset := make([]int, i)
+		for c := 0; c < i; c++ {
+			subset[c] = c
+		}
+		signal := strategy(a.Subset(subset), b.Subset(subset))
+
+		profitLossA, profitLossB := 0.0, 0.0
+		if signal.Position != 0 && !math.IsNaN(signal.HedgeRatio) {
+			// Units of A such that the gross notional of both legs equals the current investment
+			unitsA := currentInvest / (openA[i] + math.Abs(signal.HedgeRatio)*openB[i])
+			unitsB := signal.HedgeRatio * unitsA
+			direction := float64(signal.Position)
+
+			profitLossA = direction * unitsA * (closeA[i] - openA[i])
+			profitLossB = -direction * unitsB * (closeB[i] - openB[i])
+		}
+		profitLoss := profitLossA + profitLossB
+
+		switch {
+		case signal.Position > 0:
+			longCount++
+		case signal.Position < 0:
+			shortCount++
+		default:
+			flatCount++
+		}
+
+		// Update total profit/loss and current investment
+		totalProfitLoss += profitLoss
+		currentInvest += profitLoss
+
+		// Ensure investment doesn't drop below zero
+		if currentInvest < 0 {
+			currentInvest = 0
+		}
+
+		// Track max up and max down
+		if totalProfitLoss > maxUp {
+			maxUp = totalProfitLoss
+		}
+		if totalProfitLoss < maxDown {
+			maxDown = totalProfitLoss
+		}
+
+		tradeResults = append(tradeResults, map[string]interface{}{
+			"Timestamp":       time.Unix(int64(timestamps[i]), 0).Format(time.RFC3339),
+			"Position":        signal.Position,
+			"HedgeRatio":      signal.HedgeRatio,
+			"ProfitLossA":     profitLossA,
+			"ProfitLossB":     profitLossB,
+			"ProfitLoss":      profitLoss,
+			"TotalProfitLoss": totalProfitLoss,
+			"CurrentInvest":   currentInvest,
+		})
+	}
+
+	return backtest_types.PairBacktestResult{
+		TotalProfitLoss: totalProfitLoss,
+		MaxUp:           maxUp,
+		MaxDown:         maxDown,
+		TradeLog:        dataframe.LoadMaps(tradeResults),
+		LongCount:       longCount,
+		ShortCount:      shortCount,
+		FlatCount:       flatCount,
+		GainStrategy:    totalProfitLoss / initialInvest,
+	}, nil
+}
+
+// alignPair keeps only the rows whose timestamps appear in both dataframes, in the order of the first.
+func alignPair(a, b dataframe.DataFrame) (dataframe.DataFrame, dataframe.DataFrame) {
+	rowB := make(map[int64]int)
+	for i, ts := range b.Col("Timestamp").Float() {
+		rowB[int64(ts)] = i
+	}
+
+	var rowsA, rowsB []int
+	for i, ts := range a.Col("Timestamp").Float() {
+		if j, ok := rowB[int64(ts)]; ok {
+			rowsA = append(rowsA, i)
+			rowsB = append(rowsB, j)
+		}
+	}
+	return a.Subset(rowsA), b.Subset(rowsB)
+}
@@ -0,0 +1,188 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	backtest_types "goquant/pkg/backtest"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// BacktestPortfolio runs a backtesting simulation of a periodically rebalanced multi-asset portfolio.
+//
+// The dataframes are aligned on the timestamps present for every ticker. Every rebalanceEvery bars the
+// allocation function sees the aligned history up to and including the previous bar and the portfolio is
+// rebalanced to its weights at that bar's close. Between rebalances the weights drift with the prices.
+//
+// Parameters:
+//
+//	data (map[string]dataframe.DataFrame): The dataframe of every ticker.
+//	allocate (backtest_types.AllocationFunction): The function returning the target weights.
+//	rebalanceEvery (int): The number of bars between rebalances.
+//	initialInvest (float64): The initial investment amount.
+//
+// Returns:
+//
+//	backtest_types.PortfolioBacktestResult: The result of the backtesting simulation.
+//	error: Any error that occurred during the simulation.
+func BacktestPortfolio(data map[string]dataframe.DataFrame, allocate backtest_types.AllocationFunction, rebalanceEvery int, initialInvest float64) (backtest_types.PortfolioBacktestResult, error) {
+	if len(data) == 0 {
+		return backtest_types.PortfolioBacktestResult{}, errors.New("no dataframes to backtest")
+	}
+	if rebalanceEvery < 1 {
+		return backtest_types.PortfolioBacktestResult{}, fmt.Errorf("rebalance interval must be at least 1, got %d", rebalanceEvery)
+	}
+	for ticker, df := range data {
+		for _, col := range []string{"Timestamp", "Close"} {
+			if !slices.Contains(df.Names(), col) {
+				return backtest_types.PortfolioBacktestResult{}, fmt.Errorf("dataframe of %s must have a '%s' column", ticker, col)
+			}
+		}
+	}
+
+	tickers, aligned, timestamps := alignPortfolio(data)
+	if len(timestamps) < 2 {
+		return backtest_types.PortfolioBacktestResult{}, errors.New("dataframes have fewer than 2 common timestamps")
+	}
+
+	closes := make([][]float64, len(tickers))
+	for j, ticker := range tickers {
+		closes[j] = aligned[ticker].Col("Close").Float()
+	}
+
+	totalProfitLoss := 0.0
+	maxUp := 0.0
+	maxDown := 0.0
+	currentInvest := initialInvest
+	rebalances := 0
+	turnover := 0.0
+	weights := make([]float64, len(tickers))
+	tradeResults := []map[string]interface{}{}
+
+	for i := 1; i < len(timestamps); i++ {
+		rebalanced := false
+		if (i-1)%rebalanceEvery == 0 {
+			subset := make([]int, i)
+			for c := 0; c < i; c++ {
+				subset[c] = c
+			}
+			history := make(map[string]dataframe.DataFrame, len(tickers))
+			for _, ticker := range tickers {
+				history[ticker] = aligned[ticker].Subset(subset)
+			}
+
+			target := allocate(history)
+			for j, ticker := range tickers {
+				w := target[ticker]
+				if math.IsNaN(w) || math.IsInf(w, 0) {
+					w = 0
+				}
+				turnover += math.Abs(w - weights[j])
+				weights[j] = w
+			}
+			rebalances++
+			rebalanced = true
+		}
+
+		// Portfolio return from the previous close to the current close, then let the weights drift
+		portfolioReturn := 0.0
+		growth := make([]float64, len(tickers))
+		for j := range tickers {
+			assetReturn := 0.0
+			if closes[j][i-1] != 0 {
+				assetReturn = closes[j][i]/closes[j][i-1] - 1
+			}
+			portfolioReturn += weights[j] * assetReturn
+			growth[j] = weights[j] * (1 + assetReturn)
+		}
+		if 1+portfolioReturn != 0 {
+			for j := range weights {
+				weights[j] = growth[j] / (1 + portfolioReturn)
+			}
+		}
+
+		profitLoss := portfolioReturn * currentInvest
+		totalProfitLoss += profitLoss
+		currentInvest += profitLoss
+
+		// Ensure investment doesn't drop below zero
+		if currentInvest < 0 {
+			currentInvest = 0
+		}
+
+		// Track max up and max down
+		if totalProfitLoss > maxUp {
+			maxUp = totalProfitLoss
+		}
+		if totalProfitLoss < maxDown {
+			maxDown = totalProfitLoss
+		}
+
+		record := map[string]interface{}{
+			"Timestamp":       time.Unix(timestamps[i], 0).Format(time.RFC3339),
+			"Rebalanced":      rebalanced,
+			"Return":          portfolioReturn,
+			"ProfitLoss":      profitLoss,
+			"TotalProfitLoss": totalProfitLoss,
+			"CurrentInvest":   currentInvest,
+		}
+		for j, ticker := range tickers {
+			record["Weight_"+ticker] = weights[j]
+		}
+		tradeResults = append(tradeResults, record)
+	}
+
+	return backtest_types.PortfolioBacktestResult{
+		TotalProfitLoss: totalProfitLoss,
+		MaxUp:           maxUp,
+		MaxDown:         maxDown,
+		TradeLog:        dataframe.LoadMaps(tradeResults),
+		Rebalances:      rebalances,
+		Turnover:        turnover,
+		GainStrategy:    totalProfitLoss / initialInvest,
+	}, nil
+}
+
+// alignPortfolio keeps the rows whose timestamps appear in every dataframe, sorted by timestamp.
+// It returns the sorted tickers, the aligned dataframes and the common timestamps.
+func alignPortfolio(data map[string]dataframe.DataFrame) ([]string, map[string]dataframe.DataFrame, []int64) {
+	tickers := make([]string, 0, len(data))
+	for ticker := range data {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	rows := make(map[string]map[int64]int, len(tickers))
+	counts := make(map[int64]int)
+	for _, ticker := range tickers {
+		rows[ticker] = make(map[int64]int)
+		for i, ts := range data[ticker].Col("Timestamp").Float() {
+			if _, seen := rows[ticker][int64(ts)]; !seen {
+				counts[int64(ts)]++
+			}
+			rows[ticker][int64(ts)] = i
+		}
+	}
+
+	var timestamps []int64
+	for ts, count := range counts {
+		if count == len(tickers) {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	sort.Slice(timestamps, func(a, b int) bool { return timestamps[a] < timestamps[b] })
+
+	aligned := make(map[string]dataframe.DataFrame, len(tickers))
+	for _, ticker := range tickers {
+		subset := make([]int, len(timestamps))
+		for i, ts := range timestamps {
+			subset[i] = rows[ticker][ts]
+		}
+		aligned[ticker] = data[ticker].Subset(subset)
+	}
+	return tickers, aligned, timestamps
+}
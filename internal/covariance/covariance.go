@@ -0,0 +1,379 @@
+package covariance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Estimator estimates the covariance matrix of aligned return series, given as one row per period
+// and one column per asset, e.g. as built by portfolio.ReturnsMatrix.
+type Estimator interface {
+	Estimate(returns mat.Matrix) (*mat.SymDense, error)
+}
+
+// Sample is the unbiased sample covariance estimator.
+type Sample struct{}
+
+// Estimate returns the sample covariance matrix of the returns.
+func (Sample) Estimate(returns mat.Matrix) (*mat.SymDense, error) {
+	n, p, err := dims(returns, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	centered := center(returns)
+	cov := mat.NewSymDense(p, nil)
+	cov.SymOuterK(1/float64(n-1), centered.T())
+	return cov, nil
+}
+
+// ExponentiallyWeighted estimates the covariance with exponentially decaying weights, so that recent
+// observations count more. The weight of an observation k periods old is proportional to Lambda^k.
+type ExponentiallyWeighted struct {
+	Lambda float64
+}
+
+// NewExponentiallyWeighted creates an ExponentiallyWeighted estimator from a half-life in periods.
+func NewExponentiallyWeighted(halfLife float64) (ExponentiallyWeighted, error) {
+	if halfLife <= 0 || math.IsNaN(halfLife) {
+		return ExponentiallyWeighted{}, fmt.Errorf("half-life must be positive, got %v", halfLife)
+	}
+	return ExponentiallyWeighted{Lambda: math.Pow(0.5, 1/halfLife)}, nil
+}
+
+// Estimate returns the exponentially weighted covariance matrix of the returns. The last row is the most recent.
+func (e ExponentiallyWeighted) Estimate(returns mat.Matrix) (*mat.SymDense, error) {
+	n, p, err := dims(returns, 2)
+	if err != nil {
+		return nil, err
+	}
+	if e.Lambda <= 0 || e.Lambda >= 1 {
+		return nil, fmt.Errorf("lambda must be in (0, 1), got %v", e.Lambda)
+	}
+
+	weights := make([]float64, n)
+	total := 0.0
+	for i := 0; i < n; i++ {
+		weights[i] = math.Pow(e.Lambda, float64(n-1-i))
+		total += weights[i]
+	}
+
+	mean := make([]float64, p)
+	for i := 0; i < n; i++ {
+		for j := 0; j < p; j++ {
+			mean[j] += weights[i] / total * returns.At(i, j)
+		}
+	}
+
+	// Weighted deviations, scaled by sqrt(w_i) so that X'X is the weighted sum of outer products
+	scaled := mat.NewDense(n, p, nil)
+	for i := 0; i < n; i++ {
+		s := math.Sqrt(weights[i] / total)
+		for j := 0; j < p; j++ {
+			scaled.Set(i, j, s*(returns.At(i, j)-mean[j]))
+		}
+	}
+
+	// Bias correction for reliability weights
+	sumSquares := 0.0
+	for _, w := range weights {
+		sumSquares += (w / total) * (w / total)
+	}
+	cov := mat.NewSymDense(p, nil)
+	cov.SymOuterK(1/(1-sumSquares), scaled.T())
+	return cov, nil
+}
+
+// LedoitWolf shrinks the sample covariance towards a scaled identity matrix with the optimal intensity
+// of Ledoit and Wolf (2004), "A well-conditioned estimator for large-dimensional covariance matrices".
+type LedoitWolf struct{}
+
+// Estimate returns the shrunk covariance matrix of the returns.
+func (LedoitWolf) Estimate(returns mat.Matrix) (*mat.SymDense, error) {
+	cov, _, err := LedoitWolfShrinkage(returns)
+	return cov, err
+}
+
+// LedoitWolfShrinkage returns the Ledoit-Wolf covariance matrix together with the shrinkage intensity in [0, 1].
+func LedoitWolfShrinkage(returns mat.Matrix) (*mat.SymDense, float64, error) {
+	n, p, err := dims(returns, 2)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	centered := center(returns)
+	sample := mat.NewSymDense(p, nil)
+	sample.SymOuterK(1/float64(n), centered.T())
+
+	mu := mat.Trace(sample) / float64(p)
+
+	// delta² = ||S - mu I||², beta² = (1/n²) Σ_k ||x_k x_k' - S||²
+	delta := 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			d := sample.At(i, j)
+			if i == j {
+				d -= mu
+			}
+			delta += d * d
+		}
+	}
+
+	beta := 0.0
+	for k := 0; k < n; k++ {
+		for i := 0; i < p; i++ {
+			for j := 0; j < p; j++ {
+				d := centered.At(k, i)*centered.At(k, j) - sample.At(i, j)
+				beta += d * d
+			}
+		}
+	}
+	beta /= float64(n) * float64(n)
+	beta = math.Min(beta, delta)
+
+	shrinkage := 0.0
+	if delta > 0 {
+		shrinkage = beta / delta
+	}
+
+	cov := mat.NewSymDense(p, nil)
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			v := (1 - shrinkage) * sample.At(i, j)
+			if i == j {
+				v += shrinkage * mu
+			}
+			cov.SetSym(i, j, v)
+		}
+	}
+	return cov, shrinkage, nil
+}
+
+// ConstantCorrelation shrinks the sample covariance towards a constant-correlation target with the optimal
+// intensity of Ledoit and Wolf (2004), "Honey, I shrunk the sample covariance matrix".
+type ConstantCorrelation struct{}
+
+// Estimate returns the shrunk covariance matrix of the returns.
+func (ConstantCorrelation) Estimate(returns mat.Matrix) (*mat.SymDense, error) {
+	cov, _, err := ConstantCorrelationShrinkage(returns)
+	return cov, err
+}
+
+// ConstantCorrelationShrinkage returns the shrunk covariance matrix together with the shrinkage intensity in [0, 1].
+func ConstantCorrelationShrinkage(returns mat.Matrix) (*mat.SymDense, float64, error) {
+	n, p, err := dims(returns, 2)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	centered := center(returns)
+	sample := mat.NewSymDense(p, nil)
+	sample.SymOuterK(1/float64(n), centered.T())
+
+	stdDev := make([]float64, p)
+	for i := 0; i < p; i++ {
+		stdDev[i] = math.Sqrt(sample.At(i, i))
+		if stdDev[i] == 0 {
+			return nil, 0, fmt.Errorf("asset %d has no variance", i)
+		}
+	}
+
+	// Average correlation and the constant-correlation target F
+	rBar := 0.0
+	if p > 1 {
+		for i := 0; i < p; i++ {
+			for j := i + 1; j < p; j++ {
+				rBar += sample.At(i, j) / (stdDev[i] * stdDev[j])
+			}
+		}
+		rBar = 2 * rBar / float64(p*(p-1))
+	}
+	target := mat.NewSymDense(p, nil)
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			if i == j {
+				target.SetSym(i, i, sample.At(i, i))
+			} else {
+				target.SetSym(i, j, rBar*stdDev[i]*stdDev[j])
+			}
+		}
+	}
+
+	// pi: asymptotic variances of the sample covariances
+	piMat := mat.NewDense(p, p, nil)
+	piSum := 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			v := 0.0
+			for k := 0; k < n; k++ {
+				d := centered.At(k, i)*centered.At(k, j) - sample.At(i, j)
+				v += d * d
+			}
+			v /= float64(n)
+			piMat.Set(i, j, v)
+			piSum += v
+		}
+	}
+
+	// rho: asymptotic covariances of the target with the sample covariances
+	rho := 0.0
+	for i := 0; i < p; i++ {
+		rho += piMat.At(i, i)
+	}
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			if i == j {
+				continue
+			}
+			thetaII, thetaJJ := 0.0, 0.0
+			for k := 0; k < n; k++ {
+				cross := centered.At(k, i) * centered.At(k, j)
+				thetaII += (centered.At(k, i)*centered.At(k, i) - sample.At(i, i)) * (cross - sample.At(i, j))
+				thetaJJ += (centered.At(k, j)*centered.At(k, j) - sample.At(j, j)) * (cross - sample.At(i, j))
+			}
+			thetaII /= float64(n)
+			thetaJJ /= float64(n)
+			rho += rBar / 2 * (stdDev[j]/stdDev[i]*thetaII + stdDev[i]/stdDev[j]*thetaJJ)
+		}
+	}
+
+	// gamma: misspecification of the target
+	gamma := 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			d := target.At(i, j) - sample.At(i, j)
+			gamma += d * d
+		}
+	}
+
+	shrinkage := 0.0
+	if gamma > 0 {
+		kappa := (piSum - rho) / gamma
+		shrinkage = math.Max(0, math.Min(1, kappa/float64(n)))
+	}
+
+	cov := mat.NewSymDense(p, nil)
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			cov.SetSym(i, j, shrinkage*target.At(i, j)+(1-shrinkage)*sample.At(i, j))
+		}
+	}
+	return cov, shrinkage, nil
+}
+
+// RepairPSD returns the nearest positive semi-definite matrix in the sense of clipping the eigenvalues
+// of cov at minEigenvalue. The diagonal of the result is rescaled to the original variances so that
+// only the correlations change.
+func RepairPSD(cov mat.Symmetric, minEigenvalue float64) (*mat.SymDense, error) {
+	if cov == nil {
+		return nil, errors.New("covariance matrix must not be nil")
+	}
+	if minEigenvalue < 0 {
+		return nil, fmt.Errorf("minimum eigenvalue must be non-negative, got %v", minEigenvalue)
+	}
+	p := cov.Symmetric()
+
+	var eig mat.EigenSym
+	if ok := eig.Factorize(cov, true); !ok {
+		return nil, errors.New("eigendecomposition failed")
+	}
+	values := eig.Values(nil)
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+
+	clipped := false
+	for i, v := range values {
+		if v < minEigenvalue {
+			values[i] = minEigenvalue
+			clipped = true
+		}
+	}
+
+	repaired := mat.NewSymDense(p, nil)
+	if !clipped {
+		repaired.CopySym(cov)
+		return repaired, nil
+	}
+
+	// V diag(values) V'
+	var scaled mat.Dense
+	scaled.Apply(func(i, j int, v float64) float64 { return v * values[j] }, &vectors)
+	var full mat.Dense
+	full.Mul(&scaled, vectors.T())
+
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			v := full.At(i, j)
+			original := math.Sqrt(cov.At(i, i) * cov.At(j, j))
+			current := math.Sqrt(full.At(i, i) * full.At(j, j))
+			if current > 0 && original > 0 {
+				v *= original / current
+			}
+			repaired.SetSym(i, j, v)
+		}
+	}
+	return repaired, nil
+}
+
+// IsPSD reports whether the smallest eigenvalue of cov is at least -tol.
+func IsPSD(cov mat.Symmetric, tol float64) bool {
+	var eig mat.EigenSym
+	if ok := eig.Factorize(cov, false); !ok {
+		return false
+	}
+	return eig.Values(nil)[0] >= -tol
+}
+
+// Correlation converts a covariance matrix to a correlation matrix.
+func Correlation(cov mat.Symmetric) *mat.SymDense {
+	p := cov.Symmetric()
+	corr := mat.NewSymDense(p, nil)
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			denominator := math.Sqrt(cov.At(i, i) * cov.At(j, j))
+			if denominator > 0 {
+				corr.SetSym(i, j, cov.At(i, j)/denominator)
+			}
+		}
+	}
+	return corr
+}
+
+// dims checks that the returns have at least minRows rows and one column, and are finite.
+func dims(returns mat.Matrix, minRows int) (int, int, error) {
+	if returns == nil {
+		return 0, 0, errors.New("returns must not be nil")
+	}
+	n, p := returns.Dims()
+	if n < minRows || p == 0 {
+		return 0, 0, fmt.Errorf("need at least %d observations of at least one asset, got %dx%d", minRows, n, p)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < p; j++ {
+			if v := returns.At(i, j); math.IsNaN(v) || math.IsInf(v, 0) {
+				return 0, 0, fmt.Errorf("return (%d, %d) is not finite", i, j)
+			}
+		}
+	}
+	return n, p, nil
+}
+
+// center returns a copy of the returns with the column means subtracted.
+func center(returns mat.Matrix) *mat.Dense {
+	n, p := returns.Dims()
+	centered := mat.DenseCopyOf(returns)
+	for j := 0; j < p; j++ {
+		mean := 0.0
+		for i := 0; i < n; i++ {
+			mean += centered.At(i, j)
+		}
+		mean /= float64(n)
+		for i := 0; i < n; i++ {
+			centered.Set(i, j, centered.At(i, j)-mean)
+		}
+	}
+	return centered
+}
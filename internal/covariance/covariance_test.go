@@ -0,0 +1,162 @@
+package covariance
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// testReturns holds six periods of returns of three assets.
+var testReturns = mat.NewDense(6, 3, []float64{
+	0.01, 0.02, -0.01,
+	0.03, -0.01, 0.00,
+	-0.02, 0.01, 0.02,
+	0.00, 0.03, -0.02,
+	0.02, -0.02, 0.01,
+	-0.01, 0.00, 0.03,
+})
+
+// assertSym fails if a matrix differs from the expected values by more than tol.
+func assertSym(t *testing.T, name string, got mat.Symmetric, want [][]float64, tol float64) {
+	t.Helper()
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(got.At(i, j)-want[i][j]) > tol {
+				t.Errorf("%s (%d, %d): got %.10g, want %.10g", name, i, j, got.At(i, j), want[i][j])
+			}
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	cov, err := Sample{}.Estimate(testReturns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSym(t, "sample", cov, [][]float64{
+		{0.00035, -0.00017, -0.00015},
+		{-0.00017, 0.00035, -0.00019},
+		{-0.00015, -0.00019, 0.00035},
+	}, 1e-15)
+}
+
+func TestLedoitWolf(t *testing.T) {
+	// Reference values computed with the formulas of Ledoit and Wolf (2004), as in scikit-learn
+	cov, shrinkage, err := LedoitWolfShrinkage(testReturns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(shrinkage-0.6064761904761905) > 1e-12 {
+		t.Errorf("shrinkage: got %.15f, want 0.606476190476190", shrinkage)
+	}
+	assertSym(t, "ledoit-wolf", cov, [][]float64{
+		{2.916666666666667e-04, -5.574920634920634e-05, -4.919047619047619e-05},
+		{-5.574920634920634e-05, 2.916666666666667e-04, -6.230793650793650e-05},
+		{-4.919047619047619e-05, -6.230793650793650e-05, 2.916666666666667e-04},
+	}, 1e-15)
+}
+
+func TestConstantCorrelation(t *testing.T) {
+	cov, shrinkage, err := ConstantCorrelationShrinkage(testReturns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shrinkage < 0 || shrinkage > 1 {
+		t.Errorf("shrinkage %v outside [0, 1]", shrinkage)
+	}
+	// Shrinking towards the constant-correlation target keeps the maximum likelihood variances
+	for i := 0; i < 3; i++ {
+		if math.Abs(cov.At(i, i)-0.00035*5/6) > 1e-15 {
+			t.Errorf("variance %d: got %.10g", i, cov.At(i, i))
+		}
+	}
+	if !IsPSD(cov, 0) {
+		t.Error("shrunk covariance is not positive semi-definite")
+	}
+}
+
+func TestExponentiallyWeighted(t *testing.T) {
+	// With a very long half-life every observation counts the same and the estimate is the sample covariance
+	ew, err := NewExponentiallyWeighted(1e12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cov, err := ew.Estimate(testReturns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample, _ := Sample{}.Estimate(testReturns)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if math.Abs(cov.At(i, j)-sample.At(i, j)) > 1e-12 {
+				t.Errorf("(%d, %d): got %.10g, want %.10g", i, j, cov.At(i, j), sample.At(i, j))
+			}
+		}
+	}
+
+	if ew, _ := NewExponentiallyWeighted(1); math.Abs(ew.Lambda-0.5) > 1e-15 {
+		t.Errorf("half-life 1: got lambda %v, want 0.5", ew.Lambda)
+	}
+	if _, err := NewExponentiallyWeighted(0); err == nil {
+		t.Error("half-life 0: expected an error")
+	}
+}
+
+func TestRepairPSD(t *testing.T) {
+	indefinite := mat.NewSymDense(3, []float64{
+		1, 0.9, 0.9,
+		0.9, 1, -0.9,
+		0.9, -0.9, 1,
+	})
+	if IsPSD(indefinite, 0) {
+		t.Fatal("test matrix should not be positive semi-definite")
+	}
+	repaired, err := RepairPSD(indefinite, 1e-8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPSD(repaired, 1e-12) {
+		t.Error("repaired matrix is not positive semi-definite")
+	}
+	for i := 0; i < 3; i++ {
+		if math.Abs(repaired.At(i, i)-1) > 1e-12 {
+			t.Errorf("variance %d: got %v, want 1", i, repaired.At(i, i))
+		}
+	}
+
+	sample, _ := Sample{}.Estimate(testReturns)
+	unchanged, err := RepairPSD(sample, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(unchanged, sample) {
+		t.Error("a positive definite matrix must not change")
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	sample, _ := Sample{}.Estimate(testReturns)
+	assertSym(t, "correlation", Correlation(sample), [][]float64{
+		{1, -17.0 / 35, -15.0 / 35},
+		{-17.0 / 35, 1, -19.0 / 35},
+		{-15.0 / 35, -19.0 / 35, 1},
+	}, 1e-12)
+}
+
+func TestEstimateRejectsInvalidReturns(t *testing.T) {
+	tests := []struct {
+		name    string
+		returns mat.Matrix
+	}{
+		{"single observation", mat.NewDense(1, 2, []float64{0.01, 0.02})},
+		{"not finite", mat.NewDense(2, 2, []float64{0.01, math.NaN(), 0.02, 0.03})},
+	}
+	for _, tt := range tests {
+		for _, estimator := range []Estimator{Sample{}, LedoitWolf{}, ConstantCorrelation{}} {
+			if _, err := estimator.Estimate(tt.returns); err == nil {
+				t.Errorf("%s, %T: expected an error", tt.name, estimator)
+			}
+		}
+	}
+}
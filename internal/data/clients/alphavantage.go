@@ -1,10 +1,12 @@
 package clients
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	data_types "goquant/pkg/data"
@@ -13,6 +15,13 @@ import (
 type AlphaVantageClient struct {
 	APIKey  string
 	BaseURL string
+	// HTTP performs the requests. The default allows the 5 requests per minute of the free tier.
+	HTTP *HTTPClient
+	// Concurrency is the number of months of intraday bars fetched at once, within the rate limit of HTTP.
+	Concurrency int
+	// Now returns the current time, against which the history limits of intervals are checked. Nil uses
+	// time.Now; replays of recorded responses set it to the time of the recording.
+	Now func() time.Time
 }
 
 // NewAlphaVantageClient creates a new AlphaVantageClient instance.
@@ -22,53 +31,172 @@ type AlphaVantageClient struct {
 func NewAlphaVantageClient(apiKey string) *AlphaVantageClient {
 
 	return &AlphaVantageClient{
-		APIKey:  apiKey,
-		BaseURL: "https://www.alphavantage.co/query?",
+		APIKey:      apiKey,
+		BaseURL:     "https://www.alphavantage.co/query?",
+		HTTP:        NewHTTPClient(DefaultHTTPConfig(5, 1)),
+		Concurrency: 1,
 	}
 }
 
+// alphaVantageIntraday maps intervals to the interval parameter of TIME_SERIES_INTRADAY.
+var alphaVantageIntraday = map[data_types.Interval]string{
+	data_types.Interval1m:  "1min",
+	data_types.Interval5m:  "5min",
+	data_types.Interval15m: "15min",
+	data_types.Interval1h:  "60min",
+}
+
+// Capabilities returns the intervals served by Alpha Vantage. Intraday bars are fetched a month at a
+// time and have no history limit.
+func (c *AlphaVantageClient) Capabilities() data_types.Capabilities {
+	return data_types.Capabilities{Intervals: data_types.Intervals}
+}
+
+// Fetch fetches daily data for a given symbol.
+//
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *AlphaVantageClient) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchContext(context.Background(), symbol, start, end)
+}
+
+// FetchContext fetches daily data for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *AlphaVantageClient) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(ctx, symbol, start, end, data_types.Interval1d)
+}
+
 // FetchMinuteData fetches intraday minute data for a given symbol.
 //
+// Deprecated: use FetchBars with a typed Interval.
+//
 // Parameter symbol is the stock symbol to fetch data for.
 // Parameter start and end are the start and end timestamps to filter data by.
 // Parameter interval is the interval of the data (e.g. 1min, 5min, etc.).
 // Returns a slice of MarketData and an error.
 func (c *AlphaVantageClient) FetchMinuteData(symbol string, start, end int64, interval string) ([]data_types.MarketData, error) {
-	// Prepare the URL
-	url := fmt.Sprintf("%sfunction=TIME_SERIES_INTRADAY&symbol=%s&interval=%s&apikey=%s&datatype=csv&outputsize=full", c.BaseURL, symbol, interval, c.APIKey)
-	fmt.Println(url)
-	// Perform the request
-	resp, err := http.Get(url)
+	parsed, err := data_types.ParseInterval(interval)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching data: %v", err)
+		return nil, err
 	}
+	return c.FetchBars(symbol, start, end, parsed)
+}
 
-	defer resp.Body.Close()
+// FetchBars fetches bars at the given interval for a given symbol.
+//
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval; intraday intervals use TIME_SERIES_INTRADAY, daily and weekly
+// bars use TIME_SERIES_DAILY and TIME_SERIES_WEEKLY.
+// Returns a slice of MarketData and an error.
+func (c *AlphaVantageClient) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+// FetchBarsContext fetches bars at the given interval for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (c *AlphaVantageClient) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	data, _, err := c.FetchBarsReport(ctx, symbol, start, end, interval)
+	return data, err
+}
+
+// FetchBarsReport fetches bars at the given interval for a given symbol until the context is done and
+// reports the rows it had to skip.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval; intraday intervals use TIME_SERIES_INTRADAY one calendar month
+// per request, fetched Concurrency at a time, while daily and weekly bars use TIME_SERIES_DAILY and
+// TIME_SERIES_WEEKLY. The result is sorted and free of duplicates.
+// Returns a slice of MarketData, the report and an error wrapping one of the errors of data_types if
+// Alpha Vantage rejects the request or its response cannot be parsed.
+func (c *AlphaVantageClient) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("alphavantage", symbol, interval)
+	if err := c.Capabilities().Check(interval, start, clockNow(c.Now)); err != nil {
+		return nil, report, err
+	}
+
+	var url string
+	switch interval {
+	case data_types.Interval1d:
+		url = fmt.Sprintf("%sfunction=TIME_SERIES_DAILY&symbol=%s&apikey=%s&datatype=csv&outputsize=full", c.BaseURL, symbol, c.APIKey)
+	case data_types.Interval1w:
+		url = fmt.Sprintf("%sfunction=TIME_SERIES_WEEKLY&symbol=%s&apikey=%s&datatype=csv", c.BaseURL, symbol, c.APIKey)
+	}
+	if url != "" {
+		data, err := c.fetchURL(ctx, url, "2006-01-02", time.UTC, symbol, start, end, &report)
+		if err != nil {
+			return nil, report, err
+		}
+		data = MergeBars(data)
+		report.Bars = len(data)
+		return data, report, nil
+	}
+
+	// Intraday bars are served a month at a time, in months of US/Eastern time
+	chunks := splitMonths(start, end, newYork)
+	data, err := fetchChunks(ctx, chunks, c.Concurrency, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+		month := time.Unix(chunk.Start, 0).In(newYork).Format("2006-01")
+		url := fmt.Sprintf("%sfunction=TIME_SERIES_INTRADAY&symbol=%s&interval=%s&month=%s&apikey=%s&datatype=csv&outputsize=full", c.BaseURL, symbol, alphaVantageIntraday[interval], month, c.APIKey)
+		return c.fetchURL(ctx, url, "2006-01-02 15:04:05", newYork, symbol, chunk.Start, chunk.End, report)
+	})
+	return data, report, err
+}
+
+// fetchURL fetches a CSV time series and keeps the bars between start and end. Timestamps are parsed
+// with layout in loc: intraday bars are reported in US/Eastern time, while daily and weekly bars are
+// dated and timestamped at midnight UTC of their date.
+func (c *AlphaVantageClient) fetchURL(ctx context.Context, url, layout string, loc *time.Location, symbol string, start, end int64, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+	// Perform the request
+	body, err := c.HTTP.GetChecked(ctx, url, checkAlphaVantage)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching data: %w", err)
+	}
+	report.Requests++
 
 	// Parse the CSV response
-	r := csv.NewReader(resp.Body)
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
 	records, err := r.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("error reading CSV: %v", err)
+		return nil, malformedf("error reading CSV: %v", err)
+	}
+	if len(records) == 0 || len(records[0]) < 6 || records[0][0] != "timestamp" {
+		return nil, malformedf("unexpected CSV header in %q", truncate(string(body), 200))
 	}
 
 	// Process the data and filter by the start and end time
 	var data []data_types.MarketData
-	for i, record := range records {
-		if i == 0 {
-			continue // Skip header
+	rows, skipped := len(records)-1, len(report.Skipped)
+	report.Rows += rows
+	for i, record := range records[1:] {
+		raw := strings.Join(record, ",")
+		if len(record) < 6 {
+			report.Skip(i, raw, fmt.Errorf("expected 6 columns, got %d", len(record)))
+			continue
 		}
-		timestamp, _ := time.Parse("2006-01-02 15:04:05", record[0])
-		if timestamp.Unix() < start || timestamp.Unix() > end {
+		timestamp, err := time.ParseInLocation(layout, record[0], loc)
+		if err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid timestamp %q", record[0]))
 			continue
 		}
-		open, _ := strconv.ParseFloat(record[1], 64)
-		high, _ := strconv.ParseFloat(record[2], 64)
-		low, _ := strconv.ParseFloat(record[3], 64)
-		closePrice, _ := strconv.ParseFloat(record[4], 64)
-		volume, _ := strconv.ParseInt(record[5], 10, 64)
-
-		data = append(data, data_types.MarketData{
+		open, high, low, closePrice, volume, err := parseOHLCV(record[1:6])
+		if err != nil {
+			report.Skip(i, raw, err)
+			continue
+		}
+		bar := data_types.MarketData{
 			Timestamp: timestamp.Unix(),
 			Ticker:    symbol,
 			Open:      open,
@@ -76,8 +204,42 @@ func (c *AlphaVantageClient) FetchMinuteData(symbol string, start, end int64, in
 			Low:       low,
 			Close:     closePrice,
 			Volume:    volume,
-		})
+		}
+		if !acceptBar(report, i, raw, bar) || bar.Timestamp < start || bar.Timestamp > end {
+			continue
+		}
+		data = append(data, bar)
 	}
 
-	return data, nil
+	return data, checkSkipped(report, rows, skipped)
+}
+
+// checkAlphaVantage detects the JSON messages that Alpha Vantage returns with status 200 in place of
+// CSV when a request fails.
+func checkAlphaVantage(body []byte) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+	var message map[string]string
+	if err := json.Unmarshal(trimmed, &message); err != nil {
+		return malformedf("unexpected JSON response %q", truncate(string(trimmed), 200))
+	}
+
+	switch {
+	case message["Error Message"] != "":
+		if strings.Contains(strings.ToLower(message["Error Message"]), "apikey") {
+			return fmt.Errorf("%w: %s", data_types.ErrAuth, message["Error Message"])
+		}
+		return fmt.Errorf("%w: %s", data_types.ErrInvalidSymbol, message["Error Message"])
+	case message["Note"] != "":
+		return fmt.Errorf("%w: %s", data_types.ErrRateLimited, message["Note"])
+	case message["Information"] != "":
+		info := strings.ToLower(message["Information"])
+		if strings.Contains(info, "rate limit") || strings.Contains(info, "call frequency") || strings.Contains(info, "requests per") {
+			return fmt.Errorf("%w: %s", data_types.ErrRateLimited, message["Information"])
+		}
+		return fmt.Errorf("%w: %s", data_types.ErrAuth, message["Information"])
+	}
+	return malformedf("unexpected JSON response %q", truncate(string(trimmed), 200))
 }
@@ -0,0 +1,94 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayAlphaVantage returns an AlphaVantageClient replaying a scenario recorded on 2024-03-15.
+func replayAlphaVantage(t *testing.T, scenario string) *AlphaVantageClient {
+	client := NewAlphaVantageClient("")
+	client.HTTP = replayHTTP(t, "alphavantage/"+scenario)
+	client.Now = clockAt(t, "2024-03-15T00:00:00Z")
+	return client
+}
+
+func TestAlphaVantageDaily(t *testing.T) {
+	client := replayAlphaVantage(t, "daily")
+	data, report, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted oldest first, at midnight UTC, without the rows outside the range or with an invalid high
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 187.76, High: 193.898, Low: 185.86, Close: 191.66, Volume: 5962649},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-05T00:00:00Z"), Open: 192, High: 195.2, Low: 191.88, Close: 192.95, Volume: 4102426},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-06T00:00:00Z"), Open: 193.5, High: 198.13, Low: 192.96, Close: 196.16, Volume: 6945818},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-08T00:00:00Z"), Open: 196.06, High: 197.71, Low: 195.32, Close: 195.95, Volume: 3943023},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 2, Raw: "2024-03-07,197.4400,n/a,195.5500,198.7400,3935347"})
+	assertReport(t, report, 1, 7, 4)
+}
+
+func TestAlphaVantageErrors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		want     error
+	}{
+		{"invalid_symbol", data_types.ErrInvalidSymbol},
+		{"auth", data_types.ErrAuth},
+		{"rate_limited", data_types.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		client := replayAlphaVantage(t, tt.scenario)
+		_, _, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.scenario, err, tt.want)
+		}
+	}
+}
+
+func TestAlphaVantageIntradayDST(t *testing.T) {
+	// Intraday timestamps are US/Eastern wall-clock times: the open is 14:30 UTC under standard time
+	// and 13:30 UTC under daylight time
+	tests := []struct {
+		scenario   string
+		start, end string
+		requests   int
+		want       []string
+	}{
+		{
+			// Daylight time starts on Sunday 2024-03-10
+			"intraday_march", "2024-03-08T14:30:00Z", "2024-03-11T13:31:00Z", 1,
+			[]string{"2024-03-08T14:30:00Z", "2024-03-08T20:59:00Z", "2024-03-11T13:30:00Z", "2024-03-11T13:31:00Z"},
+		},
+		{
+			// Daylight time ends on Sunday 2024-11-03. The evening of October 31 in New York is already
+			// November 1 in UTC, and comes with the bars of October.
+			"intraday_november", "2024-10-31T13:30:00Z", "2024-11-04T14:30:00Z", 2,
+			[]string{"2024-10-31T13:30:00Z", "2024-10-31T23:59:00Z", "2024-11-01T08:00:00Z", "2024-11-01T13:30:00Z", "2024-11-04T14:30:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		client := replayAlphaVantage(t, tt.scenario)
+		data, report, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, tt.start), unixAt(t, tt.end), data_types.Interval1m)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.scenario, err)
+		}
+		if len(data) != len(tt.want) {
+			t.Fatalf("%s: got %d bars, want %d", tt.scenario, len(data), len(tt.want))
+		}
+		for i, want := range tt.want {
+			if data[i].Timestamp != unixAt(t, want) {
+				t.Errorf("%s: bar %d at %s, want %s", tt.scenario, i, time.Unix(data[i].Timestamp, 0).UTC().Format(time.RFC3339), want)
+			}
+		}
+		if report.Requests != tt.requests || len(report.Skipped) != 0 {
+			t.Errorf("%s: report %v, want %d requests", tt.scenario, report, tt.requests)
+		}
+	}
+}
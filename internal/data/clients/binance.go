@@ -0,0 +1,237 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// binanceLimit is the most klines the klines endpoint returns per request. Tests lower it to page
+// through small recorded responses.
+var binanceLimit = 1000
+
+// BinanceClient fetches candlesticks from the klines endpoint of Binance or an exchange with the same
+// API. Crypto markets trade around the clock, so bars follow each other without session gaps and daily
+// bars cover UTC days, which matches the midnight UTC timestamps of daily bars from other sources.
+type BinanceClient struct {
+	// BaseURL is the klines endpoint, up to its query parameters.
+	BaseURL string
+	// HTTP performs the requests. The default stays well below the request weight limit of Binance.
+	HTTP *HTTPClient
+	// IncludeUnclosed keeps the kline that is still open at the end of a range, whose prices and volume
+	// change until it closes.
+	IncludeUnclosed bool
+	// Now returns the current time, against which klines are checked for being closed. Nil uses
+	// time.Now; replays of recorded responses set it to the time of the recording.
+	Now func() time.Time
+}
+
+// NewBinanceClient creates a new BinanceClient for api.binance.com, which needs no API key for market data.
+//
+// No parameters.
+// Returns a pointer to a BinanceClient object.
+func NewBinanceClient() *BinanceClient {
+	return &BinanceClient{
+		BaseURL: "https://api.binance.com/api/v3/klines?",
+		HTTP:    NewHTTPClient(DefaultHTTPConfig(1200, 20)),
+	}
+}
+
+// binanceIntervals maps intervals to the interval parameter of the klines endpoint.
+var binanceIntervals = map[data_types.Interval]string{
+	data_types.Interval1m:  "1m",
+	data_types.Interval5m:  "5m",
+	data_types.Interval15m: "15m",
+	data_types.Interval1h:  "1h",
+	data_types.Interval1d:  "1d",
+	data_types.Interval1w:  "1w",
+}
+
+// Capabilities returns the intervals served by Binance, which keeps the full history of every interval.
+func (c *BinanceClient) Capabilities() data_types.Capabilities {
+	return data_types.Capabilities{Intervals: data_types.Intervals}
+}
+
+// Fetch fetches daily klines for a given symbol.
+//
+// Parameter symbol is the trading pair to fetch data for, such as BTCUSDT.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *BinanceClient) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchContext(context.Background(), symbol, start, end)
+}
+
+// FetchContext fetches daily klines for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries and remaining pages.
+// Parameter symbol is the trading pair to fetch data for, such as BTCUSDT.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *BinanceClient) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(ctx, symbol, start, end, data_types.Interval1d)
+}
+
+// FetchBars fetches klines at the given interval for a given symbol.
+//
+// Parameter symbol is the trading pair to fetch data for, such as BTCUSDT.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (c *BinanceClient) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+// FetchBarsContext fetches klines at the given interval for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries and remaining pages.
+// Parameter symbol is the trading pair to fetch data for, such as BTCUSDT.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (c *BinanceClient) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	data, _, err := c.FetchBarsReport(ctx, symbol, start, end, interval)
+	return data, err
+}
+
+// FetchBarsReport fetches klines at the given interval for a given symbol until the context is done and
+// reports the rows it had to skip. Ranges of more than 1000 klines are paged through by moving
+// startTime past the last kline received, which also steps over gaps where the exchange was down.
+//
+// Parameter ctx cancels the request, including its retries and remaining pages.
+// Parameter symbol is the trading pair to fetch data for, such as BTCUSDT.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData with the base asset volume, the report and an error wrapping one of
+// the errors of data_types if Binance rejects the request or its response cannot be parsed.
+func (c *BinanceClient) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("binance", symbol, interval)
+	if err := c.Capabilities().Check(interval, start, clockNow(c.Now)); err != nil {
+		return nil, report, err
+	}
+
+	var data []data_types.MarketData
+	endMillis := end*1000 + 999
+	for cursor := start * 1000; cursor <= endMillis; {
+		if err := ctx.Err(); err != nil {
+			return nil, report, err
+		}
+		url := fmt.Sprintf("%ssymbol=%s&interval=%s&startTime=%d&endTime=%d&limit=%d",
+			c.BaseURL, symbol, binanceIntervals[interval], cursor, endMillis, binanceLimit)
+		page, last, rows, err := c.fetchPage(ctx, url, symbol, &report)
+		if err != nil {
+			return nil, report, err
+		}
+		data = append(data, page...)
+		if rows < binanceLimit || last < cursor {
+			break
+		}
+		cursor = last + 1
+	}
+
+	received := len(data)
+	data = MergeBars(data)
+	report.Duplicates = received - len(data)
+	report.Bars = len(data)
+	return data, report, nil
+}
+
+// fetchPage fetches a page of klines and returns its bars, the open time in milliseconds of its last
+// kline and the number of klines received.
+func (c *BinanceClient) fetchPage(ctx context.Context, url, symbol string, report *data_types.FetchReport) ([]data_types.MarketData, int64, int, error) {
+	body, err := c.HTTP.Get(ctx, url)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("error fetching data: %w", binanceError(err))
+	}
+	report.Requests++
+
+	var klines [][]json.RawMessage
+	if err := json.Unmarshal(body, &klines); err != nil {
+		return nil, 0, 0, malformedf("error unmarshaling JSON: %v", err)
+	}
+
+	var data []data_types.MarketData
+	var last int64
+	skipped := len(report.Skipped)
+	report.Rows += len(klines)
+	now := clockNow(c.Now).UnixMilli()
+	for i, kline := range klines {
+		encoded, _ := json.Marshal(kline)
+		raw := string(encoded)
+		if len(kline) < 7 {
+			report.Skip(i, raw, fmt.Errorf("expected at least 7 fields, got %d", len(kline)))
+			continue
+		}
+		var openTime, closeTime int64
+		if err := json.Unmarshal(kline[0], &openTime); err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid open time %s", kline[0]))
+			continue
+		}
+		last = max(last, openTime)
+		if err := json.Unmarshal(kline[6], &closeTime); err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid close time %s", kline[6]))
+			continue
+		}
+
+		fields := make([]string, 5)
+		for j := range fields {
+			if err := json.Unmarshal(kline[j+1], &fields[j]); err != nil {
+				fields[j] = string(kline[j+1])
+			}
+		}
+		open, high, low, closePrice, volume, err := parseOHLCV(fields)
+		if err != nil {
+			report.Skip(i, raw, err)
+			continue
+		}
+		bar := data_types.MarketData{
+			Ticker:    symbol,
+			Timestamp: openTime / 1000,
+			Open:      open,
+			High:      high,
+			Low:       low,
+			Close:     closePrice,
+			Volume:    volume,
+		}
+		if !acceptBar(report, i, raw, bar) {
+			continue
+		}
+		if closeTime >= now && !c.IncludeUnclosed {
+			continue
+		}
+		data = append(data, bar)
+	}
+	return data, last, len(klines), checkSkipped(report, len(klines), skipped)
+}
+
+// binanceError maps the error codes in the body of a rejected request to the errors of data_types.
+// Binance answers 418 once an IP is banned for ignoring rate limits.
+func binanceError(err error) error {
+	var status *StatusError
+	if !errors.As(err, &status) {
+		return err
+	}
+	if status.StatusCode == http.StatusTeapot {
+		return fmt.Errorf("%w: %w", data_types.ErrRateLimited, err)
+	}
+	var body struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal([]byte(status.Body), &body) != nil {
+		return err
+	}
+	switch body.Code {
+	case -1121:
+		return fmt.Errorf("%w: %s", data_types.ErrInvalidSymbol, body.Msg)
+	case -1003:
+		return fmt.Errorf("%w: %s", data_types.ErrRateLimited, body.Msg)
+	case -2014, -2015:
+		return fmt.Errorf("%w: %s", data_types.ErrAuth, body.Msg)
+	}
+	return fmt.Errorf("%w: binance error %d: %s", err, body.Code, body.Msg)
+}
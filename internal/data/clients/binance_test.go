@@ -0,0 +1,110 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayBinance returns a BinanceClient replaying a scenario recorded at 00:03:30 UTC on 2024-03-01,
+// while the kline of 00:03 was still open.
+func replayBinance(t *testing.T, scenario string) *BinanceClient {
+	client := NewBinanceClient()
+	client.HTTP = replayHTTP(t, "binance/"+scenario)
+	client.Now = clockAt(t, "2024-03-01T00:03:30Z")
+	return client
+}
+
+func TestBinanceKlines(t *testing.T) {
+	client := replayBinance(t, "klines")
+	data, report, err := client.FetchBarsReport(context.Background(), "BTCUSDT", unixAt(t, "2024-03-01T00:00:00Z"), unixAt(t, "2024-03-01T00:03:00Z"), data_types.Interval1m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The kline of 00:02 has its high below its low and the kline of 00:03 has not closed
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "BTCUSDT", Timestamp: unixAt(t, "2024-03-01T00:00:00Z"), Open: 61130.98, High: 61200, Low: 61106.01, Close: 61182.01, Volume: 28.91451},
+		{Ticker: "BTCUSDT", Timestamp: unixAt(t, "2024-03-01T00:01:00Z"), Open: 61182.01, High: 61226.32, Low: 61161.84, Close: 61205.21, Volume: 21.39474},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 2, Raw: `[1709251320000,"61205.21000000","61150.00000000","61180.00000000","61171.88000000","19.02361000",1709251379999,"1163827.48019130",1210,"8.37712000","512510.00937720","0"]`})
+	assertReport(t, report, 1, 4, 2)
+
+	client = replayBinance(t, "klines")
+	client.IncludeUnclosed = true
+	data, _, err = client.FetchBarsReport(context.Background(), "BTCUSDT", unixAt(t, "2024-03-01T00:00:00Z"), unixAt(t, "2024-03-01T00:03:00Z"), data_types.Interval1m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 || data[2].Timestamp != unixAt(t, "2024-03-01T00:03:00Z") {
+		t.Errorf("IncludeUnclosed: got %+v, want the kline of 00:03 last", data)
+	}
+}
+
+func TestBinanceErrors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		want     error
+	}{
+		{"invalid_symbol", data_types.ErrInvalidSymbol},
+		{"banned", data_types.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		client := replayBinance(t, tt.scenario)
+		_, _, err := client.FetchBarsReport(context.Background(), "BTCUSDT", unixAt(t, "2024-03-01T00:00:00Z"), unixAt(t, "2024-03-01T00:03:00Z"), data_types.Interval1m)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.scenario, err, tt.want)
+		}
+	}
+}
+
+func TestBinancePaging(t *testing.T) {
+	// With three klines a request, each page starts a millisecond after the open of the last kline of the
+	// previous page, and paging stops at a short page or at a page that does not move past the cursor
+	defer func(limit int) { binanceLimit = limit }(binanceLimit)
+	binanceLimit = 3
+	tests := []struct {
+		scenario   string
+		startTimes []string
+		rows       int
+		bars       int
+		duplicates int
+	}{
+		// Binance selects klines by open time, so pages do not overlap
+		{"pages", []string{"1709251200000", "1709251320001", "1709251500001"}, 8, 8, 0},
+		// An exchange selecting klines by close time returns the last kline of each full page again
+		{"pages_close_time", []string{"1709251200000", "1709251320001", "1709251440001", "1709251560001"}, 11, 8, 3},
+		// An exchange ignoring startTime returns the first page again, which ends the paging
+		{"ignores_start", []string{"1709251200000", "1709251320001"}, 6, 3, 3},
+	}
+	for _, tt := range tests {
+		client := replayBinance(t, tt.scenario)
+		client.Now = clockAt(t, "2024-03-01T00:10:00Z")
+		var startTimes []string
+		replay := client.HTTP.Client.Transport
+		client.HTTP.Client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			startTimes = append(startTimes, req.URL.Query().Get("startTime"))
+			return replay.RoundTrip(req)
+		})
+		data, report, err := client.FetchBarsReport(context.Background(), "BTCUSDT", unixAt(t, "2024-03-01T00:00:00Z"), unixAt(t, "2024-03-01T00:07:00Z"), data_types.Interval1m)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.scenario, err)
+		}
+		if strings.Join(startTimes, ",") != strings.Join(tt.startTimes, ",") {
+			t.Errorf("%s: got start times %v, want %v", tt.scenario, startTimes, tt.startTimes)
+		}
+		assertReport(t, report, len(tt.startTimes), tt.rows, tt.bars)
+		if report.Duplicates != tt.duplicates {
+			t.Errorf("%s: got %d duplicates, want %d", tt.scenario, report.Duplicates, tt.duplicates)
+		}
+		// Consecutive minutes from 00:00, once each
+		for i, bar := range data {
+			if bar.Timestamp != unixAt(t, "2024-03-01T00:00:00Z")+int64(60*i) {
+				t.Errorf("%s: bar %d at %d", tt.scenario, i, bar.Timestamp)
+			}
+		}
+	}
+}
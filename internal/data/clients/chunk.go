@@ -0,0 +1,142 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// Chunk is a range of Unix timestamps, inclusive at both ends, that a provider serves in one request.
+type Chunk struct {
+	Start int64
+	End   int64
+}
+
+// SplitRange splits the range from start to end into consecutive chunks of at most span each.
+//
+// Parameters:
+// - start, end: The Unix timestamps of the range, inclusive.
+// - span: The longest range a single request returns. Zero or less returns the whole range as one chunk.
+// Returns the chunks, oldest first, or nil if end is before start.
+func SplitRange(start, end int64, span time.Duration) []Chunk {
+	if end < start {
+		return nil
+	}
+	step := int64(span / time.Second)
+	if step <= 0 {
+		return []Chunk{{Start: start, End: end}}
+	}
+	var chunks []Chunk
+	for from := start; from <= end; from += step {
+		chunks = append(chunks, Chunk{Start: from, End: min(from+step-1, end)})
+	}
+	return chunks
+}
+
+// splitMonths splits the range from start to end at calendar month boundaries in the location.
+func splitMonths(start, end int64, loc *time.Location) []Chunk {
+	if end < start {
+		return nil
+	}
+	var chunks []Chunk
+	from := time.Unix(start, 0).In(loc)
+	for from.Unix() <= end {
+		next := time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, loc)
+		chunks = append(chunks, Chunk{Start: from.Unix(), End: min(next.Unix()-1, end)})
+		from = next
+	}
+	return chunks
+}
+
+// MergeBars sorts bars by ticker and timestamp and removes duplicates, as returned by overlapping
+// chunks. Of several bars with the same ticker and timestamp the one that came last is kept.
+//
+// Parameters:
+// - data: The bars in any order. The slice is reordered in place.
+// Returns the sorted bars without duplicates.
+func MergeBars(data []data_types.MarketData) []data_types.MarketData {
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].Ticker != data[j].Ticker {
+			return data[i].Ticker < data[j].Ticker
+		}
+		return data[i].Timestamp < data[j].Timestamp
+	})
+	merged := data[:0]
+	for _, bar := range data {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Ticker == bar.Ticker && merged[last].Timestamp == bar.Timestamp {
+			merged[last] = bar
+			continue
+		}
+		merged = append(merged, bar)
+	}
+	return merged
+}
+
+// fetchChunks fetches every chunk with at most concurrency requests in flight and merges the results
+// and their reports into report. The first error cancels the remaining chunks and is returned. Rate
+// limits are left to the HTTPClient of the caller, which all chunks share.
+func fetchChunks(ctx context.Context, chunks []Chunk, concurrency int, report *data_types.FetchReport, fetch func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error)) ([]data_types.MarketData, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]data_types.MarketData, len(chunks))
+	reports := make([]data_types.FetchReport, len(chunks))
+	errs := make([]error, len(chunks))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, chunk Chunk) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], errs[i] = fetch(ctx, chunk, &reports[i])
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	// Report the error that caused the cancellation rather than the cancellations it caused
+	for _, chunkReport := range reports {
+		report.Merge(chunkReport)
+	}
+
+	var first error
+	for _, err := range errs {
+		if err != nil && (first == nil || errors.Is(first, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			first = err
+		}
+	}
+	if first != nil {
+		return nil, first
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var data []data_types.MarketData
+	for _, result := range results {
+		data = append(data, result...)
+	}
+	received := len(data)
+	data = MergeBars(data)
+	report.Duplicates += received - len(data)
+	report.Bars = len(data)
+	return data, nil
+}
@@ -0,0 +1,179 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+func TestSplitRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int64
+		span       time.Duration
+		want       []Chunk
+	}{
+		{"exact", 0, 299, 100 * time.Second, []Chunk{{0, 99}, {100, 199}, {200, 299}}},
+		// The last chunk ends at the end of the range
+		{"remainder", 0, 250, 100 * time.Second, []Chunk{{0, 99}, {100, 199}, {200, 250}}},
+		{"single instant", 50, 50, 100 * time.Second, []Chunk{{50, 50}}},
+		{"no span", 0, 250, 0, []Chunk{{0, 250}}},
+		// Spans shorter than a second cannot be split at second granularity
+		{"subsecond span", 0, 250, time.Millisecond, []Chunk{{0, 250}}},
+		{"end before start", 100, 99, time.Second, nil},
+	}
+	for _, tt := range tests {
+		got := SplitRange(tt.start, tt.end, tt.span)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMergeBars(t *testing.T) {
+	// Sorted by ticker and timestamp, keeping the bar of each pair that came last
+	got := MergeBars([]data_types.MarketData{
+		{Ticker: "MSFT", Timestamp: 60, Close: 1},
+		{Ticker: "IBM", Timestamp: 120, Close: 2},
+		{Ticker: "IBM", Timestamp: 60, Close: 3},
+		{Ticker: "IBM", Timestamp: 120, Close: 4},
+		{Ticker: "MSFT", Timestamp: 60, Close: 5},
+		{Ticker: "IBM", Timestamp: 120, Close: 6},
+	})
+	want := []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: 60, Close: 3},
+		{Ticker: "IBM", Timestamp: 120, Close: 6},
+		{Ticker: "MSFT", Timestamp: 60, Close: 5},
+	}
+	assertBars(t, got, want)
+}
+
+// chunkBars returns a bar every 60 seconds of a chunk, closing at the index of the chunk.
+func chunkBars(chunk Chunk, index int) []data_types.MarketData {
+	var bars []data_types.MarketData
+	for timestamp := chunk.Start; timestamp <= chunk.End; timestamp += 60 {
+		bars = append(bars, data_types.MarketData{Ticker: "IBM", Timestamp: timestamp, Close: float64(index)})
+	}
+	return bars
+}
+
+func TestFetchChunksMerge(t *testing.T) {
+	// Overlapping chunks fetched concurrently and in any order are merged oldest first, the later chunk
+	// winning each overlap
+	chunks := []Chunk{{0, 180}, {120, 300}, {300, 420}}
+	var inFlight, most atomic.Int32
+	report := data_types.FetchReport{Source: "test"}
+	data, err := fetchChunks(context.Background(), chunks, 3, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// The first chunk finishes last
+		if chunk.Start == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		bars := chunkBars(chunk, int(chunk.Start))
+		report.Requests++
+		report.Rows += len(bars)
+		return bars, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		timestamp int64
+		close     float64
+	}{{0, 0}, {60, 0}, {120, 120}, {180, 120}, {240, 120}, {300, 300}, {360, 300}, {420, 300}}
+	if len(data) != len(want) {
+		t.Fatalf("got %d bars, want %d: %+v", len(data), len(want), data)
+	}
+	for i, w := range want {
+		if data[i].Timestamp != w.timestamp || data[i].Close != w.close {
+			t.Errorf("bar %d: got %d closing at %v, want %d closing at %v", i, data[i].Timestamp, data[i].Close, w.timestamp, w.close)
+		}
+	}
+	assertReport(t, report, 3, 11, 8)
+	if report.Duplicates != 3 {
+		t.Errorf("got %d duplicates, want 3", report.Duplicates)
+	}
+	if most.Load() < 2 {
+		t.Errorf("at most %d chunks in flight, want concurrent fetches", most.Load())
+	}
+}
+
+func TestFetchChunksFirstError(t *testing.T) {
+	// The failure of the second chunk cancels the first, and its error is returned rather than the
+	// cancellation it caused
+	failure := errors.New("chunk failed")
+	var report data_types.FetchReport
+	_, err := fetchChunks(context.Background(), []Chunk{{0, 59}, {60, 119}}, 2, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+		if chunk.Start == 60 {
+			return nil, failure
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("got %v, want the error of the second chunk", err)
+	}
+
+	// Chunks that are not started yet when a chunk fails are not fetched
+	var fetched atomic.Int32
+	_, err = fetchChunks(context.Background(), SplitRange(0, 599, time.Minute), 1, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+		fetched.Add(1)
+		return nil, data_types.ErrRateLimited
+	})
+	if !errors.Is(err, data_types.ErrRateLimited) || fetched.Load() != 1 {
+		t.Errorf("got %v after %d chunks, want ErrRateLimited after 1", err, fetched.Load())
+	}
+
+	// A cancelled caller gets its cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fetchChunks(ctx, []Chunk{{0, 59}}, 1, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+		return chunkBars(chunk, 0), nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: got %v, want context.Canceled", err)
+	}
+}
+func TestSplitMonthsNewYork(t *testing.T) {
+	// Months start at midnight in New York, at 04:00 UTC under daylight time and 05:00 UTC under
+	// standard time, so that November 2024 is an hour longer than 30 days
+	chunks := splitMonths(unixAt(t, "2024-10-31T13:30:00Z"), unixAt(t, "2024-12-02T14:30:00Z"), newYork)
+	want := []struct{ start, end string }{
+		{"2024-10-31T13:30:00Z", "2024-11-01T03:59:59Z"},
+		{"2024-11-01T04:00:00Z", "2024-12-01T04:59:59Z"},
+		{"2024-12-01T05:00:00Z", "2024-12-02T14:30:00Z"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(want), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Start != unixAt(t, want[i].start) || chunk.End != unixAt(t, want[i].end) {
+			t.Errorf("chunk %d: got %s to %s, want %s to %s", i,
+				time.Unix(chunk.Start, 0).UTC().Format(time.RFC3339), time.Unix(chunk.End, 0).UTC().Format(time.RFC3339), want[i].start, want[i].end)
+		}
+	}
+
+	// A range within a single month in New York is a single chunk, even across a UTC month boundary
+	chunks = splitMonths(unixAt(t, "2024-03-31T20:00:00Z"), unixAt(t, "2024-04-01T03:00:00Z"), newYork)
+	if len(chunks) != 1 {
+		t.Errorf("got %d chunks, want 1: %+v", len(chunks), chunks)
+	}
+}
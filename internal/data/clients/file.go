@@ -0,0 +1,650 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// FileFormat is the format of a file read by FileDataSource.
+type FileFormat string
+
+const (
+	FormatCSV     FileFormat = "csv"
+	FormatJSONL   FileFormat = "jsonl"
+	FormatParquet FileFormat = "parquet"
+)
+
+// fileExtensions maps file extensions to formats.
+var fileExtensions = map[string]FileFormat{
+	".csv":     FormatCSV,
+	".tsv":     FormatCSV,
+	".txt":     FormatCSV,
+	".jsonl":   FormatJSONL,
+	".ndjson":  FormatJSONL,
+	".parquet": FormatParquet,
+}
+
+// ColumnMapping names the columns that hold the fields of a bar. Names are matched case-insensitively.
+// An empty name falls back to common spellings, such as "date", "datetime" or "time" for the timestamp
+// and "o" or "adj close" for prices. For CSV files without a header, names are 0-based column indexes
+// and the default order is timestamp, open, high, low, close, volume, without a time column.
+type ColumnMapping struct {
+	Timestamp string
+	Open      string
+	High      string
+	Low       string
+	Close     string
+	// Volume is optional; bars of files without it have zero volume.
+	Volume string
+	// Ticker is optional; without it every row belongs to the requested symbol.
+	Ticker string
+	// Time is optional; it holds the time of day of bars whose timestamp column only holds their date,
+	// as in vendor dumps with separate Date and Time columns. By default a "time" column other than the
+	// timestamp column is used.
+	Time string
+}
+
+// The fields of a bar, in the order of their default CSV columns.
+const (
+	fieldTimestamp = iota
+	fieldOpen
+	fieldHigh
+	fieldLow
+	fieldClose
+	fieldVolume
+	fieldTicker
+	fieldTime
+	fieldCount
+)
+
+// fieldNames are the names of the fields in error messages.
+var fieldNames = [fieldCount]string{"timestamp", "open", "high", "low", "close", "volume", "ticker", "time"}
+
+// defaultColumns are the spellings tried for a field without a configured name.
+var defaultColumns = [fieldCount][]string{
+	{"timestamp", "date", "datetime", "time", "t"},
+	{"open", "o"},
+	{"high", "h"},
+	{"low", "l"},
+	{"close", "c", "adj close", "adj_close"},
+	{"volume", "vol", "v"},
+	{"ticker", "symbol"},
+	{"time"},
+}
+
+// names returns the configured name of every field.
+func (m ColumnMapping) names() [fieldCount]string {
+	return [fieldCount]string{m.Timestamp, m.Open, m.High, m.Low, m.Close, m.Volume, m.Ticker, m.Time}
+}
+
+// resolve finds the index of every field in a header. Missing optional fields have index -1.
+func (m ColumnMapping) resolve(header []string) ([fieldCount]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := positions[name]; !ok {
+			positions[name] = i
+		}
+	}
+
+	var columns [fieldCount]int
+	for field, name := range m.names() {
+		columns[field] = -1
+		candidates := defaultColumns[field]
+		if name != "" {
+			candidates = []string{name}
+		}
+		for _, candidate := range candidates {
+			// The time of day is a column of its own, never the timestamp itself
+			if i, ok := positions[strings.ToLower(candidate)]; ok && (field != fieldTime || i != columns[fieldTimestamp]) {
+				columns[field] = i
+				break
+			}
+		}
+		if columns[field] < 0 && field < fieldVolume {
+			return columns, malformedf("no %s column among %v", fieldNames[field], header)
+		}
+	}
+	return columns, nil
+}
+
+// resolveIndexes reads the fields of a file without a header from column indexes.
+func (m ColumnMapping) resolveIndexes() ([fieldCount]int, error) {
+	var columns [fieldCount]int
+	for field, name := range m.names() {
+		columns[field] = -1
+		if name == "" {
+			if field < fieldTicker {
+				columns[field] = field
+			}
+			continue
+		}
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 {
+			return columns, fmt.Errorf("%s column must be an index in a file without a header, got %q", fieldNames[field], name)
+		}
+		columns[field] = i
+	}
+	return columns, nil
+}
+
+// FileDataSource reads bars from files on disk, such as vendor dumps, instead of a provider's API.
+// Path is either a single file holding one or more symbols, or a directory holding one file per symbol
+// named after it, such as AAPL.csv, or a subdirectory per symbol, such as AAPL/2023.parquet, whose files
+// are read in name order.
+type FileDataSource struct {
+	Path string
+	// Format is the format of every file. Empty detects it from the extension: .csv, .tsv and .txt are
+	// CSV, .jsonl and .ndjson are JSON lines and .parquet is Parquet.
+	Format  FileFormat
+	Columns ColumnMapping
+	// TimeFormats are the layouts tried for text timestamps, in order. Numeric timestamps are Unix times
+	// in seconds, milliseconds, microseconds or nanoseconds, told apart by their magnitude.
+	TimeFormats []string
+	// Location is the time zone of text timestamps without one, and the exchange time zone whose dates
+	// text timestamps of daily and weekly bars are re-stamped to, as midnight UTC. Numeric and Parquet
+	// timestamps are instants and are used as they are, whatever the Location.
+	Location *time.Location
+	// Delimiter separates CSV columns. Zero uses a tab for .tsv files and a comma otherwise.
+	Delimiter rune
+	// Comment starts CSV lines that are ignored. Zero disables comments.
+	Comment rune
+	// NoHeader reads CSV files without a header row, with Columns holding column indexes.
+	NoHeader bool
+	// Interval is the interval of the bars in the files.
+	Interval data_types.Interval
+	// Now returns the current time, against which the history limits of intervals are checked. Nil uses
+	// time.Now.
+	Now func() time.Time
+}
+
+// DefaultTimeFormats are the timestamp layouts FileDataSource tries unless configured otherwise.
+var DefaultTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+	"20060102",
+	"2-Jan-06",
+	"2-Jan-2006",
+}
+
+// NewFileDataSource creates a FileDataSource for daily bars that detects formats and columns.
+//
+// Parameter path is a file or a directory of files per symbol.
+// Returns a pointer to a FileDataSource object.
+func NewFileDataSource(path string) *FileDataSource {
+	return &FileDataSource{
+		Path:        path,
+		TimeFormats: DefaultTimeFormats,
+		Location:    time.UTC,
+		Interval:    data_types.Interval1d,
+	}
+}
+
+// Capabilities returns the interval of the bars in the files.
+func (s *FileDataSource) Capabilities() data_types.Capabilities {
+	return data_types.Capabilities{Intervals: []data_types.Interval{s.Interval}}
+}
+
+// Fetch reads the bars of a symbol.
+//
+// Parameter symbol is the symbol to read bars for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (s *FileDataSource) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return s.FetchContext(context.Background(), symbol, start, end)
+}
+
+// FetchContext reads the bars of a symbol until the context is done.
+//
+// Parameter ctx cancels reading between files and rows.
+// Parameter symbol is the symbol to read bars for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (s *FileDataSource) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return s.FetchBarsContext(ctx, symbol, start, end, s.Interval)
+}
+
+// FetchBars reads the bars of a symbol, which must be at the interval of the files.
+//
+// Parameter symbol is the symbol to read bars for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (s *FileDataSource) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	return s.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+// FetchBarsContext reads the bars of a symbol, which must be at the interval of the files, until the
+// context is done.
+//
+// Parameter ctx cancels reading between files and rows.
+// Parameter symbol is the symbol to read bars for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (s *FileDataSource) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	data, _, err := s.FetchBarsReport(ctx, symbol, start, end, interval)
+	return data, err
+}
+
+// FetchBarsReport reads the bars of a symbol between start and end and reports the rows it had to skip.
+// Rows of other tickers are ignored; the result is sorted and free of duplicates.
+//
+// Parameter ctx cancels reading between files and rows.
+// Parameter symbol is the symbol to read bars for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval, which must be the interval of the files.
+// Returns a slice of MarketData, the report and an error wrapping data_types.ErrInvalidSymbol if no
+// file holds the symbol or data_types.ErrMalformedPayload if a file cannot be parsed.
+func (s *FileDataSource) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("file", symbol, interval)
+	if err := s.Capabilities().Check(interval, start, clockNow(s.Now)); err != nil {
+		return nil, report, err
+	}
+
+	paths, err := s.files(symbol)
+	if err != nil {
+		return nil, report, err
+	}
+	var data []data_types.MarketData
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, report, err
+		}
+		bars, err := s.readFile(ctx, path, symbol, start, end, &report)
+		if err != nil {
+			return nil, report, fmt.Errorf("%s: %w", path, err)
+		}
+		data = append(data, bars...)
+	}
+
+	received := len(data)
+	data = MergeBars(data)
+	report.Duplicates = received - len(data)
+	report.Bars = len(data)
+	return data, report, nil
+}
+
+// files returns the files that hold the bars of a symbol.
+func (s *FileDataSource) files(symbol string) ([]string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{s.Path}, nil
+	}
+
+	entries, err := os.ReadDir(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir() && strings.EqualFold(name, symbol):
+			files, err := os.ReadDir(filepath.Join(s.Path, name))
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if !file.IsDir() && s.readable(file.Name()) {
+					paths = append(paths, filepath.Join(s.Path, name, file.Name()))
+				}
+			}
+		case !entry.IsDir() && s.readable(name) && strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), symbol):
+			paths = append(paths, filepath.Join(s.Path, name))
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: no files for %s in %s", data_types.ErrInvalidSymbol, symbol, s.Path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readable reports whether a file has a format that can be read.
+func (s *FileDataSource) readable(name string) bool {
+	if s.Format != "" {
+		return true
+	}
+	_, ok := fileExtensions[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// readFile reads the bars of a symbol between start and end from a file.
+func (s *FileDataSource) readFile(ctx context.Context, path, symbol string, start, end int64, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+	format := s.Format
+	if format == "" {
+		format = fileExtensions[strings.ToLower(filepath.Ext(path))]
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	report.Requests++
+
+	var data []data_types.MarketData
+	rows, invalid := 0, 0
+	emit := func(row int, raw func() string, values []any, columns [fieldCount]int, err error) error {
+		rows++
+		if rows%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		var bar data_types.MarketData
+		if err == nil {
+			bar, err = s.convertRow(values, columns, symbol)
+		}
+		if err != nil {
+			report.Skip(row, raw(), err)
+			invalid++
+			return nil
+		}
+		if !strings.EqualFold(bar.Ticker, symbol) || bar.Timestamp < start || bar.Timestamp > end {
+			return nil
+		}
+		if err := bar.Validate(); err != nil {
+			report.Skip(row, raw(), err)
+			invalid++
+			return nil
+		}
+		bar.Ticker = symbol
+		data = append(data, bar)
+		return nil
+	}
+
+	switch format {
+	case FormatCSV:
+		err = s.readCSV(file, path, emit)
+	case FormatJSONL:
+		err = s.readJSONL(file, emit)
+	case FormatParquet:
+		err = s.readParquet(file, emit)
+	default:
+		err = fmt.Errorf("unknown file format %q", format)
+	}
+	report.Rows += rows
+	if err != nil {
+		return nil, err
+	}
+	if rows > 0 && invalid == rows {
+		return nil, malformedf("all %d rows are invalid, first: %v", rows, report.Skipped[len(report.Skipped)-rows].Err)
+	}
+	return data, nil
+}
+
+// rowFunc receives a row of a file as values of the columns found by resolving its header, or the
+// error that kept the row from being read. raw formats the row for the report if it is skipped.
+type rowFunc func(row int, raw func() string, values []any, columns [fieldCount]int, err error) error
+
+// readCSV reads the rows of a CSV file.
+func (s *FileDataSource) readCSV(r io.Reader, path string, emit rowFunc) error {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	reader.Comma = s.Delimiter
+	if reader.Comma == 0 {
+		reader.Comma = ','
+		if strings.EqualFold(filepath.Ext(path), ".tsv") {
+			reader.Comma = '\t'
+		}
+	}
+	reader.Comment = s.Comment
+
+	var columns [fieldCount]int
+	var err error
+	if s.NoHeader {
+		columns, err = s.Columns.resolveIndexes()
+	} else {
+		var header []string
+		header, err = reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return malformedf("error reading CSV header: %v", err)
+		}
+		columns, err = s.Columns.resolve(header)
+	}
+	if err != nil {
+		return err
+	}
+
+	values := make([]any, 0, 8)
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return malformedf("error reading CSV: %v", err)
+		}
+		values = values[:0]
+		for _, field := range record {
+			values = append(values, field)
+		}
+		raw := func() string { return strings.Join(record, string(reader.Comma)) }
+		if err := emit(row, raw, values, columns, nil); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSONL reads the objects of a JSON lines file, one per line.
+func (s *FileDataSource) readJSONL(r io.Reader, emit rowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for row := 0; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		raw := func() string { return line }
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			if err := emit(row, raw, nil, [fieldCount]int{}, fmt.Errorf("invalid JSON: %v", err)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header := make([]string, 0, len(object))
+		values := make([]any, 0, len(object))
+		for key, value := range object {
+			header = append(header, key)
+			values = append(values, value)
+		}
+		columns, err := s.Columns.resolve(header)
+		if err := emit(row, raw, values, columns, err); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return malformedf("error reading JSON lines: %v", err)
+	}
+	return nil
+}
+
+// convertRow converts the values of a row to a bar. Rows without a ticker column belong to symbol.
+func (s *FileDataSource) convertRow(values []any, columns [fieldCount]int, symbol string) (data_types.MarketData, error) {
+	value := func(field int) any {
+		if columns[field] < 0 || columns[field] >= len(values) {
+			return nil
+		}
+		return values[columns[field]]
+	}
+
+	bar := data_types.MarketData{Ticker: symbol}
+	timestamp, err := s.toTime(value(fieldTimestamp), value(fieldTime))
+	if err != nil {
+		return bar, err
+	}
+	bar.Timestamp = timestamp.Unix()
+	prices := []*float64{&bar.Open, &bar.High, &bar.Low, &bar.Close}
+	for i, price := range prices {
+		if *price, err = toFloat(value(fieldOpen + i)); err != nil {
+			return bar, fmt.Errorf("invalid %s: %w", fieldNames[fieldOpen+i], err)
+		}
+	}
+	if volume := value(fieldVolume); volume != nil {
+		v, err := toFloat(volume)
+		if err != nil {
+			return bar, fmt.Errorf("invalid volume: %w", err)
+		}
+		bar.Volume = v
+	}
+	if ticker := value(fieldTicker); ticker != nil {
+		bar.Ticker = strings.TrimSpace(fmt.Sprint(ticker))
+	}
+	return bar, nil
+}
+
+// toFloat converts a number or its text to a float64.
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, errors.New("missing value")
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", ""), 64)
+	default:
+		return 0, fmt.Errorf("unexpected value %v", value)
+	}
+}
+
+// toTime converts a timestamp, a Unix time or its text to a time, on the time of day of a separate time
+// column if the file has one. Text timestamps of bars that are not intraday become midnight UTC of their
+// date in Location, the convention of daily bars, while those of intraday bars must have a time of day.
+func (s *FileDataSource) toTime(value, timeOfDay any) (time.Time, error) {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	var t time.Time
+	clock := timeOfDay != nil
+	switch v := value.(type) {
+	case nil:
+		return time.Time{}, errors.New("missing timestamp")
+	case time.Time:
+		if timeOfDay == nil {
+			return v, nil
+		}
+		// A date column, stored as its midnight UTC
+		year, month, day := v.UTC().Date()
+		t = time.Date(year, month, day, 0, 0, 0, 0, loc)
+	case string:
+		v = strings.TrimSpace(v)
+		formats := s.TimeFormats
+		if len(formats) == 0 {
+			formats = DefaultTimeFormats
+		}
+		parsed := false
+		for _, layout := range formats {
+			if t, parsed = parseInLocation(layout, v, loc); parsed {
+				clock = clock || hasClock(layout)
+				break
+			}
+		}
+		if !parsed {
+			if n, err := strconv.ParseFloat(v, 64); err == nil && timeOfDay == nil {
+				return unixTime(n), nil
+			}
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+		}
+	default:
+		n, err := toFloat(value)
+		if err != nil || timeOfDay != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %v", value)
+		}
+		return unixTime(n), nil
+	}
+
+	if !s.Interval.Intraday() {
+		return sessionDate(t, loc), nil
+	}
+	if !clock {
+		return time.Time{}, fmt.Errorf("timestamp %v has no time of day for %s bars", value, s.Interval)
+	}
+	if timeOfDay != nil {
+		clockTime, err := parseTimeOfDay(timeOfDay)
+		if err != nil {
+			return time.Time{}, err
+		}
+		year, month, day := t.Date()
+		t = time.Date(year, month, day, clockTime.Hour(), clockTime.Minute(), clockTime.Second(), clockTime.Nanosecond(), loc)
+	}
+	return t, nil
+}
+
+// timeOfDayFormats are the layouts tried for the values of a time column.
+var timeOfDayFormats = []string{"15:04:05.999999999", "15:04", "3:04:05 PM", "3:04 PM", "150405", "1504"}
+
+// parseTimeOfDay parses the value of a time column.
+func parseTimeOfDay(value any) (time.Time, error) {
+	if text, ok := value.(string); ok {
+		for _, layout := range timeOfDayFormats {
+			if t, ok := parseInLocation(layout, strings.TrimSpace(text), time.UTC); ok {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time of day %v", value)
+}
+
+// parseInLocation parses a time like time.ParseInLocation and reports whether it succeeded.
+func parseInLocation(layout, value string, loc *time.Location) (time.Time, bool) {
+	t, err := time.ParseInLocation(layout, value, loc)
+	return t, err == nil
+}
+
+// hasClock reports whether a layout holds a time of day.
+func hasClock(layout string) bool {
+	return strings.Contains(layout, "15") || strings.Contains(layout, "3:04") || strings.Contains(layout, "03:04")
+}
+
+// unixTime converts a Unix time in seconds, milliseconds, microseconds or nanoseconds to a time.
+func unixTime(n float64) time.Time {
+	switch abs := math.Abs(n); {
+	case abs >= 1e17:
+		return time.Unix(0, int64(n))
+	case abs >= 1e14:
+		return time.UnixMicro(int64(n))
+	case abs >= 1e11:
+		return time.UnixMilli(int64(n))
+	default:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9))
+	}
+}
@@ -0,0 +1,193 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// testFile returns a FileDataSource reading a file or directory of testdata/files.
+func testFile(name string) *FileDataSource {
+	return NewFileDataSource(filepath.Join("testdata", "files", name))
+}
+
+// fetchFile reads the bars of a symbol between two RFC 3339 times.
+func fetchFile(t *testing.T, source *FileDataSource, symbol, start, end string) ([]data_types.MarketData, data_types.FetchReport) {
+	t.Helper()
+	data, report, err := source.FetchBarsReport(context.Background(), symbol, unixAt(t, start), unixAt(t, end), source.Interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, report
+}
+
+func TestFileColumnMapping(t *testing.T) {
+	// Vendor column names, a byte order mark, thousands separators and a ticker column
+	source := testFile("mapped.csv")
+	source.Columns = ColumnMapping{Timestamp: "trade date", Open: "Px Open", High: "px high", Low: "PX LOW", Close: "Px Last", Volume: "Shares", Ticker: "Sym"}
+	data, report := fetchFile(t, source, "IBM", "2024-03-04T00:00:00Z", "2024-03-07T00:00:00Z")
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 187.76, High: 193.898, Low: 185.86, Close: 191.66, Volume: 5962649},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-05T00:00:00Z"), Open: 192, High: 195.2, Low: 191.88, Close: 192.95, Volume: 4102426},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-07T00:00:00Z"), Open: 197.44, High: 198.25, Low: 195.55, Close: 198.74, Volume: 3935347},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 3, Raw: "2024-03-06,n/a,198.13,192.96,196.16,6,945,818,IBM"})
+	assertReport(t, report, 1, 5, 3)
+
+	// Without the mapping the default spellings do not match the vendor's
+	_, _, err := testFile("mapped.csv").FetchBarsReport(context.Background(), "IBM", 0, unixAt(t, "2024-03-07T00:00:00Z"), data_types.Interval1d)
+	if !errors.Is(err, data_types.ErrMalformedPayload) {
+		t.Errorf("default columns: got %v, want ErrMalformedPayload", err)
+	}
+}
+
+func TestFileNoHeader(t *testing.T) {
+	// Column indexes in a semicolon separated file with comments and without a volume column
+	source := testFile("noheader.txt")
+	source.NoHeader = true
+	source.Delimiter = ';'
+	source.Comment = '#'
+	source.Columns = ColumnMapping{Timestamp: "4", Open: "1", High: "2", Low: "3", Close: "0"}
+	data, report := fetchFile(t, source, "IBM", "2024-03-01T00:00:00Z", "2024-03-08T00:00:00Z")
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 187.76, High: 193.898, Low: 185.86, Close: 191.66},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-05T00:00:00Z"), Open: 192, High: 195.2, Low: 191.88, Close: 192.95},
+	})
+	assertReport(t, report, 1, 2, 2)
+
+	source.Columns.Open = "open"
+	if _, _, err := source.FetchBarsReport(context.Background(), "IBM", 0, unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d); err == nil {
+		t.Error("column name in a file without a header: no error")
+	}
+}
+
+func TestFileTSV(t *testing.T) {
+	// Tabs are the default delimiter of .tsv files
+	source := testFile("IBM.tsv")
+	source.Comment = '#'
+	data, report := fetchFile(t, source, "IBM", "2024-03-01T00:00:00Z", "2024-03-08T00:00:00Z")
+	if len(data) != 2 || data[1].Close != 192.95 || data[1].Volume != 4102426 {
+		t.Errorf("got %+v", data)
+	}
+	assertReport(t, report, 1, 2, 2)
+}
+
+func TestFileJSONL(t *testing.T) {
+	// Millisecond timestamps, numbers as numbers or text, an invalid line and another symbol
+	source := testFile("bars.jsonl")
+	source.Interval = data_types.Interval1m
+	data, report := fetchFile(t, source, "BTCUSDT", "2024-03-01T00:00:00Z", "2024-03-01T01:00:00Z")
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "BTCUSDT", Timestamp: unixAt(t, "2024-03-01T00:00:00Z"), Open: 61130.98, High: 61200, Low: 61106.01, Close: 61182.01, Volume: 28.91451},
+		{Ticker: "BTCUSDT", Timestamp: unixAt(t, "2024-03-01T00:01:00Z"), Open: 61182.01, High: 61226.32, Low: 61161.84, Close: 61205.21, Volume: 21.39474},
+		{Ticker: "BTCUSDT", Timestamp: unixAt(t, "2024-03-01T00:04:00Z"), Open: 61171.88, High: 61190.1, Low: 61120, Close: 61140.01, Volume: 6.82113},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 2, Raw: `{"t": 1709251320000, "o": 61205.21`})
+	assertReport(t, report, 1, 5, 3)
+}
+
+func TestFileParquet(t *testing.T) {
+	// Parquet timestamps are instants, used as they are
+	source := testFile("bars.parquet")
+	source.Interval = data_types.Interval1m
+	data, report := fetchFile(t, source, "IBM", "2024-03-04T14:30:00Z", "2024-03-04T15:00:00Z")
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-04T14:30:00Z"), Open: 187.76, High: 188.1, Low: 187.5, Close: 187.9, Volume: 12000},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-04T14:31:00Z"), Open: 187.9, High: 188, Low: 187.7, Close: 187.8, Volume: 8000},
+	})
+	// The bar of 14:32 has its high below its low
+	if len(report.Skipped) != 1 || report.Skipped[0].Row != 3 {
+		t.Errorf("got skipped rows %+v, want row 3", report.Skipped)
+	}
+	assertReport(t, report, 1, 4, 2)
+}
+
+func TestFileDirectory(t *testing.T) {
+	// A file per symbol, or a subdirectory per symbol whose files overlap
+	source := testFile("symbols")
+	data, report := fetchFile(t, source, "IBM", "2024-01-01T00:00:00Z", "2024-12-31T00:00:00Z")
+	if len(data) != 1 || data[0].Timestamp != unixAt(t, "2024-03-04T00:00:00Z") {
+		t.Errorf("IBM: got %+v", data)
+	}
+	assertReport(t, report, 1, 1, 1)
+
+	data, report = fetchFile(t, source, "msft", "2023-01-01T00:00:00Z", "2024-12-31T00:00:00Z")
+	if len(data) != 3 || data[0].Ticker != "msft" || data[2].Timestamp != unixAt(t, "2024-01-03T00:00:00Z") {
+		t.Errorf("MSFT: got %+v", data)
+	}
+	assertReport(t, report, 2, 4, 3)
+	if report.Duplicates != 1 {
+		t.Errorf("MSFT: got %d duplicates, want 1", report.Duplicates)
+	}
+
+	_, _, err := source.FetchBarsReport(context.Background(), "NFLX", 0, unixAt(t, "2024-12-31T00:00:00Z"), data_types.Interval1d)
+	if !errors.Is(err, data_types.ErrInvalidSymbol) {
+		t.Errorf("NFLX: got %v, want ErrInvalidSymbol", err)
+	}
+}
+
+func TestFileDailyRestamping(t *testing.T) {
+	// Text timestamps of daily bars become midnight UTC of their date in New York, while numeric
+	// timestamps are instants used as they are
+	source := testFile("restamp.csv")
+	source.Location = newYork
+	data, _ := fetchFile(t, source, "IBM", "2024-03-01T00:00:00Z", "2024-03-15T00:00:00Z")
+	want := []string{"2024-03-08T00:00:00Z", "2024-03-11T00:00:00Z", "2024-03-12T00:00:00Z", "2024-03-14T20:00:00Z"}
+	if len(data) != len(want) {
+		t.Fatalf("got %d bars, want %d", len(data), len(want))
+	}
+	for i := range want {
+		if data[i].Timestamp != unixAt(t, want[i]) {
+			t.Errorf("bar %d at %s, want %s", i, time.Unix(data[i].Timestamp, 0).UTC().Format(time.RFC3339), want[i])
+		}
+	}
+}
+
+func TestFileTimeColumn(t *testing.T) {
+	// Separate Date and Time columns in New York time, on both sides of the switch to daylight time
+	source := testFile("datetime.csv")
+	source.Interval = data_types.Interval1m
+	source.Location = newYork
+	data, report := fetchFile(t, source, "IBM", "2024-03-04T00:00:00Z", "2024-03-12T00:00:00Z")
+	want := []string{"2024-03-04T14:30:00Z", "2024-03-04T14:31:00Z", "2024-03-04T14:32:00Z", "2024-03-11T13:30:00Z"}
+	if len(data) != len(want) {
+		t.Fatalf("got %d bars, want %d: %v", len(data), len(want), report)
+	}
+	for i := range want {
+		if data[i].Timestamp != unixAt(t, want[i]) {
+			t.Errorf("bar %d at %s, want %s", i, time.Unix(data[i].Timestamp, 0).UTC().Format(time.RFC3339), want[i])
+		}
+	}
+	if report.Duplicates != 0 {
+		t.Errorf("got %d duplicates, want 0", report.Duplicates)
+	}
+
+	// Daily bars of the same file are dated and merged, keeping the last bar of each day
+	source.Interval = data_types.Interval1d
+	data, report = fetchFile(t, source, "IBM", "2024-03-04T00:00:00Z", "2024-03-12T00:00:00Z")
+	if len(data) != 2 || data[0].Close != 187.65 || report.Duplicates != 2 {
+		t.Errorf("daily: got %+v, %v", data, report)
+	}
+}
+
+func TestFileIntradayWithoutTime(t *testing.T) {
+	// Dates without a time of day cannot be minute bars
+	source := testFile("IBM.tsv")
+	source.Comment = '#'
+	source.Interval = data_types.Interval1m
+	_, report, err := source.FetchBarsReport(context.Background(), "IBM", 0, unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1m)
+	if !errors.Is(err, data_types.ErrMalformedPayload) || len(report.Skipped) != 2 {
+		t.Errorf("got %v with %d skipped rows, want ErrMalformedPayload", err, len(report.Skipped))
+	}
+}
+
+func TestFileAllRowsInvalid(t *testing.T) {
+	_, _, err := testFile("invalid.csv").FetchBarsReport(context.Background(), "IBM", 0, unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if !errors.Is(err, data_types.ErrMalformedPayload) {
+		t.Errorf("got %v, want ErrMalformedPayload", err)
+	}
+}
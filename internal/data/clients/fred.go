@@ -0,0 +1,177 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// fredLimit is the most observations the series/observations endpoint returns per request.
+const fredLimit = 100000
+
+// FREDClient fetches economic series such as rates, CPI or unemployment from FRED, or any service with
+// the same API. Every vintage of a series is requested from ALFRED's real-time periods, so that a
+// backtest sees the values as first released and as later revised, each from its own release date.
+type FREDClient struct {
+	APIKey string
+	// BaseURL is the series/observations endpoint, up to its query parameters.
+	BaseURL string
+	// HTTP performs the requests. The default stays below the 120 requests per minute FRED allows.
+	HTTP *HTTPClient
+	// ReleaseDelay is added to the midnight UTC of a release date to obtain when a value becomes usable.
+	// FRED only publishes release dates, so the default of a day keeps values out of every bar of their
+	// release date, whatever the time of the release.
+	ReleaseDelay time.Duration
+}
+
+// NewFREDClient creates a new FREDClient instance.
+//
+// Parameter apiKey is the API key used for authentication with FRED.
+// Returns a pointer to a FREDClient object.
+func NewFREDClient(apiKey string) *FREDClient {
+	return &FREDClient{
+		APIKey:       apiKey,
+		BaseURL:      "https://api.stlouisfed.org/fred/series/observations?",
+		HTTP:         NewHTTPClient(DefaultHTTPConfig(100, 5)),
+		ReleaseDelay: 24 * time.Hour,
+	}
+}
+
+// fredObservations is a page of the series/observations endpoint.
+type fredObservations struct {
+	Observations []struct {
+		RealtimeStart string `json:"realtime_start"`
+		Date          string `json:"date"`
+		Value         string `json:"value"`
+	} `json:"observations"`
+}
+
+// FetchSeries fetches every release of the observations of a series. Periods from start to end are
+// requested; a backtest should start a release lag after start so that its first bars have a value.
+//
+// Parameter ctx cancels the request, including its retries and remaining pages.
+// Parameter id is the FRED series ID, such as CPIAUCSL or DGS10.
+// Parameter start and end are the timestamps of the first and last periods to fetch.
+// Returns the series and an error wrapping one of the errors of data_types if FRED rejects the request
+// or its response cannot be parsed.
+func (c *FREDClient) FetchSeries(ctx context.Context, id string, start, end int64) (data_types.Series, error) {
+	series, _, err := c.FetchSeriesReport(ctx, id, start, end)
+	return series, err
+}
+
+// FetchSeriesReport fetches every release of the observations of a series like FetchSeries and reports
+// the observations it had to skip. The Bars of the report count the observations returned.
+//
+// Parameter ctx cancels the request, including its retries and remaining pages.
+// Parameter id is the FRED series ID, such as CPIAUCSL or DGS10.
+// Parameter start and end are the timestamps of the first and last periods to fetch.
+// Returns the series, the report and an error wrapping one of the errors of data_types if FRED rejects
+// the request or none of the observations of a response can be parsed.
+func (c *FREDClient) FetchSeriesReport(ctx context.Context, id string, start, end int64) (data_types.Series, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("fred", id, "")
+	series := data_types.Series{ID: id}
+	query := url.Values{}
+	query.Set("series_id", id)
+	query.Set("api_key", c.APIKey)
+	query.Set("file_type", "json")
+	query.Set("observation_start", time.Unix(start, 0).UTC().Format("2006-01-02"))
+	query.Set("observation_end", time.Unix(end, 0).UTC().Format("2006-01-02"))
+	query.Set("realtime_start", "1776-07-04")
+	query.Set("realtime_end", "9999-12-31")
+	query.Set("limit", strconv.Itoa(fredLimit))
+
+	for offset := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return series, report, err
+		}
+		query.Set("offset", strconv.Itoa(offset))
+		page, err := c.fetchPage(ctx, c.BaseURL+query.Encode(), &series, &report)
+		if err != nil {
+			return series, report, err
+		}
+		offset += page
+		if page == 0 || page < fredLimit {
+			break
+		}
+	}
+	report.Bars = len(series.Observations)
+	return series, report, nil
+}
+
+// fetchPage fetches a page of observations, appends them to the series and returns the number of
+// observations received. Values of "." mark periods without a value and are left out; observations
+// that cannot be parsed are skipped and reported.
+func (c *FREDClient) fetchPage(ctx context.Context, url string, series *data_types.Series, report *data_types.FetchReport) (int, error) {
+	body, err := c.HTTP.Get(ctx, url)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching data: %w", fredError(err))
+	}
+	report.Requests++
+
+	var page fredObservations
+	if err := json.Unmarshal(body, &page); err != nil {
+		return 0, malformedf("error unmarshaling JSON: %v", err)
+	}
+
+	rows, skipped := len(page.Observations), len(report.Skipped)
+	report.Rows += rows
+	for i, row := range page.Observations {
+		if strings.TrimSpace(row.Value) == "." {
+			continue
+		}
+		raw := fmt.Sprintf("%s,%s,%s", row.RealtimeStart, row.Date, row.Value)
+		date, err := time.Parse("2006-01-02", row.Date)
+		if err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid date %q", row.Date))
+			continue
+		}
+		released, err := time.Parse("2006-01-02", row.RealtimeStart)
+		if err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid realtime start %q", row.RealtimeStart))
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(row.Value), 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			report.Skip(i, raw, fmt.Errorf("invalid value %q", row.Value))
+			continue
+		}
+		series.Observations = append(series.Observations, data_types.Observation{
+			Timestamp: date.Unix(),
+			Value:     value,
+			Available: released.Add(c.ReleaseDelay).Unix(),
+		})
+	}
+	return rows, checkSkipped(report, rows, skipped)
+}
+
+// fredError maps the message in the body of a rejected request to the errors of data_types. FRED
+// answers 400 both for unknown series and for invalid API keys.
+func fredError(err error) error {
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusBadRequest {
+		return err
+	}
+	var body struct {
+		ErrorMessage string `json:"error_message"`
+	}
+	if json.Unmarshal([]byte(status.Body), &body) != nil {
+		return err
+	}
+	message := strings.ToLower(body.ErrorMessage)
+	switch {
+	case strings.Contains(message, "api_key"):
+		return fmt.Errorf("%w: %s", data_types.ErrAuth, body.ErrorMessage)
+	case strings.Contains(message, "series does not exist"):
+		return fmt.Errorf("%w: %s", data_types.ErrInvalidSymbol, body.ErrorMessage)
+	}
+	return fmt.Errorf("%w: fred error: %s", err, body.ErrorMessage)
+}
@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayFRED returns a FREDClient replaying a scenario.
+func replayFRED(t *testing.T, scenario string) *FREDClient {
+	client := NewFREDClient("")
+	client.HTTP = replayHTTP(t, "fred/"+scenario)
+	return client
+}
+
+func TestFREDObservations(t *testing.T) {
+	client := replayFRED(t, "observations")
+	series, report, err := client.FetchSeriesReport(context.Background(), "CPIAUCSL", unixAt(t, "2024-01-01T00:00:00Z"), unixAt(t, "2024-02-01T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every release is kept and usable a day after its release date; the February value missing from
+	// its first release is left out
+	want := []data_types.Observation{
+		{Timestamp: unixAt(t, "2024-01-01T00:00:00Z"), Value: 308.417, Available: unixAt(t, "2024-02-14T00:00:00Z")},
+		{Timestamp: unixAt(t, "2024-01-01T00:00:00Z"), Value: 308.742, Available: unixAt(t, "2024-03-13T00:00:00Z")},
+		{Timestamp: unixAt(t, "2024-02-01T00:00:00Z"), Value: 310.326, Available: unixAt(t, "2024-03-13T00:00:00Z")},
+	}
+	if series.ID != "CPIAUCSL" || len(series.Observations) != len(want) {
+		t.Fatalf("got %+v, want %d observations", series, len(want))
+	}
+	for i := range want {
+		if series.Observations[i] != want[i] {
+			t.Errorf("observation %d: got %+v, want %+v", i, series.Observations[i], want[i])
+		}
+	}
+	assertSkipped(t, report, data_types.SkippedRow{Row: 4, Raw: "2024-04-10,2024-02-01,n/a"})
+	assertReport(t, report, 1, 5, 3)
+
+	known, ok := series.AsOf(unixAt(t, "2024-03-01T00:00:00Z"))
+	if !ok || known.Value != 308.417 {
+		t.Errorf("as of March 1: got %+v, want the first release of January", known)
+	}
+}
+
+func TestFREDErrors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		want     error
+	}{
+		{"invalid_symbol", data_types.ErrInvalidSymbol},
+		{"auth", data_types.ErrAuth},
+	}
+	for _, tt := range tests {
+		client := replayFRED(t, tt.scenario)
+		_, _, err := client.FetchSeriesReport(context.Background(), "CPIAUCSL", unixAt(t, "2024-01-01T00:00:00Z"), unixAt(t, "2024-02-01T00:00:00Z"))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.scenario, err, tt.want)
+		}
+	}
+}
@@ -1,28 +1,36 @@
 package clients
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
 	"fmt"
 	data_types "goquant/pkg/data"
-	"net/http"
-	"strconv"
 	"strings"
 	"time"
 )
 
+// googleFinanceURL is the default BaseURL of GoogleFinanceDataSource.
 const googleFinanceURL = "https://finance.google.com/finance/historical?q="
 
 type GoogleFinanceDataSource struct {
-	Client *http.Client
+	// BaseURL is the historical prices endpoint, up to the symbol.
+	BaseURL string
+	// HTTP performs the requests.
+	HTTP *HTTPClient
+	// Now returns the current time, against which the history limits of intervals are checked. Nil uses
+	// time.Now; replays of recorded responses set it to the time of the recording.
+	Now func() time.Time
 }
 
-// NewGoogleFinanceDataSource creates a new GoogleFinanceDataSource with a default HTTP client.
+// NewGoogleFinanceDataSource creates a new GoogleFinanceDataSource with a default HTTP client that makes at most 60 requests per minute.
 //
 // No parameters.
 // Returns a pointer to a GoogleFinanceDataSource object.
 func NewGoogleFinanceDataSource() *GoogleFinanceDataSource {
 	return &GoogleFinanceDataSource{
-		Client: &http.Client{Timeout: 10 * time.Second},
+		BaseURL: googleFinanceURL,
+		HTTP:    NewHTTPClient(DefaultHTTPConfig(60, 5)),
 	}
 }
 
@@ -39,30 +47,117 @@ func NewGoogleFinanceDataSource() *GoogleFinanceDataSource {
 //	A slice of data_types.MarketData containing the retrieved market data.
 //	An error if the data retrieval or parsing fails.
 func (g *GoogleFinanceDataSource) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
-	// Convert Unix timestamp to date format required by Google Finance (yyyy-MM-dd)
-	startDate := time.Unix(start, 0).Format("2006-01-02")
-	endDate := time.Unix(end, 0).Format("2006-01-02")
+	return g.FetchContext(context.Background(), symbol, start, end)
+}
 
-	url := fmt.Sprintf("%s%s&startdate=%s&enddate=%s&output=csv", googleFinanceURL, symbol, startDate, endDate)
+// FetchContext retrieves market data from Google Finance until the context is done.
+//
+// Parameters:
+//
+//	ctx: cancels the request, including its retries.
+//	symbol: the stock symbol of the market data to be retrieved.
+//	start: the start date of the market data in Unix timestamp.
+//	end: the end date of the market data in Unix timestamp.
+//
+// Returns:
+//
+//	A slice of data_types.MarketData containing the retrieved market data.
+//	An error if the context is done or the data retrieval or parsing fails.
+func (g *GoogleFinanceDataSource) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return g.FetchBarsContext(ctx, symbol, start, end, data_types.Interval1d)
+}
 
-	resp, err := g.Client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data from Google Finance: %v", err)
+// Capabilities returns the intervals served by Google Finance, which only provides daily bars.
+func (g *GoogleFinanceDataSource) Capabilities() data_types.Capabilities {
+	return data_types.Capabilities{Intervals: []data_types.Interval{data_types.Interval1d}}
+}
+
+// FetchBars retrieves bars at the given interval from Google Finance.
+//
+// Parameters:
+//
+//	symbol: the stock symbol of the market data to be retrieved.
+//	start: the start date of the market data in Unix timestamp.
+//	end: the end date of the market data in Unix timestamp.
+//	interval: the bar interval, which must be 1d.
+//
+// Returns:
+//
+//	A slice of data_types.MarketData containing the retrieved market data.
+//	An error if the interval is unsupported or the data retrieval or parsing fails.
+func (g *GoogleFinanceDataSource) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	return g.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+// FetchBarsContext retrieves bars at the given interval from Google Finance until the context is done.
+//
+// Parameters:
+//
+//	ctx: cancels the request, including its retries.
+//	symbol: the stock symbol of the market data to be retrieved.
+//	start: the start date of the market data in Unix timestamp.
+//	end: the end date of the market data in Unix timestamp.
+//	interval: the bar interval, which must be 1d.
+//
+// Returns:
+//
+//	A slice of data_types.MarketData containing the retrieved market data.
+//	An error if the context is done, the interval is unsupported or the data retrieval or parsing fails.
+func (g *GoogleFinanceDataSource) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	data, _, err := g.FetchBarsReport(ctx, symbol, start, end, interval)
+	return data, err
+}
+
+// FetchBarsReport retrieves bars at the given interval from Google Finance until the context is done
+// and reports the rows it had to skip.
+//
+// Parameters:
+//
+//	ctx: cancels the request, including its retries.
+//	symbol: the stock symbol of the market data to be retrieved.
+//	start: the start date of the market data in Unix timestamp.
+//	end: the end date of the market data in Unix timestamp.
+//	interval: the bar interval, which must be 1d.
+//
+// Returns:
+//
+//	A slice of data_types.MarketData containing the retrieved market data.
+//	The report of the rows received and skipped.
+//	An error wrapping one of the errors of data_types if Google Finance rejects the request or its
+//	response cannot be parsed.
+func (g *GoogleFinanceDataSource) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("google", symbol, interval)
+	if err := g.Capabilities().Check(interval, start, clockNow(g.Now)); err != nil {
+		return nil, report, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	// Convert Unix timestamp to date format required by Google Finance (yyyy-MM-dd), in UTC like the
+	// midnight timestamps of daily bars rather than in the local time zone
+	startDate := time.Unix(start, 0).UTC().Format("2006-01-02")
+	endDate := time.Unix(end, 0).UTC().Format("2006-01-02")
+
+	url := fmt.Sprintf("%s%s&startdate=%s&enddate=%s&output=csv", g.BaseURL, symbol, startDate, endDate)
+
+	body, err := g.HTTP.Get(ctx, url)
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to fetch data from Google Finance: %w", err)
 	}
+	report.Requests++
 
-	reader := csv.NewReader(resp.Body)
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
 	records, err := reader.ReadAll()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CSV data: %v", err)
+		return nil, report, malformedf("failed to parse CSV data: %v", err)
 	}
 
-	marketData := convertGoogleFinanceData(symbol, records)
-	return marketData, nil
+	marketData, err := convertGoogleFinanceData(symbol, records, &report)
+	if err != nil {
+		return nil, report, err
+	}
+	marketData = MergeBars(marketData)
+	report.Bars = len(marketData)
+	return marketData, report, nil
 }
 
 // convertGoogleFinanceData converts Google Finance CSV response to MarketData slice.
@@ -71,23 +166,42 @@ func (g *GoogleFinanceDataSource) Fetch(symbol string, start, end int64) ([]data
 //
 //	symbol: the stock symbol of the market data.
 //	records: a 2D slice of strings representing the CSV data.
+//	report: receives the rows that are skipped.
 //
 // Returns:
 //
 //	A slice of data_types.MarketData containing the converted market data.
-func convertGoogleFinanceData(symbol string, records [][]string) []data_types.MarketData {
+//	An error wrapping data_types.ErrMalformedPayload if the header is missing or every row is invalid.
+func convertGoogleFinanceData(symbol string, records [][]string, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+	if len(records) == 0 || len(records[0]) < 6 || !strings.HasSuffix(records[0][0], "Date") {
+		return nil, malformedf("unexpected CSV header")
+	}
+
 	var data []data_types.MarketData
+	rows, skipped := len(records)-1, len(report.Skipped)
+	report.Rows += rows
 
 	// Skip the first line (header)
-	for _, record := range records[1:] {
-		timestamp, _ := time.Parse("2-Jan-2006", record[0])
-		open, _ := strconv.ParseFloat(record[1], 64)
-		high, _ := strconv.ParseFloat(record[2], 64)
-		low, _ := strconv.ParseFloat(record[3], 64)
-		closePrice, _ := strconv.ParseFloat(record[4], 64)
-		volume, _ := strconv.ParseInt(strings.ReplaceAll(record[5], ",", ""), 10, 64)
-
-		data = append(data, data_types.MarketData{
+	for i, record := range records[1:] {
+		raw := strings.Join(record, ",")
+		if len(record) < 6 {
+			report.Skip(i, raw, fmt.Errorf("expected 6 columns, got %d", len(record)))
+			continue
+		}
+		timestamp, err := time.Parse("2-Jan-06", record[0])
+		if err != nil {
+			if timestamp, err = time.Parse("2-Jan-2006", record[0]); err != nil {
+				report.Skip(i, raw, fmt.Errorf("invalid date %q", record[0]))
+				continue
+			}
+		}
+		open, high, low, closePrice, volume, err := parseOHLCV(record[1:6])
+		if err != nil {
+			report.Skip(i, raw, err)
+			continue
+		}
+
+		bar := data_types.MarketData{
 			Ticker:    symbol,
 			Timestamp: timestamp.Unix(),
 			Open:      open,
@@ -95,7 +209,10 @@ func convertGoogleFinanceData(symbol string, records [][]string) []data_types.Ma
 			Low:       low,
 			Close:     closePrice,
 			Volume:    volume,
-		})
+		}
+		if acceptBar(report, i, raw, bar) {
+			data = append(data, bar)
+		}
 	}
-	return data
+	return data, checkSkipped(report, rows, skipped)
 }
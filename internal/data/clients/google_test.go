@@ -0,0 +1,42 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayGoogle returns a GoogleFinanceDataSource replaying a scenario recorded on 2024-03-15.
+func replayGoogle(t *testing.T, scenario string) *GoogleFinanceDataSource {
+	source := NewGoogleFinanceDataSource()
+	source.HTTP = replayHTTP(t, "google/"+scenario)
+	source.Now = clockAt(t, "2024-03-15T00:00:00Z")
+	return source
+}
+
+func TestGoogleDaily(t *testing.T) {
+	source := replayGoogle(t, "daily")
+	data, report, err := source.FetchBarsReport(context.Background(), "NASDAQ:AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "NASDAQ:AAPL", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 176.15, High: 176.9, Low: 173.79, Close: 175.1, Volume: 81510101},
+		{Ticker: "NASDAQ:AAPL", Timestamp: unixAt(t, "2024-03-06T00:00:00Z"), Open: 171.06, High: 171.24, Low: 168.68, Close: 169.12, Volume: 68587707},
+		{Ticker: "NASDAQ:AAPL", Timestamp: unixAt(t, "2024-03-07T00:00:00Z"), Open: 169.15, High: 170.73, Low: 168.49, Close: 169, Volume: 71765061},
+		{Ticker: "NASDAQ:AAPL", Timestamp: unixAt(t, "2024-03-08T00:00:00Z"), Open: 169, High: 173.7, Low: 168.94, Close: 170.73, Volume: 76114600},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 3, Raw: "5-Mar-24,170.76,172.04,168.42,-,95,132,355"})
+	assertReport(t, report, 1, 5, 4)
+}
+
+func TestGoogleMalformed(t *testing.T) {
+	// An HTML error page in place of the CSV
+	source := replayGoogle(t, "malformed")
+	_, _, err := source.FetchBarsReport(context.Background(), "NASDAQ:AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if !errors.Is(err, data_types.ErrMalformedPayload) {
+		t.Errorf("got %v, want ErrMalformedPayload", err)
+	}
+}
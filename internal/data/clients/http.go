@@ -0,0 +1,306 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// RateLimiter is a token bucket that allows a burst of requests at once and refills at a steady rate.
+// It is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter that starts with a full bucket.
+//
+// Parameters:
+// - perMinute: The sustained number of requests per minute. Zero or less disables the limit.
+// - burst: The number of requests that may be made at once, at least 1.
+// Returns a pointer to the newly created RateLimiter.
+func NewRateLimiter(perMinute float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: perMinute / 60, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a request may be made or the context is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return ctx.Err()
+	}
+	for {
+		// A done context takes no token, even when one is available
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// HTTPConfig configures an HTTPClient.
+type HTTPConfig struct {
+	// Timeout bounds every single attempt, including reading the body.
+	Timeout time.Duration
+	// RequestsPerMinute and Burst configure the token bucket shared by all requests of the client.
+	RequestsPerMinute float64
+	Burst             int
+	// MaxRetries is the number of retries after the first attempt on 429, 5xx and network errors.
+	MaxRetries int
+	// BaseDelay and MaxDelay bound the exponential backoff between retries.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Transport sends the requests, such as a ReplayTransport in tests. Nil uses http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// DefaultHTTPConfig returns a configuration with a 30 second timeout, 3 retries backing off from 1 to
+// 30 seconds, and the given rate limit.
+func DefaultHTTPConfig(requestsPerMinute float64, burst int) HTTPConfig {
+	return HTTPConfig{
+		Timeout:           30 * time.Second,
+		RequestsPerMinute: requestsPerMinute,
+		Burst:             burst,
+		MaxRetries:        3,
+		BaseDelay:         time.Second,
+		MaxDelay:          30 * time.Second,
+	}
+}
+
+// HTTPClient is the HTTP layer shared by the data clients. It rate limits requests per provider, retries
+// rate-limited, server and network errors with exponential backoff and full jitter, honours Retry-After,
+// and stops as soon as the context is done.
+type HTTPClient struct {
+	Client  *http.Client
+	Limiter *RateLimiter
+	Config  HTTPConfig
+	rngMu   sync.Mutex
+	rng     *rand.Rand
+}
+
+// NewHTTPClient creates an HTTPClient with its own http.Client and rate limiter.
+func NewHTTPClient(config HTTPConfig) *HTTPClient {
+	return &HTTPClient{
+		Client:  &http.Client{Timeout: config.Timeout, Transport: config.Transport},
+		Limiter: NewRateLimiter(config.RequestsPerMinute, config.Burst),
+		Config:  config,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// StatusError is returned when a provider answers with an unsuccessful HTTP status after all retries.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error returns the status and the beginning of the body.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response code: %d: %s", e.StatusCode, truncate(e.Body, 200))
+}
+
+// truncate shortens s to at most n bytes for error messages.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// Is maps the status to the errors of data_types: 429 to ErrRateLimited, 401, 402 and 403 to ErrAuth
+// and 404 to ErrInvalidSymbol.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case data_types.ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case data_types.ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusPaymentRequired || e.StatusCode == http.StatusForbidden
+	case data_types.ErrInvalidSymbol:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// defaultHTTPClient serves clients whose HTTP field was left nil, e.g. clients built as struct literals.
+// It retries like DefaultHTTPConfig but does not rate limit, since it is shared by every provider.
+var defaultHTTPClient = NewHTTPClient(DefaultHTTPConfig(0, 1))
+
+// Get performs a GET request and returns the body of a successful response. A nil client uses a
+// default client without rate limit.
+func (c *HTTPClient) Get(ctx context.Context, url string) ([]byte, error) {
+	return c.GetChecked(ctx, url, nil)
+}
+
+// GetChecked performs a GET request like Get and passes the body of every successful response to check,
+// for providers that report errors with status 200. A check error wrapping data_types.ErrRateLimited is
+// retried like a 429 response; any other check error is returned as is.
+func (c *HTTPClient) GetChecked(ctx context.Context, url string, check func(body []byte) error) ([]byte, error) {
+	if c == nil {
+		c = defaultHTTPClient
+	}
+	var lastErr error
+	for attempt := 0; attempt <= c.Config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.backoff(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		body, err := c.do(ctx, url)
+		if err == nil && check != nil {
+			err = check(body)
+		}
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if !retryable(ctx, err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", c.Config.MaxRetries+1, lastErr)
+}
+
+// do performs a single attempt.
+func (c *HTTPClient) do(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &retryAfterError{
+			StatusError: &StatusError{StatusCode: resp.StatusCode, Body: string(body)},
+			retryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return body, nil
+}
+
+// retryAfterError carries the Retry-After delay of a response along with its StatusError.
+type retryAfterError struct {
+	*StatusError
+	retryAfter time.Duration
+}
+
+// Unwrap returns the StatusError.
+func (e *retryAfterError) Unwrap() error {
+	return e.StatusError
+}
+
+// retryable reports whether a failed attempt should be retried.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, data_types.ErrRateLimited) {
+		return true
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= 500
+	}
+	// Timeouts and failed connections are transient, while other transport errors are not
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns the delay before a retry: the Retry-After of the last response if present, and
+// otherwise a uniformly random delay up to BaseDelay·2^(attempt-1), capped at MaxDelay.
+func (c *HTTPClient) backoff(attempt int, lastErr error) time.Duration {
+	var retryAfter *retryAfterError
+	if errors.As(lastErr, &retryAfter) && retryAfter.retryAfter > 0 {
+		return min(retryAfter.retryAfter, c.Config.MaxDelay)
+	}
+
+	ceiling := float64(c.Config.BaseDelay) * math.Pow(2, float64(attempt-1))
+	ceiling = math.Min(ceiling, float64(c.Config.MaxDelay))
+	c.rngMu.Lock()
+	defer c.rngMu.Unlock()
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return time.Duration(c.rng.Float64() * ceiling)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// sleepContext waits for the duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
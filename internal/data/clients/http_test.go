@@ -0,0 +1,219 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// response is a canned answer of a test transport.
+type response struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// scriptedHTTP returns an HTTPClient answering each attempt with the next response, repeating the last
+// one, and a pointer to the number of attempts made.
+func scriptedHTTP(responses ...response) (*HTTPClient, *int) {
+	attempts := new(int)
+	config := DefaultHTTPConfig(0, 1)
+	config.BaseDelay = time.Millisecond
+	config.MaxDelay = 20 * time.Millisecond
+	config.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		r := responses[min(*attempts, len(responses)-1)]
+		*attempts++
+		header := http.Header{}
+		if r.retryAfter != "" {
+			header.Set("Retry-After", r.retryAfter)
+		}
+		return &http.Response{StatusCode: r.status, Header: header, Body: io.NopCloser(strings.NewReader(r.body)), Request: req}, nil
+	})
+	return NewHTTPClient(config), attempts
+}
+
+func TestHTTPRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []response
+		attempts  int
+		// status is the StatusCode of the error returned, or 0 for success
+		status int
+	}{
+		{"success", []response{{status: 200, body: "ok"}}, 1, 0},
+		{"rate limited", []response{{status: 429}, {status: 200, body: "ok"}}, 2, 0},
+		{"server errors", []response{{status: 500}, {status: 503}, {status: 200, body: "ok"}}, 3, 0},
+		// Client errors other than 429 are final
+		{"not found", []response{{status: 404}, {status: 200, body: "ok"}}, 1, 404},
+		{"forbidden", []response{{status: 403}, {status: 200, body: "ok"}}, 1, 403},
+		{"bad request", []response{{status: 400}, {status: 200, body: "ok"}}, 1, 400},
+		// The first attempt and three retries
+		{"persistent rate limit", []response{{status: 429}}, 4, 429},
+	}
+	for _, tt := range tests {
+		client, attempts := scriptedHTTP(tt.responses...)
+		body, err := client.Get(context.Background(), "https://example.com/bars")
+		if *attempts != tt.attempts {
+			t.Errorf("%s: got %d attempts, want %d", tt.name, *attempts, tt.attempts)
+		}
+		if tt.status == 0 {
+			if err != nil || string(body) != "ok" {
+				t.Errorf("%s: got %q, %v, want ok", tt.name, body, err)
+			}
+			continue
+		}
+		var status *StatusError
+		if !errors.As(err, &status) || status.StatusCode != tt.status {
+			t.Errorf("%s: got %v, want status %d", tt.name, err, tt.status)
+		}
+	}
+
+	// Statuses map to the errors of data_types
+	for status, want := range map[int]error{429: data_types.ErrRateLimited, 402: data_types.ErrAuth, 404: data_types.ErrInvalidSymbol} {
+		client, _ := scriptedHTTP(response{status: status})
+		if _, err := client.Get(context.Background(), "https://example.com/bars"); !errors.Is(err, want) {
+			t.Errorf("status %d: got %v, want %v", status, err, want)
+		}
+	}
+}
+
+func TestHTTPGivingUp(t *testing.T) {
+	client, _ := scriptedHTTP(response{status: 502, body: "Bad Gateway"})
+	_, err := client.Get(context.Background(), "https://example.com/bars")
+	var status *StatusError
+	if err == nil || !strings.HasPrefix(err.Error(), "giving up after 4 attempts: ") || !errors.As(err, &status) || status.StatusCode != 502 {
+		t.Errorf("got %v, want giving up after 4 attempts wrapping the 502", err)
+	}
+
+	// Without retries the error of the single attempt is wrapped the same way
+	client.Config.MaxRetries = 0
+	_, err = client.Get(context.Background(), "https://example.com/bars")
+	if err == nil || !strings.HasPrefix(err.Error(), "giving up after 1 attempts: ") {
+		t.Errorf("no retries: got %v", err)
+	}
+}
+
+func TestHTTPCheckRateLimited(t *testing.T) {
+	// A provider reporting its rate limit in a successful response is retried like a 429
+	client, attempts := scriptedHTTP(response{status: 200, body: "limit"}, response{status: 200, body: "ok"})
+	check := func(body []byte) error {
+		if string(body) == "limit" {
+			return fmt.Errorf("%w: too many calls", data_types.ErrRateLimited)
+		}
+		return nil
+	}
+	body, err := client.GetChecked(context.Background(), "https://example.com/bars", check)
+	if err != nil || string(body) != "ok" || *attempts != 2 {
+		t.Errorf("got %q, %v after %d attempts, want ok after 2", body, err, *attempts)
+	}
+
+	// Any other check error is returned at once
+	client, attempts = scriptedHTTP(response{status: 200, body: "unknown"})
+	_, err = client.GetChecked(context.Background(), "https://example.com/bars", func([]byte) error { return data_types.ErrInvalidSymbol })
+	if !errors.Is(err, data_types.ErrInvalidSymbol) || *attempts != 1 {
+		t.Errorf("got %v after %d attempts, want ErrInvalidSymbol after 1", err, *attempts)
+	}
+}
+
+func TestHTTPRetryAfter(t *testing.T) {
+	client, _ := scriptedHTTP(response{status: 200})
+	client.Config.MaxDelay = time.Minute
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name       string
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"seconds", "7", 7 * time.Second, 7 * time.Second},
+		// HTTP dates have a resolution of a second
+		{"date", date, 8 * time.Second, 10 * time.Second},
+		{"capped", "3600", time.Minute, time.Minute},
+		// A date in the past or an invalid value falls back to the jittered backoff
+		{"past date", "Sun, 06 Nov 1994 08:49:37 GMT", 0, 4 * time.Millisecond},
+		{"invalid", "soon", 0, 4 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		err := &retryAfterError{StatusError: &StatusError{StatusCode: 429}, retryAfter: parseRetryAfter(tt.retryAfter)}
+		got := client.backoff(3, fmt.Errorf("error fetching data: %w", err))
+		if got < tt.min || got > tt.max {
+			t.Errorf("%s: got %v, want between %v and %v", tt.name, got, tt.min, tt.max)
+		}
+	}
+
+	// Jittered delays stay below BaseDelay·2^(attempt-1) and MaxDelay
+	client.Config.BaseDelay, client.Config.MaxDelay = time.Second, 5*time.Second
+	for attempt, ceiling := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 5: 5 * time.Second} {
+		for i := 0; i < 100; i++ {
+			if got := client.backoff(attempt, errors.New("timeout")); got < 0 || got > ceiling {
+				t.Fatalf("attempt %d: got %v, want at most %v", attempt, got, ceiling)
+			}
+		}
+	}
+
+	// A Retry-After above MaxDelay waits MaxDelay between attempts
+	client, attempts := scriptedHTTP(response{status: 429, retryAfter: "3600"}, response{status: 200, body: "ok"})
+	began := time.Now()
+	if _, err := client.Get(context.Background(), "https://example.com/bars"); err != nil || *attempts != 2 {
+		t.Fatalf("got %v after %d attempts", err, *attempts)
+	}
+	if elapsed := time.Since(began); elapsed < client.Config.MaxDelay || elapsed > time.Second {
+		t.Errorf("waited %v, want about %v", elapsed, client.Config.MaxDelay)
+	}
+}
+
+func TestHTTPCancellation(t *testing.T) {
+	// A context ending during the backoff stops the wait for the next attempt
+	client, attempts := scriptedHTTP(response{status: 503, retryAfter: "10"})
+	client.Config.MaxDelay = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	began := time.Now()
+	_, err := client.Get(ctx, "https://example.com/bars")
+	if !errors.Is(err, context.DeadlineExceeded) || *attempts != 1 || time.Since(began) > time.Second {
+		t.Errorf("backoff: got %v after %d attempts and %v", err, *attempts, time.Since(began))
+	}
+
+	// A context ending while the rate limiter waits for a token stops the request before it is sent
+	client, attempts = scriptedHTTP(response{status: 200, body: "ok"})
+	client.Limiter = NewRateLimiter(1, 1)
+	if _, err := client.Get(context.Background(), "https://example.com/bars"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	began = time.Now()
+	_, err = client.Get(ctx, "https://example.com/bars")
+	if !errors.Is(err, context.DeadlineExceeded) || *attempts != 1 || time.Since(began) > time.Second {
+		t.Errorf("rate limiter: got %v after %d attempts and %v", err, *attempts, time.Since(began))
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// A burst of two is available at once, the third request waits for the refill
+	limiter := NewRateLimiter(600, 2)
+	began := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(began); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("three requests at 10 a second with a burst of 2 took %v, want about 100ms", elapsed)
+	}
+
+	// A cancelled context is reported even by a limiter that does not limit
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, limiter := range []*RateLimiter{nil, NewRateLimiter(0, 1), NewRateLimiter(1, 1)} {
+		if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	}
+}
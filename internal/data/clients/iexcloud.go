@@ -1,10 +1,10 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"strings"
 	"time"
 
 	data_types "goquant/pkg/data"
@@ -13,6 +13,11 @@ import (
 type IEXCloudClient struct {
 	APIKey  string
 	BaseURL string
+	// HTTP performs the requests. The default stays below the limit of 100 requests per second.
+	HTTP *HTTPClient
+	// Now returns the current time, against which the history limits of intervals are checked. Nil uses
+	// time.Now; replays of recorded responses set it to the time of the recording.
+	Now func() time.Time
 }
 
 // NewIEXCloudClient creates a new IEXCloudClient instance.
@@ -23,65 +28,194 @@ func NewIEXCloudClient(apiKey string) *IEXCloudClient {
 	return &IEXCloudClient{
 		APIKey:  apiKey,
 		BaseURL: "https://cloud.iexapis.com/stable/stock/",
+		HTTP:    NewHTTPClient(DefaultHTTPConfig(3000, 50)),
 	}
 }
 
+// Capabilities returns the intervals served by IEX Cloud. Minute bars are only available for the
+// current trading day and daily bars for the last 15 years.
+func (c *IEXCloudClient) Capabilities() data_types.Capabilities {
+	return data_types.Capabilities{
+		Intervals: []data_types.Interval{data_types.Interval1m, data_types.Interval1d},
+		MaxHistory: map[data_types.Interval]time.Duration{
+			data_types.Interval1m: 24 * time.Hour,
+			data_types.Interval1d: 15 * 365 * 24 * time.Hour,
+		},
+	}
+}
+
+// Fetch fetches daily data for a given symbol.
+//
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *IEXCloudClient) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchContext(context.Background(), symbol, start, end)
+}
+
+// FetchContext fetches daily data for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *IEXCloudClient) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(ctx, symbol, start, end, data_types.Interval1d)
+}
+
+// FetchBars fetches bars at the given interval for a given symbol.
+//
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval, 1m or 1d.
+// Returns a slice of MarketData and an error.
+func (c *IEXCloudClient) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+// FetchBarsContext fetches bars at the given interval for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval, 1m or 1d.
+// Returns a slice of MarketData and an error.
+func (c *IEXCloudClient) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	data, _, err := c.FetchBarsReport(ctx, symbol, start, end, interval)
+	return data, err
+}
+
+// iexBar is a bar of the chart and intraday-prices endpoints. Minutes without trades have null prices.
+type iexBar struct {
+	Date   string   `json:"date"`
+	Minute string   `json:"minute"`
+	Open   *float64 `json:"open"`
+	High   *float64 `json:"high"`
+	Low    *float64 `json:"low"`
+	Close  *float64 `json:"close"`
+	Volume *float64 `json:"volume"`
+}
+
+// FetchBarsReport fetches bars at the given interval for a given symbol until the context is done and
+// reports the rows it had to skip.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval, 1m or 1d.
+// Returns a slice of MarketData, the report and an error wrapping one of the errors of data_types if
+// IEX Cloud rejects the request or its response cannot be parsed.
+func (c *IEXCloudClient) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("iexcloud", symbol, interval)
+	if err := c.Capabilities().Check(interval, start, clockNow(c.Now)); err != nil {
+		return nil, report, err
+	}
+	if interval == data_types.Interval1m {
+		data, err := c.fetchIntraday(ctx, symbol, start, end, &report)
+		return data, report, err
+	}
+
+	// Prepare the URL
+	url := fmt.Sprintf("%s%s/chart/max?token=%s", c.BaseURL, symbol, c.APIKey)
+
+	result, err := c.get(ctx, url, &report)
+	if err != nil {
+		return nil, report, err
+	}
+	data, err := convertIEXBars(symbol, result, start, end, &report, func(record iexBar) (time.Time, error) {
+		return time.Parse("2006-01-02", record.Date)
+	})
+	return data, report, err
+}
+
 // FetchMinuteData fetches intraday minute data for a given symbol.
 //
+// Deprecated: use FetchBars with Interval1m.
+//
 // Parameter symbol is the stock symbol to fetch data for.
 // Parameter start and end are the start and end timestamps to filter data by.
 // Parameter interval is ignored since IEX Cloud provides data at 1-minute intervals.
 // Returns a slice of MarketData and an error.
 func (c *IEXCloudClient) FetchMinuteData(symbol string, start, end int64, interval string) ([]data_types.MarketData, error) {
+	report := data_types.NewFetchReport("iexcloud", symbol, data_types.Interval1m)
+	return c.fetchIntraday(context.Background(), symbol, start, end, &report)
+}
+
+// fetchIntraday fetches the minute bars of the current trading day.
+func (c *IEXCloudClient) fetchIntraday(ctx context.Context, symbol string, start, end int64, report *data_types.FetchReport) ([]data_types.MarketData, error) {
 	// Prepare the URL
 	url := fmt.Sprintf("%s%s/intraday-prices?token=%s", c.BaseURL, symbol, c.APIKey)
 
-	// Perform the request
-	resp, err := http.Get(url)
+	result, err := c.get(ctx, url, report)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching data: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	// Minutes are wall-clock times on the bar's date in US/Eastern time
+	return convertIEXBars(symbol, result, start, end, report, func(record iexBar) (time.Time, error) {
+		return time.ParseInLocation("2006-01-02 15:04", record.Date+" "+record.Minute, newYork)
+	})
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+// get performs a request and decodes the bars of the response.
+func (c *IEXCloudClient) get(ctx context.Context, url string, report *data_types.FetchReport) ([]iexBar, error) {
+	// Perform the request
+	body, err := c.HTTP.Get(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("error reading body: %v", err)
+		return nil, fmt.Errorf("error fetching data: %w", err)
 	}
+	report.Requests++
 
 	// Parse the JSON response
-	var result []struct {
-		Minute string  `json:"minute"`
-		Open   float64 `json:"open"`
-		High   float64 `json:"high"`
-		Low    float64 `json:"low"`
-		Close  float64 `json:"close"`
-		Volume int64   `json:"volume"`
-	}
-
+	var result []iexBar
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("error unmarshaling JSON: %v", err)
+		return nil, malformedf("error unmarshaling JSON: %v", err)
 	}
+	return result, nil
+}
 
-	// Process the data and filter by the start and end time
+// convertIEXBars converts the bars of a response, skipping bars without trades or with invalid
+// timestamps or prices, and keeps those between start and end.
+func convertIEXBars(symbol string, result []iexBar, start, end int64, report *data_types.FetchReport, timestampOf func(iexBar) (time.Time, error)) ([]data_types.MarketData, error) {
 	var data []data_types.MarketData
-	for _, record := range result {
-		timestamp, _ := time.Parse("15:04", record.Minute)
-		fullTimestamp := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), timestamp.Hour(), timestamp.Minute(), 0, 0, time.UTC)
-
-		if fullTimestamp.Unix() < start || fullTimestamp.Unix() > end {
+	invalid, nulls := 0, 0
+	report.Rows += len(result)
+	for i, record := range result {
+		raw := strings.TrimSpace(record.Date + " " + record.Minute)
+		timestamp, err := timestampOf(record)
+		if err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid timestamp %q", raw))
+			invalid++
 			continue
 		}
-
-		data = append(data, data_types.MarketData{
-			Timestamp: fullTimestamp.Unix(),
+		if record.Open == nil || record.High == nil || record.Low == nil || record.Close == nil {
+			report.Skip(i, raw, fmt.Errorf("null quote"))
+			nulls++
+			continue
+		}
+		bar := data_types.MarketData{
+			Timestamp: timestamp.Unix(),
 			Ticker:    symbol,
-			Open:      record.Open,
-			High:      record.High,
-			Low:       record.Low,
-			Close:     record.Close,
-			Volume:    record.Volume,
-		})
+			Open:      *record.Open,
+			High:      *record.High,
+			Low:       *record.Low,
+			Close:     *record.Close,
+		}
+		if record.Volume != nil {
+			bar.Volume = *record.Volume
+		}
+		if !acceptBar(report, i, raw, bar) {
+			invalid++
+			continue
+		}
+		if bar.Timestamp < start || bar.Timestamp > end {
+			continue
+		}
+		data = append(data, bar)
 	}
-
+	// Minutes without trades are expected, but if every other row is invalid the format has changed
+	if invalid > 0 && invalid == len(result)-nulls {
+		return nil, malformedf("all %d non-null rows are invalid", invalid)
+	}
+	report.Bars += len(data)
 	return data, nil
 }
@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayIEXCloud returns an IEXCloudClient replaying a scenario recorded after the close of 2024-03-08.
+func replayIEXCloud(t *testing.T, scenario string) *IEXCloudClient {
+	client := NewIEXCloudClient("")
+	client.HTTP = replayHTTP(t, "iexcloud/"+scenario)
+	client.Now = clockAt(t, "2024-03-08T21:00:00Z")
+	return client
+}
+
+func TestIEXCloudDaily(t *testing.T) {
+	client := replayIEXCloud(t, "daily")
+	data, report, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 187.76, High: 193.898, Low: 185.86, Close: 191.66, Volume: 5962649},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-05T00:00:00Z"), Open: 192, High: 195.2, Low: 191.88, Close: 192.95, Volume: 4102426},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-07T00:00:00Z"), Open: 197.44, High: 198.25, Low: 195.55, Close: 198.74, Volume: 3935347},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-08T00:00:00Z"), Open: 196.06, High: 197.71, Low: 195.32, Close: 195.95, Volume: 3943023},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 3, Raw: "2024-03-06"})
+	assertReport(t, report, 1, 6, 4)
+}
+
+func TestIEXCloudIntraday(t *testing.T) {
+	// Minutes are US/Eastern wall-clock times, five hours behind UTC before the switch to daylight time
+	client := replayIEXCloud(t, "intraday")
+	data, report, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-08T14:30:00Z"), unixAt(t, "2024-03-08T14:32:00Z"), data_types.Interval1m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-08T14:30:00Z"), Open: 196.06, High: 196.4, Low: 195.97, Close: 196.31, Volume: 2114},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-08T14:32:00Z"), Open: 196.21, High: 196.26, Low: 196.02, Close: 196.05, Volume: 1021},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 1, Raw: "2024-03-08 09:31"})
+	assertReport(t, report, 1, 4, 2)
+}
+
+func TestIEXCloudIntradayHistory(t *testing.T) {
+	// Minute bars older than a day are rejected before any request is made
+	client := replayIEXCloud(t, "intraday")
+	_, report, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-07T14:30:00Z"), unixAt(t, "2024-03-08T14:32:00Z"), data_types.Interval1m)
+	if !errors.Is(err, data_types.ErrUnsupportedInterval) || report.Requests != 0 {
+		t.Errorf("got %v after %d requests, want ErrUnsupportedInterval", err, report.Requests)
+	}
+}
+
+func TestIEXCloudErrors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		want     error
+	}{
+		{"invalid_symbol", data_types.ErrInvalidSymbol},
+		{"auth", data_types.ErrAuth},
+	}
+	for _, tt := range tests {
+		client := replayIEXCloud(t, tt.scenario)
+		_, _, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.scenario, err, tt.want)
+		}
+	}
+}
+
+func TestIEXCloudIntradayDST(t *testing.T) {
+	// On the Monday after the switch to daylight time minutes are four hours behind UTC
+	client := replayIEXCloud(t, "intraday_dst")
+	client.Now = clockAt(t, "2024-03-11T21:00:00Z")
+	data, _, err := client.FetchBarsReport(context.Background(), "IBM", unixAt(t, "2024-03-11T13:30:00Z"), unixAt(t, "2024-03-11T20:00:00Z"), data_types.Interval1m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-11T13:30:00Z"), Open: 193, High: 193.4, Low: 192.9, Close: 193.2, Volume: 1812},
+		{Ticker: "IBM", Timestamp: unixAt(t, "2024-03-11T19:59:00Z"), Open: 191.6, High: 191.7, Low: 191.5, Close: 191.55, Volume: 3120},
+	})
+}
@@ -0,0 +1,127 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// readParquet reads the rows of a Parquet file with a flat schema. Columns are named after the last
+// element of their path.
+func (s *FileDataSource) readParquet(file *os.File, emit rowFunc) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return malformedf("error opening Parquet file: %v", err)
+	}
+
+	schema := pf.Schema()
+	paths := schema.Columns()
+	header := make([]string, len(paths))
+	converters := make([]func(parquet.Value) any, len(paths))
+	for i, path := range paths {
+		header[i] = path[len(path)-1]
+		leaf, _ := schema.Lookup(path...)
+		converters[i] = parquetConverter(leaf.Node.Type())
+	}
+	columns, err := s.Columns.resolve(header)
+	if err != nil {
+		return err
+	}
+
+	reader := parquet.NewReader(pf)
+	defer reader.Close()
+	rows := make([]parquet.Row, 256)
+	values := make([]any, len(paths))
+	for index := 0; ; {
+		n, err := reader.ReadRows(rows)
+		for _, row := range rows[:n] {
+			clear(values)
+			row.Range(func(column int, columnValues []parquet.Value) bool {
+				if len(columnValues) > 0 && !columnValues[0].IsNull() {
+					values[column] = converters[column](columnValues[0])
+				}
+				return true
+			})
+			if err := emit(index, func() string { return parquetRaw(header, values) }, values, columns, nil); err != nil {
+				return err
+			}
+			index++
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return malformedf("error reading Parquet rows: %v", err)
+		}
+	}
+}
+
+// parquetConverter returns the function that converts values of a column type: timestamps and dates
+// to time.Time, integers to int64, floating point numbers to float64 and byte arrays to strings.
+func parquetConverter(t parquet.Type) func(parquet.Value) any {
+	if logical := t.LogicalType(); logical != nil {
+		switch {
+		case logical.Timestamp != nil:
+			unit := logical.Timestamp.Unit
+			switch {
+			case unit.Nanos != nil:
+				return func(v parquet.Value) any { return time.Unix(0, v.Int64()) }
+			case unit.Micros != nil:
+				return func(v parquet.Value) any { return time.UnixMicro(v.Int64()) }
+			default:
+				return func(v parquet.Value) any { return time.UnixMilli(v.Int64()) }
+			}
+		case logical.Date != nil:
+			return parquetDate
+		}
+	}
+	if converted := t.ConvertedType(); converted != nil {
+		switch *converted {
+		case deprecated.TimestampMillis:
+			return func(v parquet.Value) any { return time.UnixMilli(v.Int64()) }
+		case deprecated.TimestampMicros:
+			return func(v parquet.Value) any { return time.UnixMicro(v.Int64()) }
+		case deprecated.Date:
+			return parquetDate
+		}
+	}
+
+	switch t.Kind() {
+	case parquet.Int32:
+		return func(v parquet.Value) any { return int64(v.Int32()) }
+	case parquet.Int64:
+		return func(v parquet.Value) any { return v.Int64() }
+	case parquet.Float:
+		return func(v parquet.Value) any { return float64(v.Float()) }
+	case parquet.Double:
+		return func(v parquet.Value) any { return v.Double() }
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return func(v parquet.Value) any { return string(v.ByteArray()) }
+	default:
+		return func(v parquet.Value) any { return v.String() }
+	}
+}
+
+// parquetDate converts a DATE value, the number of days since the Unix epoch, to midnight UTC.
+func parquetDate(v parquet.Value) any {
+	return time.Unix(int64(v.Int32())*24*60*60, 0).UTC()
+}
+
+// parquetRaw formats a row for the report of skipped rows.
+func parquetRaw(header []string, values []any) string {
+	fields := make([]string, len(values))
+	for i, value := range values {
+		fields[i] = fmt.Sprintf("%s=%v", header[i], value)
+	}
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	data_types "goquant/pkg/data"
+)
+
+// malformedf returns an error wrapping data_types.ErrMalformedPayload.
+func malformedf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", data_types.ErrMalformedPayload, fmt.Sprintf(format, args...))
+}
+
+// parseOHLCV parses the open, high, low, close and volume columns of a CSV row. Volumes may contain
+// thousands separators; a volume of "-" or "" is read as zero.
+func parseOHLCV(fields []string) (open, high, low, closePrice, volume float64, err error) {
+	prices := make([]float64, 4)
+	for i, name := range []string{"open", "high", "low", "close"} {
+		prices[i], err = strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err != nil {
+			return 0, 0, 0, 0, 0, fmt.Errorf("invalid %s %q", name, fields[i])
+		}
+	}
+	volume, err = parseVolume(fields[4])
+	if err != nil {
+		return 0, 0, 0, 0, 0, err
+	}
+	return prices[0], prices[1], prices[2], prices[3], volume, nil
+}
+
+// parseVolume parses a volume that may contain thousands separators or a fraction.
+func parseVolume(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" || s == "-" {
+		return 0, nil
+	}
+	volume, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid volume %q", s)
+	}
+	return volume, nil
+}
+
+// acceptBar validates a parsed bar and records it as skipped if it is invalid.
+func acceptBar(report *data_types.FetchReport, row int, raw string, bar data_types.MarketData) bool {
+	if err := bar.Validate(); err != nil {
+		report.Skip(row, raw, err)
+		return false
+	}
+	return true
+}
+
+// checkSkipped returns an error wrapping data_types.ErrMalformedPayload if a response had rows but every
+// one of them was skipped, which means the format is not what the parser expects.
+func checkSkipped(report *data_types.FetchReport, rows, skippedBefore int) error {
+	skipped := len(report.Skipped) - skippedBefore
+	if rows > 0 && skipped == rows {
+		first := report.Skipped[skippedBefore]
+		return malformedf("all %d rows are invalid, first row %d: %v", rows, first.Row, first.Err)
+	}
+	return nil
+}
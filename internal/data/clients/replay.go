@@ -0,0 +1,215 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ReplayMode selects whether a ReplayTransport talks to the network.
+type ReplayMode int
+
+const (
+	// Replay answers every request from its recording and fails for requests without one.
+	Replay ReplayMode = iota
+	// Record sends every request and overwrites its recording with the response.
+	Record
+	// RecordMissing answers requests from their recordings and sends and records the others.
+	RecordMissing
+)
+
+// ErrNoRecording is returned by a replaying ReplayTransport for a request that was never recorded.
+var ErrNoRecording = errors.New("no recording")
+
+// DefaultRedactedParams are the query parameters that carry API keys and are removed from recordings.
+var DefaultRedactedParams = []string{"apikey", "api_key", "token", "key"}
+
+// recording is a recorded response, stored as indented JSON so that golden files diff well.
+type recording struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// ReplayTransport is an http.RoundTripper that records provider responses to golden files and replays
+// them, so that clients can be exercised deterministically without network access. Requests are keyed
+// by method and URL without the redacted query parameters, so recordings contain no API keys and
+// replay regardless of the key in use.
+type ReplayTransport struct {
+	// Dir holds one file per recorded request.
+	Dir  string
+	Mode ReplayMode
+	// Next sends the requests that are recorded. Nil uses http.DefaultTransport.
+	Next http.RoundTripper
+	// RedactedParams are the query parameters left out of keys and recordings.
+	RedactedParams []string
+}
+
+// NewReplayTransport creates a ReplayTransport that redacts DefaultRedactedParams.
+//
+// Parameters:
+// - dir: The directory of the recordings. It is created when the first response is recorded.
+// - mode: Whether to replay, record or record missing responses.
+// Returns a pointer to the newly created ReplayTransport.
+func NewReplayTransport(dir string, mode ReplayMode) *ReplayTransport {
+	return &ReplayTransport{Dir: dir, Mode: mode, RedactedParams: DefaultRedactedParams}
+}
+
+// RoundTrip answers a request from its recording or sends and records it, depending on the mode.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.key(req)
+	path := filepath.Join(t.Dir, t.fileName(req.Method, key))
+
+	if t.Mode != Record {
+		rec, err := readRecording(path)
+		if err == nil {
+			return rec.response(req), nil
+		}
+		if t.Mode == Replay || !errors.Is(err, os.ErrNotExist) {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("%w for %s %s in %s", ErrNoRecording, req.Method, key, path)
+			}
+			return nil, err
+		}
+	}
+
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := recording{Method: req.Method, URL: key, StatusCode: resp.StatusCode, Header: recordedHeader(resp.Header), Body: string(body)}
+	if err := writeRecording(path, rec); err != nil {
+		return nil, err
+	}
+	return rec.response(req), nil
+}
+
+// key returns the URL of a request without the redacted query parameters and with the others sorted.
+func (t *ReplayTransport) key(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	for name := range query {
+		for _, redacted := range t.RedactedParams {
+			if strings.EqualFold(name, redacted) {
+				query.Del(name)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.User = nil
+	return u.String()
+}
+
+// unsafeFileChars are the characters replaced in the readable part of file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileName returns the name of the recording of a request: a readable prefix made of the host and path,
+// followed by a hash of the method and key that keeps names unique.
+func (t *ReplayTransport) fileName(method, key string) string {
+	readable := key
+	if u, err := url.Parse(key); err == nil {
+		readable = u.Host + u.Path
+	}
+	readable = strings.Trim(unsafeFileChars.ReplaceAllString(readable, "_"), "_")
+	if len(readable) > 80 {
+		readable = readable[:80]
+	}
+	sum := sha256.Sum256([]byte(method + " " + key))
+	return fmt.Sprintf("%s_%s.json", readable, hex.EncodeToString(sum[:6]))
+}
+
+// recordedHeader keeps the response headers that clients read.
+func recordedHeader(header http.Header) http.Header {
+	kept := http.Header{}
+	for _, name := range []string{"Content-Type", "Retry-After"} {
+		if value := header.Get(name); value != "" {
+			kept.Set(name, value)
+		}
+	}
+	return kept
+}
+
+// response turns a recording into a response to a request.
+func (r recording) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// readRecording reads a recording from a golden file.
+func readRecording(path string) (recording, error) {
+	var rec recording
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+	return rec, nil
+}
+
+// writeRecording writes a recording to a golden file.
+func writeRecording(path string, rec recording) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(rec); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// NewReplayHTTPClient creates an HTTPClient that sends requests through a ReplayTransport. When the
+// transport only replays, the client neither rate limits nor retries, so replays run at full speed.
+//
+// Parameters:
+// - transport: The transport that replays or records responses.
+// - config: The configuration used when the transport sends requests to the provider.
+// Returns a pointer to the newly created HTTPClient.
+func NewReplayHTTPClient(transport *ReplayTransport, config HTTPConfig) *HTTPClient {
+	if transport.Mode == Replay {
+		config.RequestsPerMinute = 0
+		config.MaxRetries = 0
+	}
+	config.Transport = transport
+	return NewHTTPClient(config)
+}
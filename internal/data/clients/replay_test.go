@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayHTTP returns an HTTPClient that replays the recordings of a scenario in testdata/replay.
+func replayHTTP(t *testing.T, scenario string) *HTTPClient {
+	t.Helper()
+	return NewReplayHTTPClient(NewReplayTransport(filepath.Join("testdata", "replay", scenario), Replay), DefaultHTTPConfig(0, 1))
+}
+
+// clockAt returns a clock stopped at an RFC 3339 time.
+func clockAt(t *testing.T, value string) func() time.Time {
+	t.Helper()
+	now := parseTime(t, value)
+	return func() time.Time { return now }
+}
+
+// unixAt returns the Unix time of an RFC 3339 time.
+func unixAt(t *testing.T, value string) int64 {
+	t.Helper()
+	return parseTime(t, value).Unix()
+}
+
+func parseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// assertBars compares bars with the expected ones, field by field.
+func assertBars(t *testing.T, got, want []data_types.MarketData) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d bars, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bar %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// assertSkipped compares the skipped rows of a report with the expected row indices and raw rows.
+func assertSkipped(t *testing.T, report data_types.FetchReport, want ...data_types.SkippedRow) {
+	t.Helper()
+	if len(report.Skipped) != len(want) {
+		t.Fatalf("got %d skipped rows, want %d: %+v", len(report.Skipped), len(want), report.Skipped)
+	}
+	for i, skipped := range report.Skipped {
+		if skipped.Row != want[i].Row || skipped.Raw != want[i].Raw || skipped.Err == nil {
+			t.Errorf("skipped row %d: got %d %q %v, want %d %q", i, skipped.Row, skipped.Raw, skipped.Err, want[i].Row, want[i].Raw)
+		}
+	}
+}
+
+// assertReport compares the counts of a report.
+func assertReport(t *testing.T, report data_types.FetchReport, requests, rows, bars int) {
+	t.Helper()
+	if report.Requests != requests || report.Rows != rows || report.Bars != bars {
+		t.Errorf("report %v: want %d requests, %d rows, %d bars", report, requests, rows, bars)
+	}
+}
+
+// roundTripFunc sends requests to a function in place of the network.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestReplayTransportRecordsAndReplays(t *testing.T) {
+	dir := t.TempDir()
+	sent := 0
+	recorder := NewReplayTransport(dir, Record)
+	recorder.Next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		header := http.Header{"Content-Type": {"text/csv"}, "Set-Cookie": {"session=secret"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader("timestamp,open")), Request: req}, nil
+	})
+	body, err := NewReplayHTTPClient(recorder, DefaultHTTPConfig(0, 1)).Get(context.Background(), "https://example.com/query?symbol=IBM&apikey=secret&function=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "timestamp,open" || sent != 1 {
+		t.Fatalf("recording: got %q after %d requests", body, sent)
+	}
+
+	// The recording holds neither the API key nor headers clients do not read
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got recordings %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("recording leaks a secret: %s", data)
+	}
+	if !strings.Contains(string(data), "https://example.com/query?function=DAILY&symbol=IBM") {
+		t.Errorf("recording does not hold the sorted URL without the key: %s", data)
+	}
+
+	// Replays match whatever the key and the order of the parameters
+	replayer := NewReplayHTTPClient(NewReplayTransport(dir, Replay), DefaultHTTPConfig(0, 1))
+	body, err = replayer.Get(context.Background(), "https://example.com/query?function=DAILY&apikey=other&symbol=IBM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "timestamp,open" || sent != 1 {
+		t.Errorf("replay: got %q after %d requests", body, sent)
+	}
+
+	_, err = replayer.Get(context.Background(), "https://example.com/query?function=DAILY&symbol=MSFT")
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("missing recording: got %v, want ErrNoRecording", err)
+	}
+}
+
+func TestReplayTransportRecordsMissing(t *testing.T) {
+	dir := t.TempDir()
+	sent := 0
+	transport := NewReplayTransport(dir, RecordMissing)
+	transport.Next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("Unknown symbol")), Request: req}, nil
+	})
+	client := NewReplayHTTPClient(transport, DefaultHTTPConfig(0, 1))
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), "https://example.com/stock/NOPE/chart?token=secret")
+		if !errors.Is(err, data_types.ErrInvalidSymbol) {
+			t.Errorf("request %d: got %v, want ErrInvalidSymbol", i, err)
+		}
+	}
+	if sent != 1 {
+		t.Errorf("sent %d requests, want 1", sent)
+	}
+}
@@ -0,0 +1,34 @@
+package clients
+
+import data_types "goquant/pkg/data"
+
+// Every client and the file source serve daily bars through DataSource, bars at any supported interval
+// through BarSource and report the rows they skip through ReportingSource.
+var (
+	_ data_types.DataSource = (*AlphaVantageClient)(nil)
+	_ data_types.DataSource = (*BinanceClient)(nil)
+	_ data_types.DataSource = (*FileDataSource)(nil)
+	_ data_types.DataSource = (*GoogleFinanceDataSource)(nil)
+	_ data_types.DataSource = (*IEXCloudClient)(nil)
+	_ data_types.DataSource = (*TwelveDataClient)(nil)
+	_ data_types.DataSource = (*YahooFinanceDataSource)(nil)
+
+	_ data_types.BarSource = (*AlphaVantageClient)(nil)
+	_ data_types.BarSource = (*BinanceClient)(nil)
+	_ data_types.BarSource = (*FileDataSource)(nil)
+	_ data_types.BarSource = (*GoogleFinanceDataSource)(nil)
+	_ data_types.BarSource = (*IEXCloudClient)(nil)
+	_ data_types.BarSource = (*TwelveDataClient)(nil)
+	_ data_types.BarSource = (*YahooFinanceDataSource)(nil)
+
+	_ data_types.ReportingSource = (*AlphaVantageClient)(nil)
+	_ data_types.ReportingSource = (*BinanceClient)(nil)
+	_ data_types.ReportingSource = (*FileDataSource)(nil)
+	_ data_types.ReportingSource = (*GoogleFinanceDataSource)(nil)
+	_ data_types.ReportingSource = (*IEXCloudClient)(nil)
+	_ data_types.ReportingSource = (*TwelveDataClient)(nil)
+	_ data_types.ReportingSource = (*YahooFinanceDataSource)(nil)
+)
+
+// The FRED client serves economic series through SeriesSource.
+var _ data_types.SeriesSource = (*FREDClient)(nil)
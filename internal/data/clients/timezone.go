@@ -0,0 +1,34 @@
+package clients
+
+import (
+	"time"
+	_ "time/tzdata"
+)
+
+// newYork is the time zone of US exchanges, in which Alpha Vantage and IEX Cloud report intraday bars.
+// The embedded time zone database keeps it available on machines without one.
+var newYork = mustLoadLocation("America/New_York")
+
+// mustLoadLocation loads a time zone of the embedded database.
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// sessionDate returns midnight UTC of the date that an instant falls on in the exchange's time zone,
+// which is how daily and weekly bars are timestamped.
+func sessionDate(t time.Time, exchange *time.Location) time.Time {
+	year, month, day := t.In(exchange).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// clockNow returns the time of a clock, or the wall-clock time if the clock is nil.
+func clockNow(clock func() time.Time) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
@@ -0,0 +1,33 @@
+package clients
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionDate(t *testing.T) {
+	sydney := mustLoadLocation("Australia/Sydney")
+	tests := []struct {
+		instant  string
+		exchange *time.Location
+		want     string
+	}{
+		// The New York open before and after both transitions of 2024
+		{"2024-03-08T14:30:00Z", newYork, "2024-03-08"},
+		{"2024-03-11T13:30:00Z", newYork, "2024-03-11"},
+		{"2024-11-01T13:30:00Z", newYork, "2024-11-01"},
+		{"2024-11-04T14:30:00Z", newYork, "2024-11-04"},
+		// After-hours in New York fall on the next UTC date
+		{"2024-03-12T00:30:00Z", newYork, "2024-03-11"},
+		{"2024-11-05T00:30:00Z", newYork, "2024-11-04"},
+		// Sydney opens on the previous UTC date
+		{"2024-03-07T23:00:00Z", sydney, "2024-03-08"},
+	}
+	for _, tt := range tests {
+		instant := parseTime(t, tt.instant)
+		got := sessionDate(instant, tt.exchange)
+		if got.Format(time.RFC3339) != tt.want+"T00:00:00Z" {
+			t.Errorf("%s in %s: got %s, want %s", tt.instant, tt.exchange, got.Format(time.RFC3339), tt.want)
+		}
+	}
+}
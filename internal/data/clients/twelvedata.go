@@ -1,10 +1,11 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	data_types "goquant/pkg/data"
@@ -13,6 +14,35 @@ import (
 type TwelveDataClient struct {
 	APIKey  string
 	BaseURL string
+	// HTTP performs the requests. The default allows the 8 requests per minute of the basic plan.
+	HTTP *HTTPClient
+	// Concurrency is the number of chunks of a long range fetched at once, within the rate limit of HTTP.
+	Concurrency int
+	// Now returns the current time, against which the history limits of intervals are checked. Nil uses
+	// time.Now; replays of recorded responses set it to the time of the recording.
+	Now func() time.Time
+}
+
+// twelveDataOutputSize is the most bars the time_series endpoint returns per request. Tests lower it to
+// page through small recorded responses.
+var twelveDataOutputSize = 5000
+
+// twelveDataSession is the length of the regular US equity session that chunks are sized for.
+const twelveDataSession = 390 * time.Minute
+
+// twelveDataChunkSpan returns the calendar span expected to hold twelveDataOutputSize bars of an equity
+// trading 6.5 hours on weekdays. Weekly bars and markets trading longer fill a chunk sooner, in which
+// case fetchChunk pages through the rest of it.
+func twelveDataChunkSpan(interval data_types.Interval) time.Duration {
+	if interval.Duration() > 24*time.Hour {
+		return time.Duration(twelveDataOutputSize) * interval.Duration()
+	}
+	barsPerDay := 1
+	if interval.Intraday() {
+		barsPerDay = int((twelveDataSession + interval.Duration() - 1) / interval.Duration())
+	}
+	tradingDays := twelveDataOutputSize / barsPerDay
+	return time.Duration(tradingDays*7/5) * 24 * time.Hour
 }
 
 // NewTwelveDataClient creates a new TwelveDataClient instance.
@@ -21,73 +51,230 @@ type TwelveDataClient struct {
 // Returns a pointer to a TwelveDataClient object.
 func NewTwelveDataClient(apiKey string) *TwelveDataClient {
 	return &TwelveDataClient{
-		APIKey:  apiKey,
-		BaseURL: "https://api.twelvedata.com/time_series?",
+		APIKey:      apiKey,
+		BaseURL:     "https://api.twelvedata.com/time_series?",
+		HTTP:        NewHTTPClient(DefaultHTTPConfig(8, 1)),
+		Concurrency: 1,
 	}
 }
 
+// twelveDataIntervals maps intervals to the interval parameter of the time_series endpoint.
+var twelveDataIntervals = map[data_types.Interval]string{
+	data_types.Interval1m:  "1min",
+	data_types.Interval5m:  "5min",
+	data_types.Interval15m: "15min",
+	data_types.Interval1h:  "1h",
+	data_types.Interval1d:  "1day",
+	data_types.Interval1w:  "1week",
+}
+
+// Capabilities returns the intervals served by Twelve Data.
+func (c *TwelveDataClient) Capabilities() data_types.Capabilities {
+	return data_types.Capabilities{Intervals: data_types.Intervals}
+}
+
+// Fetch fetches daily data for a given symbol.
+//
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *TwelveDataClient) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchContext(context.Background(), symbol, start, end)
+}
+
+// FetchContext fetches daily data for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Returns a slice of MarketData and an error.
+func (c *TwelveDataClient) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(ctx, symbol, start, end, data_types.Interval1d)
+}
+
 // FetchMinuteData fetches intraday minute data for a given symbol.
 //
+// Deprecated: use FetchBars with a typed Interval.
+//
 // Parameter symbol is the stock symbol to fetch data for.
 // Parameter start and end are the start and end timestamps to filter data by.
 // Parameter interval is the interval of the data (e.g., 1min, 5min, etc.).
 // Returns a slice of MarketData and an error.
 func (c *TwelveDataClient) FetchMinuteData(symbol string, start, end int64, interval string) ([]data_types.MarketData, error) {
-	// Prepare the URL
-	url := fmt.Sprintf("%ssymbol=%s&interval=%s&apikey=%s&start_date=%s&end_date=%s&format=JSON",
-		c.BaseURL, symbol, interval, c.APIKey,
-		time.Unix(start, 0).Format("2006-01-02 15:04:05"),
-		time.Unix(end, 0).Format("2006-01-02 15:04:05"))
-
-	// Perform the request
-	resp, err := http.Get(url)
+	parsed, err := data_types.ParseInterval(interval)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching data: %v", err)
+		return nil, err
+	}
+	return c.FetchBars(symbol, start, end, parsed)
+}
+
+// FetchBars fetches bars at the given interval for a given symbol.
+//
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (c *TwelveDataClient) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	return c.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+// FetchBarsContext fetches bars at the given interval for a given symbol until the context is done.
+//
+// Parameter ctx cancels the request, including its retries and remaining chunks.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData and an error.
+func (c *TwelveDataClient) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+	data, _, err := c.FetchBarsReport(ctx, symbol, start, end, interval)
+	return data, err
+}
+
+// FetchBarsReport fetches bars at the given interval for a given symbol until the context is done and
+// reports the rows it had to skip. Ranges longer than one request can return are split into chunks
+// expected to hold 5000 bars of regular trading hours, which are fetched Concurrency at a time and merged
+// into one sorted series without duplicates.
+//
+// Parameter ctx cancels the request, including its retries and remaining chunks.
+// Parameter symbol is the stock symbol to fetch data for.
+// Parameter start and end are the start and end timestamps to filter data by.
+// Parameter interval is the bar interval.
+// Returns a slice of MarketData, the report and an error wrapping one of the errors of data_types if
+// Twelve Data rejects the request or its response cannot be parsed.
+func (c *TwelveDataClient) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+	report := data_types.NewFetchReport("twelvedata", symbol, interval)
+	if err := c.Capabilities().Check(interval, start, clockNow(c.Now)); err != nil {
+		return nil, report, err
 	}
-	body, error := ioutil.ReadAll(resp.Body)
-	fmt.Println(string(body))
-	if error != nil {
-		return nil, fmt.Errorf("error reading body: %v", error)
+
+	chunks := SplitRange(start, end, twelveDataChunkSpan(interval))
+	data, err := fetchChunks(ctx, chunks, c.Concurrency, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+		return c.fetchChunk(ctx, symbol, chunk.Start, chunk.End, interval, report)
+	})
+	return data, report, err
+}
+
+// twelveDataResponse is the body of the time_series endpoint.
+type twelveDataResponse struct {
+	Values []struct {
+		Datetime string `json:"datetime"`
+		Open     string `json:"open"`
+		High     string `json:"high"`
+		Low      string `json:"low"`
+		Close    string `json:"close"`
+		Volume   string `json:"volume"`
+	} `json:"values"`
+}
+
+// fetchChunk fetches the bars of a chunk. Twelve Data returns the latest bars of a range that holds more
+// than a request can return, so a full response is followed by a request for the range before its
+// oldest bar.
+func (c *TwelveDataClient) fetchChunk(ctx context.Context, symbol string, start, end int64, interval data_types.Interval, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+	var data []data_types.MarketData
+	for end >= start {
+		page, oldest, rows, err := c.fetchPage(ctx, symbol, start, end, interval, report)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, page...)
+		if rows < twelveDataOutputSize || oldest > end {
+			break
+		}
+		end = oldest - 1
 	}
-	defer resp.Body.Close()
+	return data, nil
+}
+
+// fetchPage fetches the bars of a range with a single request and returns them with the timestamp of
+// the oldest row and the number of rows received.
+func (c *TwelveDataClient) fetchPage(ctx context.Context, symbol string, start, end int64, interval data_types.Interval, report *data_types.FetchReport) ([]data_types.MarketData, int64, int, error) {
+	// Prepare the URL. Twelve Data reports and interprets times in the exchange's time zone unless told
+	// otherwise, so both directions use UTC.
+	requestURL := fmt.Sprintf("%ssymbol=%s&interval=%s&apikey=%s&start_date=%s&end_date=%s&outputsize=%d&timezone=UTC&format=JSON",
+		c.BaseURL, symbol, twelveDataIntervals[interval], c.APIKey,
+		url.QueryEscape(time.Unix(start, 0).UTC().Format("2006-01-02 15:04:05")),
+		url.QueryEscape(time.Unix(end, 0).UTC().Format("2006-01-02 15:04:05")), twelveDataOutputSize)
 
+	// Perform the request
+	body, err := c.HTTP.GetChecked(ctx, requestURL, checkTwelveData)
 	if err != nil {
-		return nil, fmt.Errorf("error reading body: %v", err)
+		return nil, 0, 0, fmt.Errorf("error fetching data: %w", err)
 	}
+	report.Requests++
 
 	// Parse the JSON response
-	var result struct {
-		Values []struct {
-			Datetime string  `json:"datetime"`
-			Open     float64 `json:"open,string"`
-			High     float64 `json:"high,string"`
-			Low      float64 `json:"low,string"`
-			Close    float64 `json:"close,string"`
-			Volume   int64   `json:"volume,string"`
-		} `json:"values"`
-	}
-
+	var result twelveDataResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("error unmarshaling JSON: %v", err)
+		return nil, 0, 0, malformedf("error unmarshaling JSON: %v", err)
 	}
 
 	// Process the data and filter by the start and end time
+	layout := "2006-01-02 15:04:05"
+	if !interval.Intraday() {
+		layout = "2006-01-02"
+	}
 	var data []data_types.MarketData
-	for _, record := range result.Values {
-		timestamp, _ := time.Parse("2006-01-02 15:04:05", record.Datetime)
-		if timestamp.Unix() < start || timestamp.Unix() > end {
+	oldest := end + 1
+	rows, skipped := len(result.Values), len(report.Skipped)
+	report.Rows += rows
+	for i, record := range result.Values {
+		raw := fmt.Sprintf("%s,%s,%s,%s,%s,%s", record.Datetime, record.Open, record.High, record.Low, record.Close, record.Volume)
+		timestamp, err := time.Parse(layout, record.Datetime)
+		if err != nil {
+			report.Skip(i, raw, fmt.Errorf("invalid datetime %q", record.Datetime))
+			continue
+		}
+		oldest = min(oldest, timestamp.Unix())
+		// Forex pairs and indices have no volume
+		open, high, low, closePrice, volume, err := parseOHLCV([]string{record.Open, record.High, record.Low, record.Close, record.Volume})
+		if err != nil {
+			report.Skip(i, raw, err)
 			continue
 		}
-		data = append(data, data_types.MarketData{
+		bar := data_types.MarketData{
 			Timestamp: timestamp.Unix(),
 			Ticker:    symbol,
-			Open:      record.Open,
-			High:      record.High,
-			Low:       record.Low,
-			Close:     record.Close,
-			Volume:    record.Volume,
-		})
+			Open:      open,
+			High:      high,
+			Low:       low,
+			Close:     closePrice,
+			Volume:    volume,
+		}
+		if !acceptBar(report, i, raw, bar) || bar.Timestamp < start || bar.Timestamp > end {
+			continue
+		}
+		data = append(data, bar)
 	}
 
-	return data, nil
+	return data, oldest, rows, checkSkipped(report, rows, skipped)
+}
+
+// checkTwelveData turns the error responses of Twelve Data, which carry a status of "error" with a code
+// and message and usually HTTP status 200, into errors of data_types. A range without bars is reported
+// as an error with code 400 and is not an error here.
+func checkTwelveData(body []byte) error {
+	var result struct {
+		Status  string `json:"status"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return malformedf("error unmarshaling JSON: %v", err)
+	}
+	if result.Status != "error" {
+		return nil
+	}
+
+	message := strings.ToLower(result.Message)
+	switch {
+	case result.Code == 429:
+		return fmt.Errorf("%w: %s", data_types.ErrRateLimited, result.Message)
+	case result.Code == 401 || result.Code == 403:
+		return fmt.Errorf("%w: %s", data_types.ErrAuth, result.Message)
+	case strings.Contains(message, "no data is available"):
+		return nil
+	case result.Code == 400 || result.Code == 404:
+		return fmt.Errorf("%w: %s", data_types.ErrInvalidSymbol, result.Message)
+	}
+	return fmt.Errorf("twelve data error %d: %s", result.Code, result.Message)
 }
@@ -0,0 +1,90 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayTwelveData returns a TwelveDataClient replaying a scenario recorded on 2024-03-15.
+func replayTwelveData(t *testing.T, scenario string) *TwelveDataClient {
+	client := NewTwelveDataClient("")
+	client.HTTP = replayHTTP(t, "twelvedata/"+scenario)
+	client.Now = clockAt(t, "2024-03-15T00:00:00Z")
+	return client
+}
+
+func TestTwelveDataDaily(t *testing.T) {
+	client := replayTwelveData(t, "daily")
+	data, report, err := client.FetchBarsReport(context.Background(), "AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 176.14999, High: 176.89999, Low: 173.78999, Close: 175.10001, Volume: 81510100},
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-06T00:00:00Z"), Open: 171.06, High: 171.24001, Low: 168.67999, Close: 169.12, Volume: 68587700},
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-07T00:00:00Z"), Open: 169.14999, High: 170.73, Low: 168.49001, Close: 169, Volume: 71765100},
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-08T00:00:00Z"), Open: 169, High: 173.7, Low: 168.94, Close: 170.73, Volume: 76114600},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 3, Raw: "2024-03-05,170.75999,172.03999,,170.12000,95132400"})
+	assertReport(t, report, 1, 5, 4)
+}
+
+func TestTwelveDataNoData(t *testing.T) {
+	// A range without bars is reported as an error by Twelve Data but is an empty result here
+	client := replayTwelveData(t, "no_data")
+	data, report, err := client.FetchBarsReport(context.Background(), "AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+	if err != nil || len(data) != 0 {
+		t.Fatalf("got %d bars and %v, want none", len(data), err)
+	}
+	assertReport(t, report, 1, 0, 0)
+}
+
+func TestTwelveDataErrors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		want     error
+	}{
+		{"invalid_symbol", data_types.ErrInvalidSymbol},
+		{"auth", data_types.ErrAuth},
+		{"rate_limited", data_types.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		client := replayTwelveData(t, tt.scenario)
+		_, _, err := client.FetchBarsReport(context.Background(), "AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-08T00:00:00Z"), data_types.Interval1d)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.scenario, err, tt.want)
+		}
+	}
+}
+
+func TestTwelveDataPaging(t *testing.T) {
+	// With three bars a request, the range is split into chunks of four days fetched two at a time. Each
+	// response also holds the trading day before its start, which is dropped. The first chunk holds four
+	// trading days, so its full first page is followed by a request for the day before the oldest bar.
+	defer func(size int) { twelveDataOutputSize = size }(twelveDataOutputSize)
+	twelveDataOutputSize = 3
+	client := replayTwelveData(t, "paging")
+	client.Concurrency = 2
+	data, report, err := client.FetchBarsReport(context.Background(), "AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-13T00:00:00Z"), data_types.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2024-03-04", "2024-03-05", "2024-03-06", "2024-03-07", "2024-03-08", "2024-03-11", "2024-03-12", "2024-03-13"}
+	if len(data) != len(want) {
+		t.Fatalf("got %d bars, want %d", len(data), len(want))
+	}
+	for i, date := range want {
+		if data[i].Timestamp != unixAt(t, date+"T00:00:00Z") || data[i].Close != float64(172+i) {
+			t.Errorf("bar %d: got %s closing at %v, want %s", i, time.Unix(data[i].Timestamp, 0).UTC().Format(time.DateOnly), data[i].Close, date)
+		}
+	}
+	// Four requests: two pages of the first chunk and one of each other chunk
+	assertReport(t, report, 4, 11, 8)
+	if report.Duplicates != 0 {
+		t.Errorf("got %d duplicates, want 0", report.Duplicates)
+	}
+}
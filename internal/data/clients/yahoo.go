@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,21 +10,37 @@ import (
 	data_types "goquant/pkg/data"
 )
 
+// yahooFinanceURL is the default BaseURL of YahooFinanceDataSource.
 const yahooFinanceURL = "https://query1.finance.yahoo.com/v8/finance/chart/"
 
 
 type YahooFinanceDataSource struct {
+    // BaseURL is the chart endpoint, up to the symbol.
+    BaseURL string
+    // HTTP performs the requests.
+    HTTP *HTTPClient
+    // Client, if set, sends the requests instead of the http.Client of HTTP, keeping the rate limit
+    // and retries of HTTP.
+    //
+    // Deprecated: set HTTP, or the Transport of its HTTPConfig, instead.
     Client *http.Client
+    // Concurrency is the number of chunks of a long range fetched at once, within the rate limit of HTTP.
+    Concurrency int
+    // Now returns the current time, against which the history limits of intervals are checked. Nil uses
+    // time.Now; replays of recorded responses set it to the time of the recording.
+    Now func() time.Time
 }
 
 
-// NewYahooFinanceDataSource creates a new YahooFinanceDataSource with a default HTTP client.
+// NewYahooFinanceDataSource creates a new YahooFinanceDataSource with a default HTTP client that makes at most 60 requests per minute.
 //
 // No parameters.
 // Returns a pointer to a YahooFinanceDataSource object.
 func NewYahooFinanceDataSource() *YahooFinanceDataSource {
     return &YahooFinanceDataSource{
-        Client: &http.Client{Timeout: 10 * time.Second},
+        BaseURL:     yahooFinanceURL,
+        HTTP:        NewHTTPClient(DefaultHTTPConfig(60, 5)),
+        Concurrency: 2,
     }
 }
 
@@ -37,25 +54,145 @@ func NewYahooFinanceDataSource() *YahooFinanceDataSource {
 // Returns:
 //   A slice of data_types.MarketData and an error.
 func (y *YahooFinanceDataSource) Fetch(symbol string, start, end int64) ([]data_types.MarketData, error) {
-    url := fmt.Sprintf("%s%s?period1=%d&period2=%d&interval=1d", yahooFinanceURL, symbol, start, end)
+    return y.FetchContext(context.Background(), symbol, start, end)
+}
 
-    resp, err := y.Client.Get(url)
-    if err != nil {
-        return nil, fmt.Errorf("failed to fetch data_types from Yahoo Finance: %v", err)
+
+// FetchContext retrieves market data_types from Yahoo Finance until the context is done
+//
+// Parameters:
+//   ctx (context.Context): Cancels the request, including its retries.
+//   symbol (string): The stock symbol to fetch data for.
+//   start (int64): The start date of the time range to fetch data for.
+//   end (int64): The end date of the time range to fetch data for.
+// Returns:
+//   A slice of data_types.MarketData and an error.
+func (y *YahooFinanceDataSource) FetchContext(ctx context.Context, symbol string, start, end int64) ([]data_types.MarketData, error) {
+    return y.FetchBarsContext(ctx, symbol, start, end, data_types.Interval1d)
+}
+
+
+// yahooIntervals maps intervals to the interval parameter of the chart endpoint.
+var yahooIntervals = map[data_types.Interval]string{
+    data_types.Interval1m:  "1m",
+    data_types.Interval5m:  "5m",
+    data_types.Interval15m: "15m",
+    data_types.Interval1h:  "60m",
+    data_types.Interval1d:  "1d",
+    data_types.Interval1w:  "1wk",
+}
+
+
+// yahooChunkSpans is the longest range the chart endpoint returns per request for intervals that
+// are limited per request rather than only by history.
+var yahooChunkSpans = map[data_types.Interval]time.Duration{
+    data_types.Interval1m: 7 * 24 * time.Hour,
+}
+
+
+// Capabilities returns the intervals served by Yahoo Finance. Minute bars cover the last 30 days,
+// 5 and 15 minute bars the last 60 days and hourly bars the last 730 days.
+func (y *YahooFinanceDataSource) Capabilities() data_types.Capabilities {
+    return data_types.Capabilities{
+        Intervals: data_types.Intervals,
+        MaxHistory: map[data_types.Interval]time.Duration{
+            data_types.Interval1m:  30 * 24 * time.Hour,
+            data_types.Interval5m:  60 * 24 * time.Hour,
+            data_types.Interval15m: 60 * 24 * time.Hour,
+            data_types.Interval1h:  730 * 24 * time.Hour,
+        },
+    }
+}
+
+
+// FetchBars retrieves bars at the given interval from Yahoo Finance
+//
+// Parameters:
+//   symbol (string): The stock symbol to fetch data for.
+//   start (int64): The start date of the time range to fetch data for.
+//   end (int64): The end date of the time range to fetch data for.
+//   interval (data_types.Interval): The bar interval.
+// Returns:
+//   A slice of data_types.MarketData and an error.
+func (y *YahooFinanceDataSource) FetchBars(symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+    return y.FetchBarsContext(context.Background(), symbol, start, end, interval)
+}
+
+
+// FetchBarsContext retrieves bars at the given interval from Yahoo Finance until the context is done.
+//
+// Parameters:
+//   ctx (context.Context): Cancels the request, including its retries and remaining chunks.
+//   symbol (string): The stock symbol to fetch data for.
+//   start (int64): The start date of the time range to fetch data for.
+//   end (int64): The end date of the time range to fetch data for.
+//   interval (data_types.Interval): The bar interval.
+// Returns:
+//   A slice of data_types.MarketData and an error.
+func (y *YahooFinanceDataSource) FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, error) {
+    data, _, err := y.FetchBarsReport(ctx, symbol, start, end, interval)
+    return data, err
+}
+
+
+// FetchBarsReport retrieves bars at the given interval from Yahoo Finance until the context is done
+// and reports the rows it had to skip. Minute bars are fetched in chunks of 7 days, Concurrency at a
+// time, and merged into one sorted series without duplicates.
+//
+// Parameters:
+//   ctx (context.Context): Cancels the request, including its retries and remaining chunks.
+//   symbol (string): The stock symbol to fetch data for.
+//   start (int64): The start date of the time range to fetch data for.
+//   end (int64): The end date of the time range to fetch data for.
+//   interval (data_types.Interval): The bar interval.
+// Returns:
+//   A slice of data_types.MarketData, the report and an error wrapping one of the errors of
+//   data_types if Yahoo Finance rejects the request or its response cannot be parsed.
+func (y *YahooFinanceDataSource) FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval data_types.Interval) ([]data_types.MarketData, data_types.FetchReport, error) {
+    report := data_types.NewFetchReport("yahoo", symbol, interval)
+    if err := y.Capabilities().Check(interval, start, clockNow(y.Now)); err != nil {
+        return nil, report, err
     }
-    defer resp.Body.Close()
 
-    if resp.StatusCode != http.StatusOK {
-        return nil, fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+    chunks := SplitRange(start, end, yahooChunkSpans[interval])
+    data, err := fetchChunks(ctx, chunks, y.Concurrency, &report, func(ctx context.Context, chunk Chunk, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+        return y.fetchChunk(ctx, symbol, chunk.Start, chunk.End, interval, report)
+    })
+    return data, report, err
+}
+
+
+// fetchChunk retrieves the bars of a range that fits into a single request.
+func (y *YahooFinanceDataSource) fetchChunk(ctx context.Context, symbol string, start, end int64, interval data_types.Interval, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+    url := fmt.Sprintf("%s%s?period1=%d&period2=%d&interval=%s", y.BaseURL, symbol, start, end, yahooIntervals[interval])
+
+    body, err := y.httpClient().Get(ctx, url)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch data_types from Yahoo Finance: %w", err)
     }
+    report.Requests++
 
     var result yahooFinanceResponse
-    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-        return nil, fmt.Errorf("failed to decode response: %v", err)
+    if err := json.Unmarshal(body, &result); err != nil {
+        return nil, malformedf("failed to decode response: %v", err)
+    }
+
+    data, err := convertToMarketData(symbol, result, report)
+    if err != nil || interval.Intraday() {
+        return data, err
     }
 
-    marketData := convertToMarketData(symbol, result)
-    return marketData, nil
+    // Daily and weekly bars carry the instant the session opened; date them in the exchange's time zone
+    exchange := newYork
+    if name := result.Chart.Result[0].Meta.ExchangeTimezoneName; name != "" {
+        if loc, err := time.LoadLocation(name); err == nil {
+            exchange = loc
+        }
+    }
+    for i := range data {
+        data[i].Timestamp = sessionDate(time.Unix(data[i].Timestamp, 0), exchange).Unix()
+    }
+    return data, nil
 }
 
 
@@ -64,42 +201,104 @@ func (y *YahooFinanceDataSource) Fetch(symbol string, start, end int64) ([]data_
 // Parameters:
 //   symbol (string): The stock symbol of the MarketData.
 //   response (yahooFinanceResponse): The Yahoo Finance response to be converted.
+//   report (*data_types.FetchReport): Receives the rows that are skipped because a quote is null or invalid.
 // Returns:
-//   A slice of data_types.MarketData.
-func convertToMarketData(symbol string, response yahooFinanceResponse) []data_types.MarketData {
+//   A slice of data_types.MarketData, or an error if the response reports an error or is not a chart.
+func convertToMarketData(symbol string, response yahooFinanceResponse, report *data_types.FetchReport) ([]data_types.MarketData, error) {
+    if chartErr := response.Chart.Error; chartErr != nil {
+        if chartErr.Code == "Not Found" {
+            return nil, fmt.Errorf("%w: %s", data_types.ErrInvalidSymbol, chartErr.Description)
+        }
+        return nil, fmt.Errorf("yahoo finance error %s: %s", chartErr.Code, chartErr.Description)
+    }
+    if len(response.Chart.Result) == 0 {
+        return nil, malformedf("response contains no chart")
+    }
     result := response.Chart.Result[0]
     timestamps := result.Timestamp
+    if len(timestamps) == 0 {
+        // Ranges without trading days have no timestamps and no quotes
+        return nil, nil
+    }
+    if len(result.Indicators.Quote) == 0 {
+        return nil, malformedf("response contains %d timestamps but no quotes", len(timestamps))
+    }
     quotes := result.Indicators.Quote[0]
+    for name, values := range map[string]int{"open": len(quotes.Open), "high": len(quotes.High), "low": len(quotes.Low), "close": len(quotes.Close), "volume": len(quotes.Volume)} {
+        if values != len(timestamps) {
+            return nil, malformedf("response contains %d timestamps but %d %s quotes", len(timestamps), values, name)
+        }
+    }
 
     var dt []data_types.MarketData
+    invalid := 0
+    report.Rows += len(timestamps)
     for i, timestamp := range timestamps {
-        dt = append(dt, data_types.MarketData{
+        raw := time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+        // Yahoo sends null quotes for intervals without trades
+        if quotes.Open[i] == nil || quotes.High[i] == nil || quotes.Low[i] == nil || quotes.Close[i] == nil {
+            report.Skip(i, raw, fmt.Errorf("null quote"))
+            continue
+        }
+        bar := data_types.MarketData{
             Ticker:    symbol,
             Timestamp: timestamp,
-            Open:      quotes.Open[i],
-            High:      quotes.High[i],
-            Low:       quotes.Low[i],
-            Close:     quotes.Close[i],
-            Volume:    quotes.Volume[i],
-        })
-    }
-    return dt
+            Open:      *quotes.Open[i],
+            High:      *quotes.High[i],
+            Low:       *quotes.Low[i],
+            Close:     *quotes.Close[i],
+        }
+        if quotes.Volume[i] != nil {
+            bar.Volume = *quotes.Volume[i]
+        }
+        if !acceptBar(report, i, raw, bar) {
+            invalid++
+            continue
+        }
+        dt = append(dt, bar)
+    }
+    // Null quotes are expected, but if every other row is invalid the format has changed
+    if len(dt) == 0 && invalid > 0 {
+        return nil, malformedf("all %d non-null rows are invalid", invalid)
+    }
+    return dt, nil
 }
 
 
 type yahooFinanceResponse struct {
     Chart struct {
         Result []struct {
+            Meta struct {
+                ExchangeTimezoneName string `json:"exchangeTimezoneName"`
+            } `json:"meta"`
             Timestamp  []int64 `json:"timestamp"`
             Indicators struct {
                 Quote []struct {
-                    Open   []float64 `json:"open"`
-                    High   []float64 `json:"high"`
-                    Low    []float64 `json:"low"`
-                    Close  []float64 `json:"close"`
-                    Volume []int64   `json:"volume"`
+                    Open   []*float64 `json:"open"`
+                    High   []*float64 `json:"high"`
+                    Low    []*float64 `json:"low"`
+                    Close  []*float64 `json:"close"`
+                    Volume []*float64 `json:"volume"`
                 } `json:"quote"`
             } `json:"indicators"`
         } `json:"result"`
+        Error *struct {
+            Code        string `json:"code"`
+            Description string `json:"description"`
+        } `json:"error"`
     } `json:"chart"`
 }
+
+
+// httpClient returns the HTTPClient that performs the requests: HTTP, sending through Client if it is set.
+func (y *YahooFinanceDataSource) httpClient() *HTTPClient {
+    if y.Client == nil {
+        return y.HTTP
+    }
+    wrapped := &HTTPClient{Client: y.Client, Config: DefaultHTTPConfig(0, 1)}
+    if y.HTTP != nil {
+        wrapped.Limiter = y.HTTP.Limiter
+        wrapped.Config = y.HTTP.Config
+    }
+    return wrapped
+}
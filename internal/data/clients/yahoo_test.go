@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// replayYahoo returns a YahooFinanceDataSource replaying a scenario recorded on 2024-03-15.
+func replayYahoo(t *testing.T, scenario string) *YahooFinanceDataSource {
+	source := NewYahooFinanceDataSource()
+	source.HTTP = replayHTTP(t, "yahoo/"+scenario)
+	source.Now = clockAt(t, "2024-03-15T00:00:00Z")
+	return source
+}
+
+func TestYahooDaily(t *testing.T) {
+	source := replayYahoo(t, "daily")
+	data, report, err := source.FetchBarsReport(context.Background(), "AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-09T00:00:00Z"), data_types.Interval1d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Yahoo timestamps daily bars at the open, 14:30 UTC, and they are re-dated to midnight UTC
+	assertBars(t, data, []data_types.MarketData{
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-04T00:00:00Z"), Open: 176.14999389648438, High: 176.89999389648438, Low: 173.7899932861328, Close: 175.10000610351562, Volume: 81510100},
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-05T00:00:00Z"), Open: 170.75999450683594, High: 172.0399932861328, Low: 168.4199981689453, Close: 170.1199951171875, Volume: 95132400},
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-07T00:00:00Z"), Open: 169.14999389648438, High: 170.72999572753906, Low: 168.49000549316406, Close: 169, Volume: 71765100},
+		{Ticker: "AAPL", Timestamp: unixAt(t, "2024-03-08T00:00:00Z"), Open: 169, High: 173.6999969482422, Low: 168.94000244140625, Close: 170.72999572753906, Volume: 76114600},
+	})
+	assertSkipped(t, report, data_types.SkippedRow{Row: 2, Raw: "2024-03-06T14:30:00Z"})
+	assertReport(t, report, 1, 5, 4)
+}
+
+func TestYahooErrors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		want     error
+	}{
+		{"invalid_symbol", data_types.ErrInvalidSymbol},
+		{"rate_limited", data_types.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		source := replayYahoo(t, tt.scenario)
+		_, _, err := source.FetchBarsReport(context.Background(), "AAPL", unixAt(t, "2024-03-04T00:00:00Z"), unixAt(t, "2024-03-09T00:00:00Z"), data_types.Interval1d)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.scenario, err, tt.want)
+		}
+	}
+}
+
+func TestYahooDailySessionDate(t *testing.T) {
+	// Daily bars are dated in the exchange's time zone, whatever the offset of the open from UTC
+	tests := []struct {
+		scenario, symbol string
+		start, end       string
+		want             []string
+	}{
+		// The open moves from 13:30 to 14:30 UTC at the end of daylight time in New York
+		{"daily_dst", "AAPL", "2024-11-01T00:00:00Z", "2024-11-05T00:00:00Z", []string{"2024-11-01T00:00:00Z", "2024-11-04T00:00:00Z"}},
+		// Sydney opens at 23:00 UTC on the previous day
+		{"daily_sydney", "BHP.AX", "2024-03-07T00:00:00Z", "2024-03-12T00:00:00Z", []string{"2024-03-08T00:00:00Z", "2024-03-11T00:00:00Z"}},
+	}
+	for _, tt := range tests {
+		source := replayYahoo(t, tt.scenario)
+		source.Now = clockAt(t, "2024-11-15T00:00:00Z")
+		data, _, err := source.FetchBarsReport(context.Background(), tt.symbol, unixAt(t, tt.start), unixAt(t, tt.end), data_types.Interval1d)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.scenario, err)
+		}
+		if len(data) != len(tt.want) {
+			t.Fatalf("%s: got %d bars, want %d", tt.scenario, len(data), len(tt.want))
+		}
+		for i, want := range tt.want {
+			if data[i].Timestamp != unixAt(t, want) {
+				t.Errorf("%s: bar %d at %s, want %s", tt.scenario, i, time.Unix(data[i].Timestamp, 0).UTC().Format(time.RFC3339), want)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	data_types "goquant/pkg/data"
 
@@ -28,6 +29,21 @@ func NewInMemoryStorage() *InMemoryStorage {
 // It appends each element of the slice to the corresponding ticker in the store.
 // It returns an error if any.
 func (s *InMemoryStorage) Save(data []data_types.MarketData) error {
+    return s.SaveContext(context.Background(), data)
+}
+
+
+// SaveContext stores the market data in memory unless the context is done.
+//
+// Parameters:
+//   ctx (context.Context): Cancels the write. Nothing is stored if it is done before the write starts.
+//   data ([]data_types.MarketData): The market data to be stored.
+// Returns:
+//   The context's error if it is done, nil otherwise.
+func (s *InMemoryStorage) SaveContext(ctx context.Context, data []data_types.MarketData) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
     for _, d := range data {
         s.store[d.Ticker] = append(s.store[d.Ticker], d)
     }
@@ -1,8 +1,48 @@
 package data
 
-import data_types "goquant/pkg/data"
+import (
+	"slices"
+
+	data_types "goquant/pkg/data"
+
+	"github.com/go-gota/gota/dataframe"
+)
 
 func Resample(data []data_types.MarketData, interval string) []data_types.MarketData {
     // Implementation for resampling data to a different time frame
     return data
 }
+
+// FromDataFrame converts a DataFrame created by DataStorage.ToDataFrame back to a slice of MarketData.
+//
+// Parameters:
+//   df (dataframe.DataFrame): The DataFrame to be converted. Missing columns are left at their zero value.
+// Returns:
+//   []data_types.MarketData: The converted market data, one element per row.
+func FromDataFrame(df dataframe.DataFrame) []data_types.MarketData {
+    data := make([]data_types.MarketData, df.Nrow())
+    names := df.Names()
+
+    floatCol := func(name string, set func(d *data_types.MarketData, v float64)) {
+        if !slices.Contains(names, name) {
+            return
+        }
+        for i, v := range df.Col(name).Float() {
+            set(&data[i], v)
+        }
+    }
+
+    if slices.Contains(names, "Ticker") {
+        for i, v := range df.Col("Ticker").Records() {
+            data[i].Ticker = v
+        }
+    }
+    floatCol("Timestamp", func(d *data_types.MarketData, v float64) { d.Timestamp = int64(v) })
+    floatCol("Open", func(d *data_types.MarketData, v float64) { d.Open = v })
+    floatCol("High", func(d *data_types.MarketData, v float64) { d.High = v })
+    floatCol("Low", func(d *data_types.MarketData, v float64) { d.Low = v })
+    floatCol("Close", func(d *data_types.MarketData, v float64) { d.Close = v })
+    floatCol("Volume", func(d *data_types.MarketData, v float64) { d.Volume = v })
+
+    return data
+}
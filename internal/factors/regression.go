@@ -0,0 +1,193 @@
+package factors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"goquant/internal/statarb"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Exposure is the result of regressing returns on factor returns with an intercept.
+type Exposure struct {
+	// Timestamp is the last timestamp of the estimation window.
+	Timestamp int64
+	Factors   []string
+	// Alpha is the intercept, i.e. the average return not explained by the factors.
+	Alpha      float64
+	AlphaTStat float64
+	Betas      []float64
+	TStats     []float64
+	RSquared   float64
+	// AdjustedRSquared penalizes R² for the number of factors.
+	AdjustedRSquared float64
+	// ResidualVolatility is the standard deviation of the residuals per bar.
+	ResidualVolatility float64
+	Observations       int
+}
+
+// Regress regresses the target returns on the factor returns over the full common history.
+//
+// Parameters:
+// - target: The strategy or portfolio returns.
+// - factors: The factor returns, e.g. market, size and momentum.
+// Returns the Exposure, or an error if the series cannot be aligned or the regression is singular.
+func Regress(target Series, factors []Series) (Exposure, error) {
+	y, x, timestamps, err := Align(target, factors)
+	if err != nil {
+		return Exposure{}, err
+	}
+	exposure, err := fit(y, x)
+	if err != nil {
+		return Exposure{}, err
+	}
+	exposure.Timestamp = timestamps[len(timestamps)-1]
+	exposure.Factors = names(factors)
+	return exposure, nil
+}
+
+// Contribution splits the return of one bar into the parts explained by each factor, the alpha and the residual.
+type Contribution struct {
+	Timestamp int64
+	Return    float64
+	// Factors holds beta times factor return for every factor, in the order of the regression.
+	Factors  []float64
+	Alpha    float64
+	Residual float64
+}
+
+// RollingResult holds the rolling exposures and the return attribution of every bar after the first window.
+type RollingResult struct {
+	Factors       []string
+	Exposures     []Exposure
+	Contributions []Contribution
+	// Cumulative sums the contributions of every factor, the alpha and the residual over all bars.
+	Cumulative Contribution
+}
+
+// RollingRegression estimates factor exposures over a rolling window and attributes the return of every
+// bar to the factors. The exposures for a bar are estimated from the window ending at that bar, so the
+// contributions of every bar sum up to its return.
+//
+// Parameters:
+// - target: The strategy or portfolio returns.
+// - factors: The factor returns.
+// - window: The number of bars per regression, larger than the number of factors plus one.
+// Returns the RollingResult, or an error if the series cannot be aligned or are shorter than the window.
+func RollingRegression(target Series, factors []Series, window int) (RollingResult, error) {
+	y, x, timestamps, err := Align(target, factors)
+	if err != nil {
+		return RollingResult{}, err
+	}
+	if window <= len(factors)+1 {
+		return RollingResult{}, fmt.Errorf("window must be larger than %d, got %d", len(factors)+1, window)
+	}
+	if len(y) < window {
+		return RollingResult{}, fmt.Errorf("need at least %d common observations, got %d", window, len(y))
+	}
+
+	result := RollingResult{Factors: names(factors)}
+	result.Cumulative.Factors = make([]float64, len(factors))
+	for end := window; end <= len(y); end++ {
+		windowX := make([][]float64, len(x))
+		for k := range x {
+			windowX[k] = x[k][end-window : end]
+		}
+		exposure, err := fit(y[end-window:end], windowX)
+		if err != nil {
+			continue // Singular window, e.g. a constant factor
+		}
+		t := end - 1
+		exposure.Timestamp = timestamps[t]
+		exposure.Factors = result.Factors
+		result.Exposures = append(result.Exposures, exposure)
+
+		contribution := Contribution{
+			Timestamp: timestamps[t],
+			Return:    y[t],
+			Factors:   make([]float64, len(factors)),
+			Alpha:     exposure.Alpha,
+		}
+		explained := exposure.Alpha
+		for k, beta := range exposure.Betas {
+			contribution.Factors[k] = beta * x[k][t]
+			explained += contribution.Factors[k]
+			result.Cumulative.Factors[k] += contribution.Factors[k]
+		}
+		contribution.Residual = y[t] - explained
+		result.Contributions = append(result.Contributions, contribution)
+
+		result.Cumulative.Timestamp = timestamps[t]
+		result.Cumulative.Return += y[t]
+		result.Cumulative.Alpha += contribution.Alpha
+		result.Cumulative.Residual += contribution.Residual
+	}
+	if len(result.Exposures) == 0 {
+		return RollingResult{}, errors.New("every window is singular")
+	}
+	return result, nil
+}
+
+// fit regresses y on the factor columns x with an intercept.
+func fit(y []float64, x [][]float64) (Exposure, error) {
+	n, k := len(y), len(x)+1
+	design := mat.NewDense(n, k, nil)
+	for i := 0; i < n; i++ {
+		design.Set(i, 0, 1)
+		for j := range x {
+			design.Set(i, j+1, x[j][i])
+		}
+	}
+
+	coef, stdErr, residuals, err := statarb.Regress(design, y)
+	if err != nil {
+		return Exposure{}, err
+	}
+
+	mean := 0.0
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(n)
+	tss, rss := 0.0, 0.0
+	for i, v := range y {
+		tss += (v - mean) * (v - mean)
+		rss += residuals[i] * residuals[i]
+	}
+
+	exposure := Exposure{
+		Alpha:              coef[0],
+		AlphaTStat:         tStat(coef[0], stdErr[0]),
+		Betas:              coef[1:],
+		TStats:             make([]float64, len(x)),
+		ResidualVolatility: math.Sqrt(rss / float64(n-k)),
+		Observations:       n,
+	}
+	for j := range x {
+		exposure.TStats[j] = tStat(coef[j+1], stdErr[j+1])
+	}
+	if tss > 0 {
+		exposure.RSquared = 1 - rss/tss
+		exposure.AdjustedRSquared = 1 - (1-exposure.RSquared)*float64(n-1)/float64(n-k)
+	}
+	return exposure, nil
+}
+
+// tStat returns the t-statistic of a coefficient, or zero if its standard error is zero.
+func tStat(coef, stdErr float64) float64 {
+	if stdErr == 0 {
+		return 0
+	}
+	return coef / stdErr
+}
+
+// names returns the names of the series.
+func names(series []Series) []string {
+	result := make([]string, len(series))
+	for i, s := range series {
+		result[i] = s.Name
+	}
+	return result
+}
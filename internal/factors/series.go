@@ -0,0 +1,208 @@
+package factors
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goquant/internal/risk"
+	data_types "goquant/pkg/data"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// Series is a named return series with one Unix timestamp per return, oldest first.
+type Series struct {
+	Name       string
+	Timestamps []int64
+	Returns    []float64
+}
+
+// FromMarketData builds the close-to-close return series of an instrument, e.g. a market index used as a factor.
+func FromMarketData(name string, data []data_types.MarketData) (Series, error) {
+	if len(data) < 2 {
+		return Series{}, fmt.Errorf("%s: need at least 2 bars, got %d", name, len(data))
+	}
+
+	sorted := make([]data_types.MarketData, len(data))
+	copy(sorted, data)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Timestamp < sorted[b].Timestamp })
+
+	series := Series{Name: name}
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].Close == 0 {
+			return Series{}, fmt.Errorf("%s: zero close at %d", name, sorted[i-1].Timestamp)
+		}
+		series.Timestamps = append(series.Timestamps, sorted[i].Timestamp)
+		series.Returns = append(series.Returns, sorted[i].Close/sorted[i-1].Close-1)
+	}
+	return series, nil
+}
+
+// FromTradeLog builds the return series of a backtest from its trade log, which records the Timestamp,
+// the ProfitLoss of every bar and the CurrentInvest after it. The returns are those of risk.EquityReturns.
+func FromTradeLog(name string, tradeLog dataframe.DataFrame) (Series, error) {
+	returns, err := risk.EquityReturns(tradeLog)
+	if err != nil {
+		return Series{}, fmt.Errorf("%s: %w", name, err)
+	}
+	timestampCol := tradeLog.Col("Timestamp")
+	if timestampCol.Err != nil {
+		return Series{}, errors.New("trade log must have Timestamp, ProfitLoss and CurrentInvest columns")
+	}
+
+	timestamps := timestampCol.Records()
+	series := Series{Name: name, Returns: returns}
+	for _, timestamp := range timestamps[:len(returns)] {
+		ts, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return Series{}, fmt.Errorf("%s: invalid timestamp %q: %w", name, timestamp, err)
+		}
+		series.Timestamps = append(series.Timestamps, ts.Unix())
+	}
+	return series, nil
+}
+
+// dateLayouts are the date formats accepted in the first column of a factor CSV file.
+var dateLayouts = []string{"2006-01-02", "20060102", "2006-01", "200601", time.RFC3339, "2006-01-02 15:04:05"}
+
+// LoadCSV loads factor return series from a CSV file. The first row is a header, the first column
+// holds the date (e.g. 2006-01-02, 20060102, 200601 or a Unix timestamp) and every other column holds
+// the returns of one factor. Returns in percent, as in the Fama-French data library, are divided by scale.
+//
+// Parameters:
+// - path: The path of the CSV file.
+// - scale: The divisor applied to every return, e.g. 100 for percent or 1 for fractions.
+// Returns one Series per factor column, or an error if the file cannot be parsed.
+func LoadCSV(path string, scale float64) ([]Series, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadCSV(file, scale)
+}
+
+// ReadCSV reads factor return series in the format of LoadCSV.
+func ReadCSV(r io.Reader, scale float64) ([]Series, error) {
+	if scale == 0 {
+		return nil, errors.New("scale must not be zero")
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if len(header) < 2 {
+		return nil, errors.New("need a date column and at least one factor column")
+	}
+
+	series := make([]Series, len(header)-1)
+	for i, name := range header[1:] {
+		series[i].Name = strings.TrimSpace(name)
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		timestamp, err := parseDate(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		for i := range series {
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[i+1]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, series[i].Name, err)
+			}
+			series[i].Timestamps = append(series[i].Timestamps, timestamp)
+			series[i].Returns = append(series[i].Returns, value/scale)
+		}
+	}
+	return series, nil
+}
+
+// parseDate parses a date in one of the dateLayouts, or a Unix timestamp, as UTC.
+func parseDate(value string) (int64, error) {
+	for _, layout := range dateLayouts {
+		if len(value) != len(layout) && layout != time.RFC3339 {
+			continue
+		}
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return unix, nil
+	}
+	return 0, fmt.Errorf("unrecognized date %q", value)
+}
+
+// Align returns the returns of the target and the factors on the timestamps present in every series.
+//
+// Parameters:
+// - target: The strategy or portfolio returns.
+// - factors: The factor returns.
+// Returns:
+// - []float64: The target returns on the common timestamps.
+// - [][]float64: The returns of every factor on the common timestamps.
+// - []int64: The common timestamps, oldest first.
+// - error: An error if there are no factors or no common timestamps.
+func Align(target Series, factors []Series) ([]float64, [][]float64, []int64, error) {
+	if len(factors) == 0 {
+		return nil, nil, nil, errors.New("need at least one factor")
+	}
+
+	all := append([]Series{target}, factors...)
+	lookups := make([]map[int64]float64, len(all))
+	counts := make(map[int64]int)
+	for i, s := range all {
+		if len(s.Timestamps) != len(s.Returns) {
+			return nil, nil, nil, fmt.Errorf("%s: %d timestamps for %d returns", s.Name, len(s.Timestamps), len(s.Returns))
+		}
+		lookups[i] = make(map[int64]float64, len(s.Returns))
+		for j, ts := range s.Timestamps {
+			if _, seen := lookups[i][ts]; !seen {
+				counts[ts]++
+			}
+			lookups[i][ts] = s.Returns[j]
+		}
+	}
+
+	var timestamps []int64
+	for ts, count := range counts {
+		if count == len(all) {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	if len(timestamps) == 0 {
+		return nil, nil, nil, errors.New("series have no common timestamps")
+	}
+	sort.Slice(timestamps, func(a, b int) bool { return timestamps[a] < timestamps[b] })
+
+	y := make([]float64, len(timestamps))
+	x := make([][]float64, len(factors))
+	for k := range x {
+		x[k] = make([]float64, len(timestamps))
+	}
+	for t, ts := range timestamps {
+		y[t] = lookups[0][ts]
+		for k := range factors {
+			x[k][t] = lookups[k+1][ts]
+		}
+	}
+	return y, x, timestamps, nil
+}
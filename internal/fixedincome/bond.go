@@ -0,0 +1,293 @@
+package fixedincome
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Schedule returns the unadjusted period dates from start to end, generated backwards from end every
+// 12/frequency months. The first element is start and the last is end; a short first period is kept as a stub.
+func Schedule(start, end time.Time, frequency int) ([]time.Time, error) {
+	if frequency < 1 || 12%frequency != 0 {
+		return nil, fmt.Errorf("frequency must divide 12, got %d", frequency)
+	}
+	start, end = dateOf(start), dateOf(end)
+	if !end.After(start) {
+		return nil, fmt.Errorf("end %s must be after start %s", end.Format(time.DateOnly), start.Format(time.DateOnly))
+	}
+
+	months := 12 / frequency
+	dates := []time.Time{end}
+	for k := 1; ; k++ {
+		d := addMonths(end, -k*months)
+		if !d.After(start) {
+			break
+		}
+		dates = append(dates, d)
+	}
+	dates = append(dates, start)
+
+	// Reverse to ascending order
+	for i, j := 0, len(dates)-1; i < j; i, j = i+1, j-1 {
+		dates[i], dates[j] = dates[j], dates[i]
+	}
+	return dates, nil
+}
+
+// CashFlow is a payment of a bond.
+type CashFlow struct {
+	// AccrualStart and AccrualEnd are the unadjusted dates of the coupon period.
+	AccrualStart time.Time
+	AccrualEnd   time.Time
+	// PaymentDate is AccrualEnd adjusted to a business day.
+	PaymentDate time.Time
+	Coupon      float64
+	Principal   float64
+}
+
+// Amount returns the total payment.
+func (cf CashFlow) Amount() float64 {
+	return cf.Coupon + cf.Principal
+}
+
+// Bond is a fixed-rate bullet bond.
+type Bond struct {
+	Face       float64
+	CouponRate float64
+	// Frequency is the number of coupons per year.
+	Frequency  int
+	Issue      time.Time
+	Maturity   time.Time
+	DayCount   DayCount
+	Calendar   Calendar
+	Convention BusinessDayConvention
+}
+
+// NewBond creates a Bond with the US Treasury conventions: ACT/ACT ICMA accrual, the US calendar and
+// Following payment dates.
+//
+// Parameters:
+// - face: The principal repaid at maturity.
+// - couponRate: The annual coupon rate, e.g. 0.05 for 5%.
+// - frequency: The number of coupons per year, a divisor of 12.
+// - issue: The date interest starts accruing.
+// - maturity: The date the principal is repaid.
+// Returns a pointer to the newly created Bond, or an error if the terms are invalid.
+func NewBond(face, couponRate float64, frequency int, issue, maturity time.Time) (*Bond, error) {
+	bond := &Bond{
+		Face:       face,
+		CouponRate: couponRate,
+		Frequency:  frequency,
+		Issue:      dateOf(issue),
+		Maturity:   dateOf(maturity),
+		DayCount:   ActualActualICMA,
+		Calendar:   NewUSCalendar(issue.Year(), maturity.Year()+1),
+		Convention: Following,
+	}
+	if err := bond.validate(); err != nil {
+		return nil, err
+	}
+	return bond, nil
+}
+
+// validate checks the terms of the bond.
+func (b *Bond) validate() error {
+	if b.Face <= 0 {
+		return fmt.Errorf("face must be positive, got %v", b.Face)
+	}
+	if b.CouponRate < 0 || math.IsNaN(b.CouponRate) {
+		return fmt.Errorf("coupon rate must be non-negative, got %v", b.CouponRate)
+	}
+	if b.Frequency < 1 || 12%b.Frequency != 0 {
+		return fmt.Errorf("frequency must divide 12, got %d", b.Frequency)
+	}
+	if !b.Maturity.After(b.Issue) {
+		return errors.New("maturity must be after issue")
+	}
+	return nil
+}
+
+// CashFlows returns every coupon and the principal. Regular periods pay CouponRate/Frequency of the
+// face; a short first period pays the coupon accrued under the day count convention, measured against
+// the regular period ending at the first coupon date.
+func (b *Bond) CashFlows() ([]CashFlow, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	dates, err := Schedule(b.Issue, b.Maturity, b.Frequency)
+	if err != nil {
+		return nil, err
+	}
+
+	regular := b.Face * b.CouponRate / float64(b.Frequency)
+	flows := make([]CashFlow, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		coupon := regular
+		if regularStart := addMonths(dates[1], -12/b.Frequency); i == 1 && regularStart.Before(dates[0]) {
+			coupon = b.Face * b.CouponRate * b.DayCount.YearFractionInPeriod(dates[0], dates[1], regularStart, dates[1], b.Frequency)
+		}
+		flows[i-1] = CashFlow{
+			AccrualStart: dates[i-1],
+			AccrualEnd:   dates[i],
+			PaymentDate:  Adjust(dates[i], b.Calendar, b.Convention),
+			Coupon:       coupon,
+		}
+	}
+	flows[len(flows)-1].Principal = b.Face
+	return flows, nil
+}
+
+// remaining returns the cash flows whose accrual period ends after settlement.
+func (b *Bond) remaining(settlement time.Time) ([]CashFlow, error) {
+	flows, err := b.CashFlows()
+	if err != nil {
+		return nil, err
+	}
+	settlement = dateOf(settlement)
+	if settlement.Before(b.Issue) || !settlement.Before(b.Maturity) {
+		return nil, fmt.Errorf("settlement %s must be in [issue, maturity)", settlement.Format(time.DateOnly))
+	}
+	for i, cf := range flows {
+		if cf.AccrualEnd.After(settlement) {
+			return flows[i:], nil
+		}
+	}
+	return nil, errors.New("bond has no remaining cash flows")
+}
+
+// AccruedInterest returns the coupon accrued from the start of the current period to settlement.
+func (b *Bond) AccruedInterest(settlement time.Time) (float64, error) {
+	flows, err := b.remaining(settlement)
+	if err != nil {
+		return 0, err
+	}
+	return flows[0].Coupon * b.periodFraction(flows[0], flows[0].AccrualStart, settlement), nil
+}
+
+// periodFraction returns the part of the accrual period of a cash flow from start to end.
+func (b *Bond) periodFraction(cf CashFlow, start, end time.Time) float64 {
+	return b.DayCount.YearFractionInPeriod(start, end, cf.AccrualStart, cf.AccrualEnd, b.Frequency) /
+		b.DayCount.YearFractionInPeriod(cf.AccrualStart, cf.AccrualEnd, cf.AccrualStart, cf.AccrualEnd, b.Frequency)
+}
+
+// discounting returns the remaining cash flows and their times from settlement in coupon periods, using
+// the street convention: the fraction of the current period followed by whole periods.
+func (b *Bond) discounting(settlement time.Time) ([]CashFlow, []float64, error) {
+	flows, err := b.remaining(settlement)
+	if err != nil {
+		return nil, nil, err
+	}
+	fraction := b.periodFraction(flows[0], settlement, flows[0].AccrualEnd)
+
+	periods := make([]float64, len(flows))
+	for i := range flows {
+		periods[i] = fraction + float64(i)
+	}
+	return flows, periods, nil
+}
+
+// DirtyPrice returns the price including accrued interest for a yield compounded Frequency times per year.
+func (b *Bond) DirtyPrice(settlement time.Time, yield float64) (float64, error) {
+	flows, periods, err := b.discounting(settlement)
+	if err != nil {
+		return 0, err
+	}
+	base := 1 + yield/float64(b.Frequency)
+	if base <= 0 {
+		return 0, fmt.Errorf("yield %v is below -%d", yield, b.Frequency)
+	}
+
+	price := 0.0
+	for i, cf := range flows {
+		price += cf.Amount() / math.Pow(base, periods[i])
+	}
+	return price, nil
+}
+
+// CleanPrice returns the quoted price, i.e. the dirty price minus accrued interest.
+func (b *Bond) CleanPrice(settlement time.Time, yield float64) (float64, error) {
+	dirty, err := b.DirtyPrice(settlement, yield)
+	if err != nil {
+		return 0, err
+	}
+	accrued, err := b.AccruedInterest(settlement)
+	if err != nil {
+		return 0, err
+	}
+	return dirty - accrued, nil
+}
+
+// Yield returns the yield to maturity, compounded Frequency times per year, that reproduces the clean price.
+func (b *Bond) Yield(settlement time.Time, cleanPrice float64) (float64, error) {
+	if cleanPrice <= 0 || math.IsNaN(cleanPrice) {
+		return 0, fmt.Errorf("price must be positive, got %v", cleanPrice)
+	}
+	accrued, err := b.AccruedInterest(settlement)
+	if err != nil {
+		return 0, err
+	}
+	target := cleanPrice + accrued
+
+	// The price decreases in the yield, so bisect between a very low and a very high yield
+	low, high := -0.99*float64(b.Frequency), 10.0
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		price, err := b.DirtyPrice(settlement, mid)
+		if err != nil {
+			return 0, err
+		}
+		if price > target {
+			low = mid
+		} else {
+			high = mid
+		}
+		if high-low < 1e-12 {
+			break
+		}
+	}
+	return (low + high) / 2, nil
+}
+
+// Risk holds the yield sensitivities of a bond.
+type Risk struct {
+	// MacaulayDuration is the present-value weighted average time to the cash flows in years.
+	MacaulayDuration float64
+	// ModifiedDuration is the relative price change per unit change of the yield.
+	ModifiedDuration float64
+	Convexity        float64
+	// DV01 is the change of the dirty price for a one basis point decrease of the yield.
+	DV01 float64
+}
+
+// Risk returns the durations, convexity and DV01 of the bond at a yield compounded Frequency times per year.
+func (b *Bond) Risk(settlement time.Time, yield float64) (Risk, error) {
+	flows, periods, err := b.discounting(settlement)
+	if err != nil {
+		return Risk{}, err
+	}
+	f := float64(b.Frequency)
+	base := 1 + yield/f
+	if base <= 0 {
+		return Risk{}, fmt.Errorf("yield %v is below -%d", yield, b.Frequency)
+	}
+
+	price, weightedTime, weightedConvexity := 0.0, 0.0, 0.0
+	for i, cf := range flows {
+		pv := cf.Amount() / math.Pow(base, periods[i])
+		t := periods[i] / f
+		price += pv
+		weightedTime += t * pv
+		weightedConvexity += pv * t * (t + 1/f)
+	}
+
+	macaulay := weightedTime / price
+	modified := macaulay / base
+	return Risk{
+		MacaulayDuration: macaulay,
+		ModifiedDuration: modified,
+		Convexity:        weightedConvexity / (price * base * base),
+		DV01:             modified * price * 0.0001,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package fixedincome
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBondPriceTextbook(t *testing.T) {
+	// Semiannual coupon bonds priced on a coupon date, against the closed-form annuity value, e.g. the
+	// 10-year 8% bond yielding 10% of Fabozzi, Bond Markets, Analysis, and Strategies
+	tests := []struct {
+		name          string
+		coupon, yield float64
+		years         int
+		want          float64
+	}{
+		{"par", 0.05, 0.05, 10, 100},
+		{"10y 8% at 10%", 0.08, 0.10, 10, 87.5378},
+		{"10y 9% at 8%", 0.09, 0.08, 10, 106.7952},
+		{"20y 10% at 11%", 0.10, 0.11, 20, 91.9769},
+		{"zero coupon 15y at 9.4%", 0, 0.094, 15, 25.2116},
+	}
+	issue := date(2024, time.January, 15)
+	for _, tt := range tests {
+		bond, err := NewBond(100, tt.coupon, 2, issue, issue.AddDate(tt.years, 0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := bond.CleanPrice(issue, tt.yield)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("%s: got %.4f, want %.4f", tt.name, got, tt.want)
+		}
+
+		yield, err := bond.Yield(issue, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(yield-tt.yield) > 1e-9 {
+			t.Errorf("%s: yield got %.10f, want %.10f", tt.name, yield, tt.yield)
+		}
+	}
+}
+
+func TestBondAccruedInterest(t *testing.T) {
+	// 91 of the 182 days of the first ACT/ACT ISDA period have accrued
+	bond, err := NewBond(100, 0.05, 2, date(2024, time.January, 15), date(2034, time.January, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := bond.AccruedInterest(date(2024, time.April, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got-1.25) > 1e-12 {
+		t.Errorf("got %.12f, want 1.25", got)
+	}
+
+	// ACT/ACT ICMA accrues 31 of the 183 days of the period from 2023-12-15 to 2024-06-15, while ACT/ACT
+	// ISDA would split the days at the turn of the year
+	bond, err = NewBond(100, 0.05, 2, date(2023, time.June, 15), date(2033, time.June, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		dc   DayCount
+		want float64
+	}{
+		{ActualActualICMA, 0.42350},
+		{ActualActualISDA, 0.42403},
+	}
+	for _, tt := range tests {
+		bond.DayCount = tt.dc
+		got, err := bond.AccruedInterest(date(2024, time.January, 15))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 5e-6 {
+			t.Errorf("%s: got %.5f, want %.5f", tt.dc, got, tt.want)
+		}
+	}
+}
+
+func TestBondCashFlows(t *testing.T) {
+	// 2026-07-15 is a Wednesday and paid unadjusted, while Saturday 2028-01-15 rolls past Martin Luther
+	// King Jr. Day on Monday the 17th to Tuesday the 18th
+	bond, err := NewBond(1000, 0.04, 2, date(2024, time.March, 1), date(2028, time.January, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flows, err := bond.CashFlows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flows) != 8 {
+		t.Fatalf("got %d cash flows, want 8", len(flows))
+	}
+	// The short first period accrues 136 days of the regular period from 2024-01-15 to 2024-07-15
+	first := flows[0]
+	if !first.AccrualEnd.Equal(date(2024, time.July, 15)) || math.Abs(first.Coupon-20*136.0/182) > 1e-12 {
+		t.Errorf("short first period: got %+v, want a coupon of %.6f", first, 20*136.0/182)
+	}
+	accrued, err := bond.AccruedInterest(date(2024, time.May, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(accrued-20*61.0/182) > 1e-12 {
+		t.Errorf("accrued in the short first period: got %.6f, want %.6f", accrued, 20*61.0/182)
+	}
+	last := flows[len(flows)-1]
+	if last.Principal != 1000 || last.Coupon != 20 || !last.PaymentDate.Equal(date(2028, time.January, 18)) {
+		t.Errorf("last cash flow: got %+v", last)
+	}
+	if !flows[4].PaymentDate.Equal(date(2026, time.July, 15)) {
+		t.Errorf("fifth payment: got %s", flows[4].PaymentDate.Format(time.DateOnly))
+	}
+}
+
+func TestBondRisk(t *testing.T) {
+	settlement := date(2024, time.January, 15)
+	zero, err := NewBond(100, 0, 2, settlement, settlement.AddDate(10, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	risk, err := zero.Risk(settlement, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(risk.MacaulayDuration-10) > 1e-12 {
+		t.Errorf("zero coupon Macaulay duration: got %.12f, want 10", risk.MacaulayDuration)
+	}
+
+	// Durations and convexity must match finite differences of the price
+	bond, err := NewBond(100, 0.06, 2, date(2020, time.May, 15), date(2045, time.May, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const yield, h = 0.09, 1e-5
+	risk, err = bond.Risk(settlement, yield)
+	if err != nil {
+		t.Fatal(err)
+	}
+	price, _ := bond.DirtyPrice(settlement, yield)
+	up, _ := bond.DirtyPrice(settlement, yield+h)
+	down, _ := bond.DirtyPrice(settlement, yield-h)
+	modified := -(up - down) / (2 * h * price)
+	convexity := (up + down - 2*price) / (h * h * price)
+	if math.Abs(risk.ModifiedDuration-modified) > 1e-6 {
+		t.Errorf("modified duration: got %.8f, want %.8f", risk.ModifiedDuration, modified)
+	}
+	if math.Abs(risk.Convexity-convexity) > 1e-2 {
+		t.Errorf("convexity: got %.4f, want %.4f", risk.Convexity, convexity)
+	}
+	if math.Abs(risk.DV01-(down-up)/2*0.0001/h) > 1e-8 {
+		t.Errorf("DV01: got %.8f", risk.DV01)
+	}
+}
@@ -0,0 +1,172 @@
+package fixedincome
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Instrument is a quoted rate instrument that a ZeroCurve can be bootstrapped from.
+type Instrument interface {
+	// MaturityDate returns the date of the last cash flow, which becomes a node of the curve.
+	MaturityDate() time.Time
+	// parError returns the value of the instrument under the curve minus its par value.
+	parError(curve *ZeroCurve) (float64, error)
+}
+
+// Deposit is a money market deposit that pays simple interest from the valuation date to maturity.
+type Deposit struct {
+	Maturity time.Time
+	Rate     float64
+	DayCount DayCount
+}
+
+// NewDeposit creates a Deposit accruing ACT/360.
+func NewDeposit(maturity time.Time, rate float64) Deposit {
+	return Deposit{Maturity: dateOf(maturity), Rate: rate, DayCount: Actual360}
+}
+
+// MaturityDate returns the maturity of the deposit.
+func (d Deposit) MaturityDate() time.Time {
+	return d.Maturity
+}
+
+// parError returns DF(T)·(1 + r·τ) - 1.
+func (d Deposit) parError(curve *ZeroCurve) (float64, error) {
+	return curve.DiscountDate(d.Maturity)*(1+d.Rate*d.DayCount.YearFraction(curve.Valuation, d.Maturity)) - 1, nil
+}
+
+// Swap is a par interest rate swap whose fixed leg starts on the valuation date. On a single curve the
+// floating leg is worth par, so the fixed leg plus the final notional must be worth par as well.
+type Swap struct {
+	Maturity time.Time
+	Rate     float64
+	// Frequency is the number of fixed payments per year.
+	Frequency  int
+	DayCount   DayCount
+	Calendar   Calendar
+	Convention BusinessDayConvention
+}
+
+// NewSwap creates a Swap with a fixed leg paying 30/360 with ModifiedFollowing payment dates on weekdays.
+func NewSwap(maturity time.Time, rate float64, frequency int) Swap {
+	return Swap{
+		Maturity:   dateOf(maturity),
+		Rate:       rate,
+		Frequency:  frequency,
+		DayCount:   Thirty360,
+		Calendar:   WeekendCalendar{},
+		Convention: ModifiedFollowing,
+	}
+}
+
+// MaturityDate returns the last payment date of the swap, i.e. its maturity adjusted to a business day.
+func (s Swap) MaturityDate() time.Time {
+	return Adjust(s.Maturity, s.Calendar, s.Convention)
+}
+
+// parError returns S·Σ τ_i DF(t_i) + DF(T) - 1.
+func (s Swap) parError(curve *ZeroCurve) (float64, error) {
+	dates, err := Schedule(curve.Valuation, s.Maturity, s.Frequency)
+	if err != nil {
+		return 0, err
+	}
+	annuity, last := 0.0, 0.0
+	for i := 1; i < len(dates); i++ {
+		payment := Adjust(dates[i], s.Calendar, s.Convention)
+		last = curve.DiscountDate(payment)
+		annuity += s.DayCount.YearFraction(dates[i-1], dates[i]) * last
+	}
+	return s.Rate*annuity + last - 1, nil
+}
+
+// Bootstrap builds a ZeroCurve with one node at the maturity of every instrument so that every instrument
+// is priced at par. The nodes are solved in order of maturity; with cubic spline interpolation, where
+// later nodes change earlier segments, the pass is repeated until the rates converge.
+//
+// Parameters:
+// - valuation: The valuation date.
+// - instruments: The deposits and swaps, with distinct maturities after the valuation date.
+// - interpolation: The interpolation of the zero rates.
+// Returns a pointer to the bootstrapped ZeroCurve, or an error if the instruments are invalid or cannot be repriced.
+func Bootstrap(valuation time.Time, instruments []Instrument, interpolation Interpolation) (*ZeroCurve, error) {
+	if len(instruments) == 0 {
+		return nil, errors.New("need at least one instrument")
+	}
+	sorted := append([]Instrument(nil), instruments...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].MaturityDate().Before(sorted[b].MaturityDate()) })
+
+	valuation = dateOf(valuation)
+	times := make([]float64, len(sorted))
+	rates := make([]float64, len(sorted))
+	for i, instrument := range sorted {
+		times[i] = curveDayCount.YearFraction(valuation, instrument.MaturityDate())
+		if times[i] <= 0 || (i > 0 && times[i] <= times[i-1]) {
+			return nil, fmt.Errorf("instrument %d: maturities must be distinct and after the valuation date", i)
+		}
+		rates[i] = 0.02
+	}
+	curve, err := NewZeroCurve(valuation, times, rates, interpolation)
+	if err != nil {
+		return nil, err
+	}
+
+	passes := 1
+	if interpolation == CubicSplineInterpolation {
+		passes = 50
+	}
+	for pass := 0; pass < passes; pass++ {
+		change := 0.0
+		for i, instrument := range sorted {
+			previous := curve.Rates[i]
+			if err := solveNode(curve, i, instrument); err != nil {
+				return nil, fmt.Errorf("instrument %d maturing %s: %w", i, instrument.MaturityDate().Format(time.DateOnly), err)
+			}
+			change = math.Max(change, math.Abs(curve.Rates[i]-previous))
+		}
+		if change < 1e-14 {
+			break
+		}
+	}
+	return curve, nil
+}
+
+// solveNode finds the zero rate of node i that prices the instrument at par, by bisection.
+func solveNode(curve *ZeroCurve, i int, instrument Instrument) error {
+	errorAt := func(rate float64) (float64, error) {
+		curve.Rates[i] = rate
+		curve.prepare()
+		return instrument.parError(curve)
+	}
+
+	// The par error decreases in the zero rate of the maturity node
+	low, high := -0.5, 1.0
+	lowError, err := errorAt(low)
+	if err != nil {
+		return err
+	}
+	highError, err := errorAt(high)
+	if err != nil {
+		return err
+	}
+	if lowError < 0 || highError > 0 {
+		return errors.New("no zero rate in [-50%, 100%] reprices the instrument")
+	}
+
+	for iter := 0; iter < 200 && high-low > 1e-15; iter++ {
+		mid := (low + high) / 2
+		midError, err := errorAt(mid)
+		if err != nil {
+			return err
+		}
+		if midError > 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	_, err = errorAt((low + high) / 2)
+	return err
+}
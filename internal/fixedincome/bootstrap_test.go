@@ -0,0 +1,71 @@
+package fixedincome
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBootstrapRepricesInstruments(t *testing.T) {
+	valuation := date(2024, time.January, 15)
+	instruments := []Instrument{
+		NewDeposit(valuation.AddDate(0, 1, 0), 0.0530),
+		NewDeposit(valuation.AddDate(0, 3, 0), 0.0535),
+		NewDeposit(valuation.AddDate(0, 6, 0), 0.0520),
+		NewSwap(valuation.AddDate(1, 0, 0), 0.0490, 2),
+		NewSwap(valuation.AddDate(2, 0, 0), 0.0440, 2),
+		NewSwap(valuation.AddDate(5, 0, 0), 0.0395, 2),
+		NewSwap(valuation.AddDate(10, 0, 0), 0.0385, 2),
+		NewSwap(valuation.AddDate(30, 0, 0), 0.0370, 2),
+	}
+
+	for _, interpolation := range []Interpolation{LinearInterpolation, CubicSplineInterpolation} {
+		curve, err := Bootstrap(valuation, instruments, interpolation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, instrument := range instruments {
+			parError, err := instrument.parError(curve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(parError) > 1e-10 {
+				t.Errorf("interpolation %d, instrument %d: par error %.3g", interpolation, i, parError)
+			}
+		}
+	}
+}
+
+func TestBootstrapFlatCurve(t *testing.T) {
+	// A deposit quoted at the simple rate equivalent to a continuous rate of 4% gives a 4% node
+	valuation := date(2024, time.January, 15)
+	maturity := valuation.AddDate(0, 6, 0)
+	tau := Actual360.YearFraction(valuation, maturity)
+	rate := (math.Exp(0.04*Actual365Fixed.YearFraction(valuation, maturity)) - 1) / tau
+
+	curve, err := Bootstrap(valuation, []Instrument{NewDeposit(maturity, rate)}, LinearInterpolation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(curve.Rates[0]-0.04) > 1e-12 {
+		t.Errorf("got %.12f, want 0.04", curve.Rates[0])
+	}
+	forward, err := curve.ForwardRate(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(forward-0.04) > 1e-12 {
+		t.Errorf("forward rate: got %.12f, want 0.04", forward)
+	}
+}
+
+func TestBootstrapRejectsDuplicateMaturities(t *testing.T) {
+	valuation := date(2024, time.January, 15)
+	instruments := []Instrument{
+		NewDeposit(valuation.AddDate(1, 0, 0), 0.05),
+		NewSwap(valuation.AddDate(1, 0, 0), 0.05, 2),
+	}
+	if _, err := Bootstrap(valuation, instruments, LinearInterpolation); err == nil {
+		t.Error("expected an error for duplicate maturities")
+	}
+}
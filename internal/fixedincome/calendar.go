@@ -0,0 +1,187 @@
+package fixedincome
+
+import (
+	"fmt"
+	"time"
+)
+
+// Calendar decides which dates are business days.
+type Calendar interface {
+	IsBusinessDay(t time.Time) bool
+}
+
+// WeekendCalendar treats every weekday as a business day.
+type WeekendCalendar struct{}
+
+// IsBusinessDay reports whether t falls on a weekday.
+func (WeekendCalendar) IsBusinessDay(t time.Time) bool {
+	day := t.Weekday()
+	return day != time.Saturday && day != time.Sunday
+}
+
+// HolidayCalendar treats weekdays that are not holidays as business days.
+type HolidayCalendar struct {
+	holidays map[time.Time]bool
+}
+
+// NewHolidayCalendar creates a HolidayCalendar from a list of holidays. Only the dates are used.
+func NewHolidayCalendar(holidays []time.Time) *HolidayCalendar {
+	calendar := &HolidayCalendar{holidays: make(map[time.Time]bool, len(holidays))}
+	for _, h := range holidays {
+		calendar.holidays[dateOf(h)] = true
+	}
+	return calendar
+}
+
+// NewUSCalendar creates a HolidayCalendar with the US federal holidays observed by the bond market
+// from fromYear to toYear inclusive. Holidays on a Saturday are observed on the Friday before and
+// holidays on a Sunday on the Monday after. Juneteenth is observed from 2022.
+func NewUSCalendar(fromYear, toYear int) *HolidayCalendar {
+	var holidays []time.Time
+	for year := fromYear; year <= toYear; year++ {
+		fixed := []time.Time{
+			date(year, time.January, 1),
+			date(year, time.July, 4),
+			date(year, time.November, 11),
+			date(year, time.December, 25),
+		}
+		if year >= 2022 {
+			fixed = append(fixed, date(year, time.June, 19))
+		}
+		for _, h := range fixed {
+			holidays = append(holidays, observed(h))
+		}
+		holidays = append(holidays,
+			nthWeekday(year, time.January, time.Monday, 3),    // Martin Luther King Jr. Day
+			nthWeekday(year, time.February, time.Monday, 3),   // Washington's Birthday
+			lastWeekday(year, time.May, time.Monday),          // Memorial Day
+			nthWeekday(year, time.September, time.Monday, 1),  // Labor Day
+			nthWeekday(year, time.October, time.Monday, 2),    // Columbus Day
+			nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving
+		)
+	}
+	return NewHolidayCalendar(holidays)
+}
+
+// IsBusinessDay reports whether t falls on a weekday that is not a holiday.
+func (c *HolidayCalendar) IsBusinessDay(t time.Time) bool {
+	return WeekendCalendar{}.IsBusinessDay(t) && !c.holidays[dateOf(t)]
+}
+
+// date returns midnight UTC of the date.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// observed moves a holiday on a weekend to the nearest weekday.
+func observed(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	default:
+		return t
+	}
+}
+
+// nthWeekday returns the n-th given weekday of the month.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := date(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last given weekday of the month.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := date(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// BusinessDayConvention determines how a date that is not a business day is adjusted.
+type BusinessDayConvention int
+
+const (
+	// Unadjusted keeps the date.
+	Unadjusted BusinessDayConvention = iota
+	// Following moves to the next business day.
+	Following
+	// ModifiedFollowing moves to the next business day unless that is in the next month, in which case it moves to the previous one.
+	ModifiedFollowing
+	// Preceding moves to the previous business day.
+	Preceding
+)
+
+// String returns the name of the convention.
+func (c BusinessDayConvention) String() string {
+	switch c {
+	case Unadjusted:
+		return "Unadjusted"
+	case Following:
+		return "Following"
+	case ModifiedFollowing:
+		return "ModifiedFollowing"
+	case Preceding:
+		return "Preceding"
+	default:
+		return fmt.Sprintf("BusinessDayConvention(%d)", int(c))
+	}
+}
+
+// Adjust moves t to a business day of the calendar according to the convention. A nil calendar
+// treats every weekday as a business day.
+func Adjust(t time.Time, calendar Calendar, convention BusinessDayConvention) time.Time {
+	if calendar == nil {
+		calendar = WeekendCalendar{}
+	}
+	t = dateOf(t)
+	if convention == Unadjusted || calendar.IsBusinessDay(t) {
+		return t
+	}
+
+	switch convention {
+	case Preceding:
+		return roll(t, calendar, -1)
+	case ModifiedFollowing:
+		if next := roll(t, calendar, 1); next.Month() == t.Month() {
+			return next
+		}
+		return roll(t, calendar, -1)
+	default:
+		return roll(t, calendar, 1)
+	}
+}
+
+// AddBusinessDays moves t by n business days of the calendar, backwards if n is negative.
+func AddBusinessDays(t time.Time, n int, calendar Calendar) time.Time {
+	if calendar == nil {
+		calendar = WeekendCalendar{}
+	}
+	t = dateOf(t)
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for ; n > 0; n-- {
+		t = roll(t, calendar, step)
+	}
+	return t
+}
+
+// roll returns the first business day strictly after (step 1) or before (step -1) t.
+func roll(t time.Time, calendar Calendar, step int) time.Time {
+	for {
+		t = t.AddDate(0, 0, step)
+		if calendar.IsBusinessDay(t) {
+			return t
+		}
+	}
+}
+
+// addMonths adds months to a date, clamping the day to the end of the target month, e.g. Jan 31 + 1 month is Feb 28.
+func addMonths(t time.Time, months int) time.Time {
+	first := date(t.Year(), t.Month(), 1).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return date(first.Year(), first.Month(), min(t.Day(), lastDay))
+}
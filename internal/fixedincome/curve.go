@@ -0,0 +1,143 @@
+package fixedincome
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Interpolation selects how a ZeroCurve interpolates zero rates between its nodes.
+type Interpolation int
+
+const (
+	// LinearInterpolation interpolates the zero rates linearly.
+	LinearInterpolation Interpolation = iota
+	// CubicSplineInterpolation interpolates the zero rates with a natural cubic spline.
+	CubicSplineInterpolation
+)
+
+// curveDayCount measures the time of curve nodes from the valuation date.
+const curveDayCount = Actual365Fixed
+
+// ZeroCurve is a curve of continuously compounded zero rates. Times are ACT/365F years from the
+// valuation date; rates are flat before the first and after the last node.
+type ZeroCurve struct {
+	Valuation     time.Time
+	Times         []float64
+	Rates         []float64
+	Interpolation Interpolation
+	// second holds the second derivatives of the cubic spline at the nodes.
+	second []float64
+}
+
+// NewZeroCurve creates a ZeroCurve from nodes.
+//
+// Parameters:
+// - valuation: The valuation date.
+// - times: The node times in years, strictly increasing and positive.
+// - rates: The continuously compounded zero rate at every node.
+// - interpolation: The interpolation between nodes.
+// Returns a pointer to the newly created ZeroCurve, or an error if the nodes are invalid.
+func NewZeroCurve(valuation time.Time, times, rates []float64, interpolation Interpolation) (*ZeroCurve, error) {
+	if len(times) == 0 || len(times) != len(rates) {
+		return nil, fmt.Errorf("need the same positive number of times and rates, got %d and %d", len(times), len(rates))
+	}
+	for i, t := range times {
+		if t <= 0 || (i > 0 && t <= times[i-1]) {
+			return nil, errors.New("times must be positive and strictly increasing")
+		}
+	}
+	if interpolation != LinearInterpolation && interpolation != CubicSplineInterpolation {
+		return nil, fmt.Errorf("unknown interpolation: %d", interpolation)
+	}
+
+	curve := &ZeroCurve{
+		Valuation:     dateOf(valuation),
+		Times:         append([]float64(nil), times...),
+		Rates:         append([]float64(nil), rates...),
+		Interpolation: interpolation,
+	}
+	curve.prepare()
+	return curve, nil
+}
+
+// prepare computes the spline coefficients after the nodes change.
+func (c *ZeroCurve) prepare() {
+	if c.Interpolation == CubicSplineInterpolation {
+		c.second = naturalSpline(c.Times, c.Rates)
+	}
+}
+
+// TimeOf returns the ACT/365F time of a date from the valuation date.
+func (c *ZeroCurve) TimeOf(t time.Time) float64 {
+	return curveDayCount.YearFraction(c.Valuation, t)
+}
+
+// ZeroRate returns the continuously compounded zero rate at time t in years.
+func (c *ZeroCurve) ZeroRate(t float64) float64 {
+	n := len(c.Times)
+	if t <= c.Times[0] {
+		return c.Rates[0]
+	}
+	if t >= c.Times[n-1] {
+		return c.Rates[n-1]
+	}
+
+	i := sort.SearchFloat64s(c.Times, t)
+	t0, t1 := c.Times[i-1], c.Times[i]
+	r0, r1 := c.Rates[i-1], c.Rates[i]
+	h := t1 - t0
+	a := (t1 - t) / h
+	b := (t - t0) / h
+	if c.Interpolation == CubicSplineInterpolation {
+		return a*r0 + b*r1 + ((a*a*a-a)*c.second[i-1]+(b*b*b-b)*c.second[i])*h*h/6
+	}
+	return a*r0 + b*r1
+}
+
+// Discount returns the discount factor at time t in years.
+func (c *ZeroCurve) Discount(t float64) float64 {
+	if t <= 0 {
+		return 1
+	}
+	return math.Exp(-c.ZeroRate(t) * t)
+}
+
+// DiscountDate returns the discount factor at a date.
+func (c *ZeroCurve) DiscountDate(t time.Time) float64 {
+	return c.Discount(c.TimeOf(t))
+}
+
+// ForwardRate returns the continuously compounded forward rate between times t1 and t2 in years.
+func (c *ZeroCurve) ForwardRate(t1, t2 float64) (float64, error) {
+	if t2 <= t1 {
+		return 0, fmt.Errorf("t2 %v must be after t1 %v", t2, t1)
+	}
+	return math.Log(c.Discount(t1)/c.Discount(t2)) / (t2 - t1), nil
+}
+
+// naturalSpline returns the second derivatives of the natural cubic spline through the points.
+func naturalSpline(x, y []float64) []float64 {
+	n := len(x)
+	second := make([]float64, n)
+	if n < 3 {
+		return second
+	}
+
+	// Tridiagonal system for the interior second derivatives, solved with the Thomas algorithm
+	u := make([]float64, n)
+	for i := 1; i < n-1; i++ {
+		sig := (x[i] - x[i-1]) / (x[i+1] - x[i-1])
+		p := sig*second[i-1] + 2
+		second[i] = (sig - 1) / p
+		u[i] = (y[i+1]-y[i])/(x[i+1]-x[i]) - (y[i]-y[i-1])/(x[i]-x[i-1])
+		u[i] = (6*u[i]/(x[i+1]-x[i-1]) - sig*u[i-1]) / p
+	}
+	second[n-1] = 0
+	for i := n - 2; i >= 0; i-- {
+		second[i] = second[i]*second[i+1] + u[i]
+	}
+	return second
+}
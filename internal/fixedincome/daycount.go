@@ -0,0 +1,122 @@
+package fixedincome
+
+import (
+	"fmt"
+	"time"
+)
+
+// DayCount is a day count convention that measures the year fraction between two dates.
+type DayCount int
+
+const (
+	// Actual360 divides the actual number of days by 360, as for money market deposits.
+	Actual360 DayCount = iota
+	// Actual365Fixed divides the actual number of days by 365.
+	Actual365Fixed
+	// ActualActualISDA splits the period by calendar year and divides each part by the days in its year.
+	ActualActualISDA
+	// Thirty360 is the US 30/360 bond basis.
+	Thirty360
+	// ThirtyE360 is the Eurobond 30E/360 basis.
+	ThirtyE360
+	// ActualActualICMA divides the actual days by the actual days of the coupon period times the number of
+	// periods per year, as for US Treasuries and most government bonds. See YearFractionInPeriod.
+	ActualActualICMA
+)
+
+// String returns the name of the day count convention.
+func (dc DayCount) String() string {
+	switch dc {
+	case Actual360:
+		return "ACT/360"
+	case Actual365Fixed:
+		return "ACT/365F"
+	case ActualActualISDA:
+		return "ACT/ACT ISDA"
+	case Thirty360:
+		return "30/360"
+	case ThirtyE360:
+		return "30E/360"
+	case ActualActualICMA:
+		return "ACT/ACT ICMA"
+	default:
+		return fmt.Sprintf("DayCount(%d)", int(dc))
+	}
+}
+
+// YearFraction returns the year fraction from start to end, negative if end is before start. ACT/ACT ICMA
+// needs a coupon period, so it counts whole years back from end and divides the rest by the days of the
+// year before, as for an annual coupon ending at end.
+func (dc DayCount) YearFraction(start, end time.Time) float64 {
+	start, end = dateOf(start), dateOf(end)
+	if end.Before(start) {
+		return -dc.YearFraction(end, start)
+	}
+
+	switch dc {
+	case Actual360:
+		return float64(daysBetween(start, end)) / 360
+	case ActualActualISDA:
+		if start.Year() == end.Year() {
+			return float64(daysBetween(start, end)) / daysInYear(start.Year())
+		}
+		nextYear := time.Date(start.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		lastYear := time.Date(end.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return float64(daysBetween(start, nextYear))/daysInYear(start.Year()) +
+			float64(end.Year()-start.Year()-1) +
+			float64(daysBetween(lastYear, end))/daysInYear(end.Year())
+	case ActualActualICMA:
+		years := 0
+		for !addMonths(end, -12*(years+1)).Before(start) {
+			years++
+		}
+		periodEnd := addMonths(end, -12*years)
+		return float64(years) + dc.YearFractionInPeriod(start, periodEnd, addMonths(periodEnd, -12), periodEnd, 1)
+	case Thirty360, ThirtyE360:
+		d1, d2 := start.Day(), end.Day()
+		if dc == Thirty360 {
+			if d1 == 31 {
+				d1 = 30
+			}
+			if d2 == 31 && d1 == 30 {
+				d2 = 30
+			}
+		} else {
+			d1, d2 = min(d1, 30), min(d2, 30)
+		}
+		days := 360*(end.Year()-start.Year()) + 30*(int(end.Month())-int(start.Month())) + d2 - d1
+		return float64(days) / 360
+	default:
+		return float64(daysBetween(start, end)) / 365
+	}
+}
+
+// YearFractionInPeriod returns the year fraction from start to end within the coupon period from
+// periodStart to periodEnd of a schedule with frequency periods per year. ACT/ACT ICMA divides the actual
+// days by frequency times the actual days of the period, so that every regular period is 1/frequency of
+// a year; a stub is measured against the regular period it is cut from. The other conventions ignore the
+// period and return YearFraction.
+func (dc DayCount) YearFractionInPeriod(start, end, periodStart, periodEnd time.Time, frequency int) float64 {
+	if dc != ActualActualICMA {
+		return dc.YearFraction(start, end)
+	}
+	return float64(daysBetween(start, end)) / (float64(frequency) * float64(daysBetween(periodStart, periodEnd)))
+}
+
+// dateOf truncates a time to midnight UTC of its calendar date.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of calendar days from start to end.
+func daysBetween(start, end time.Time) int {
+	return int(dateOf(end).Sub(dateOf(start)).Hours() / 24)
+}
+
+// daysInYear returns 366 for leap years and 365 otherwise.
+func daysInYear(year int) float64 {
+	if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 366
+	}
+	return 365
+}
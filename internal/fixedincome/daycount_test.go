@@ -0,0 +1,83 @@
+package fixedincome
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestYearFraction(t *testing.T) {
+	tests := []struct {
+		dc         DayCount
+		start, end time.Time
+		want       float64
+	}{
+		{Actual360, date(2024, time.January, 1), date(2024, time.July, 1), 182.0 / 360},
+		{Actual365Fixed, date(2024, time.January, 1), date(2025, time.January, 1), 366.0 / 365},
+		{ActualActualISDA, date(2024, time.January, 1), date(2025, time.January, 1), 1},
+		{ActualActualISDA, date(2023, time.July, 1), date(2024, time.July, 1), 184.0/365 + 182.0/366},
+		{Thirty360, date(2024, time.January, 31), date(2024, time.March, 31), 60.0 / 360},
+		{Thirty360, date(2024, time.February, 28), date(2024, time.March, 31), 33.0 / 360},
+		{ThirtyE360, date(2024, time.February, 28), date(2024, time.March, 31), 32.0 / 360},
+		// Whole years back from the end, and the rest against the 366 days of 2023-07-15 to 2024-07-15
+		{ActualActualICMA, date(2022, time.July, 1), date(2024, time.July, 1), 2},
+		{ActualActualICMA, date(2024, time.January, 15), date(2024, time.July, 15), 182.0 / 366},
+		{Actual360, date(2024, time.July, 1), date(2024, time.January, 1), -182.0 / 360},
+	}
+	for _, tt := range tests {
+		got := tt.dc.YearFraction(tt.start, tt.end)
+		if math.Abs(got-tt.want) > 1e-15 {
+			t.Errorf("%s %s to %s: got %.15f, want %.15f", tt.dc, tt.start.Format(time.DateOnly), tt.end.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestYearFractionInPeriod(t *testing.T) {
+	// A month of the semiannual period from 2023-12-15 to 2024-06-15, which has 183 days
+	start, end := date(2023, time.December, 15), date(2024, time.June, 15)
+	tests := []struct {
+		dc   DayCount
+		want float64
+	}{
+		{ActualActualICMA, 31.0 / (2 * 183)},
+		{ActualActualISDA, 17.0/365 + 14.0/366},
+	}
+	for _, tt := range tests {
+		got := tt.dc.YearFractionInPeriod(start, date(2024, time.January, 15), start, end, 2)
+		if math.Abs(got-tt.want) > 1e-15 {
+			t.Errorf("%s: got %.15f, want %.15f", tt.dc, got, tt.want)
+		}
+	}
+	// Every regular ICMA period is a fraction 1/frequency of a year, whatever its length
+	if got := ActualActualICMA.YearFractionInPeriod(start, end, start, end, 2); got != 0.5 {
+		t.Errorf("whole period: got %v, want 0.5", got)
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	us := NewUSCalendar(2024, 2026)
+	tests := []struct {
+		name       string
+		in         time.Time
+		calendar   Calendar
+		convention BusinessDayConvention
+		want       time.Time
+	}{
+		{"business day", date(2024, time.July, 3), us, Following, date(2024, time.July, 3)},
+		{"independence day", date(2024, time.July, 4), us, Following, date(2024, time.July, 5)},
+		{"observed on friday", date(2026, time.July, 3), us, Preceding, date(2026, time.July, 2)},
+		{"labor day weekend", date(2024, time.August, 31), us, Following, date(2024, time.September, 3)},
+		{"modified following stays in month", date(2024, time.August, 31), us, ModifiedFollowing, date(2024, time.August, 30)},
+		{"weekend calendar", date(2024, time.September, 1), WeekendCalendar{}, ModifiedFollowing, date(2024, time.September, 2)},
+		{"unadjusted", date(2024, time.August, 31), us, Unadjusted, date(2024, time.August, 31)},
+	}
+	for _, tt := range tests {
+		if got := Adjust(tt.in, tt.calendar, tt.convention); !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+
+	if got := AddBusinessDays(date(2024, time.July, 3), 2, us); !got.Equal(date(2024, time.July, 8)) {
+		t.Errorf("AddBusinessDays: got %s, want 2024-07-08", got.Format(time.DateOnly))
+	}
+}
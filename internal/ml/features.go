@@ -0,0 +1,258 @@
+package ml
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// Feature computes one value per bar of a market data series. The value at index i must only depend
+// on bars up to and including i; bars without enough history hold NaN.
+type Feature interface {
+	Name() string
+	Compute(data []data_types.MarketData) []float64
+}
+
+// featureFunc adapts a function to the Feature interface.
+type featureFunc struct {
+	name    string
+	compute func(data []data_types.MarketData) []float64
+}
+
+func (f featureFunc) Name() string { return f.name }
+
+func (f featureFunc) Compute(data []data_types.MarketData) []float64 { return f.compute(data) }
+
+// NewFeature creates a Feature from a name and a compute function.
+func NewFeature(name string, compute func(data []data_types.MarketData) []float64) Feature {
+	return featureFunc{name: name, compute: compute}
+}
+
+// Pipeline turns market data into feature rows.
+type Pipeline struct {
+	Features []Feature
+}
+
+// NewPipeline creates a new Pipeline from the given features.
+func NewPipeline(features ...Feature) *Pipeline {
+	return &Pipeline{Features: features}
+}
+
+// Names returns the names of the features in column order.
+func (p *Pipeline) Names() []string {
+	names := make([]string, len(p.Features))
+	for i, f := range p.Features {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+// Transform returns one feature row per bar. Rows of bars without enough history contain NaN.
+func (p *Pipeline) Transform(data []data_types.MarketData) [][]float64 {
+	columns := make([][]float64, len(p.Features))
+	for j, f := range p.Features {
+		columns[j] = f.Compute(data)
+	}
+
+	rows := make([][]float64, len(data))
+	for i := range rows {
+		rows[i] = make([]float64, len(p.Features))
+		for j := range p.Features {
+			rows[i][j] = columns[j][i]
+		}
+	}
+	return rows
+}
+
+// Complete reports whether a feature row has no missing values.
+func Complete(row []float64) bool {
+	for _, v := range row {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// NextBarLabels returns 1 for every bar whose next close is higher and 0 otherwise.
+// The last bar has no next close and holds NaN.
+func NextBarLabels(data []data_types.MarketData) []float64 {
+	labels := make([]float64, len(data))
+	for i := range data {
+		switch {
+		case i == len(data)-1:
+			labels[i] = math.NaN()
+		case data[i+1].Close > data[i].Close:
+			labels[i] = 1
+		}
+	}
+	return labels
+}
+
+// LaggedReturn is the simple close-to-close return ending lag bars ago; lag 0 is the latest return.
+func LaggedReturn(lag int) Feature {
+	return NewFeature("return_lag_"+strconv.Itoa(lag), func(data []data_types.MarketData) []float64 {
+		values := nanSlice(len(data))
+		for i := lag + 1; i < len(data); i++ {
+			if prev := data[i-lag-1].Close; prev != 0 {
+				values[i] = data[i-lag].Close/prev - 1
+			}
+		}
+		return values
+	})
+}
+
+// RollingMeanReturn is the mean of the simple returns over the trailing window.
+func RollingMeanReturn(window int) Feature {
+	return NewFeature("mean_return_"+strconv.Itoa(window), func(data []data_types.MarketData) []float64 {
+		rets := simpleReturns(data)
+		values := nanSlice(len(data))
+		for i := window; i < len(data); i++ {
+			values[i] = mean(rets[i-window+1 : i+1])
+		}
+		return values
+	})
+}
+
+// RollingVolatility is the sample standard deviation of the simple returns over the trailing window.
+func RollingVolatility(window int) Feature {
+	return NewFeature("volatility_"+strconv.Itoa(window), func(data []data_types.MarketData) []float64 {
+		rets := simpleReturns(data)
+		values := nanSlice(len(data))
+		for i := window; i < len(data) && window > 1; i++ {
+			values[i] = stdDev(rets[i-window+1 : i+1])
+		}
+		return values
+	})
+}
+
+// MovingAverageDistance is the relative distance of the close from its simple moving average.
+func MovingAverageDistance(window int) Feature {
+	return NewFeature("ma_distance_"+strconv.Itoa(window), func(data []data_types.MarketData) []float64 {
+		values := nanSlice(len(data))
+		for i := window - 1; i < len(data) && window > 0; i++ {
+			avg := 0.0
+			for _, d := range data[i-window+1 : i+1] {
+				avg += d.Close
+			}
+			avg /= float64(window)
+			if avg != 0 {
+				values[i] = data[i].Close/avg - 1
+			}
+		}
+		return values
+	})
+}
+
+// RSI is the Relative Strength Index with Wilder smoothing over the given period, scaled to [0, 100].
+func RSI(period int) Feature {
+	return NewFeature("rsi_"+strconv.Itoa(period), func(data []data_types.MarketData) []float64 {
+		values := nanSlice(len(data))
+		if period < 1 || len(data) <= period {
+			return values
+		}
+
+		avgGain, avgLoss := 0.0, 0.0
+		for i := 1; i < len(data); i++ {
+			change := data[i].Close - data[i-1].Close
+			gain, loss := math.Max(change, 0), math.Max(-change, 0)
+			if i <= period {
+				avgGain += gain / float64(period)
+				avgLoss += loss / float64(period)
+				if i < period {
+					continue
+				}
+			} else {
+				avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+				avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+			}
+
+			if avgLoss == 0 {
+				values[i] = 100
+			} else {
+				values[i] = 100 - 100/(1+avgGain/avgLoss)
+			}
+		}
+		return values
+	})
+}
+
+// VolumeChange is the relative change of the volume from the previous bar.
+func VolumeChange() Feature {
+	return NewFeature("volume_change", func(data []data_types.MarketData) []float64 {
+		values := nanSlice(len(data))
+		for i := 1; i < len(data); i++ {
+			if prev := data[i-1].Volume; prev != 0 {
+				values[i] = data[i].Volume/prev - 1
+			}
+		}
+		return values
+	})
+}
+
+// DayOfWeek is the UTC weekday of the bar, 0 for Sunday to 6 for Saturday.
+func DayOfWeek() Feature {
+	return calendarFeature("day_of_week", func(t time.Time) float64 { return float64(t.Weekday()) })
+}
+
+// HourOfDay is the UTC hour of the bar.
+func HourOfDay() Feature {
+	return calendarFeature("hour_of_day", func(t time.Time) float64 { return float64(t.Hour()) })
+}
+
+// MonthOfYear is the UTC month of the bar, 1 to 12.
+func MonthOfYear() Feature {
+	return calendarFeature("month_of_year", func(t time.Time) float64 { return float64(t.Month()) })
+}
+
+// calendarFeature creates a feature from a function of the bar's UTC timestamp.
+func calendarFeature(name string, value func(t time.Time) float64) Feature {
+	return NewFeature(name, func(data []data_types.MarketData) []float64 {
+		values := make([]float64, len(data))
+		for i, d := range data {
+			values[i] = value(time.Unix(d.Timestamp, 0).UTC())
+		}
+		return values
+	})
+}
+
+// simpleReturns returns the close-to-close returns aligned with the bars; the first bar holds NaN.
+func simpleReturns(data []data_types.MarketData) []float64 {
+	rets := nanSlice(len(data))
+	for i := 1; i < len(data); i++ {
+		if prev := data[i-1].Close; prev != 0 {
+			rets[i] = data[i].Close/prev - 1
+		}
+	}
+	return rets
+}
+
+// nanSlice returns a slice of n NaN values.
+func nanSlice(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// mean calculates the arithmetic mean of a slice of float64 values.
+func mean(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// stdDev calculates the sample standard deviation of a slice of float64 values.
+func stdDev(values []float64) float64 {
+	m := mean(values)
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - m) * (v - m)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
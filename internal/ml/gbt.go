@@ -0,0 +1,202 @@
+package ml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TreeNode is a node of a binary regression tree. Inner nodes send x to Left if
+// x[Feature] <= Threshold and to Right otherwise; leaves return Value.
+type TreeNode struct {
+	Leaf      bool      `json:"leaf,omitempty"`
+	Value     float64   `json:"value,omitempty"`
+	Feature   int       `json:"feature,omitempty"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Left      *TreeNode `json:"left,omitempty"`
+	Right     *TreeNode `json:"right,omitempty"`
+}
+
+// Evaluate returns the value of the leaf that x falls into.
+func (n *TreeNode) Evaluate(x []float64) float64 {
+	node := n
+	for !node.Leaf {
+		if x[node.Feature] <= node.Threshold {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+	return node.Value
+}
+
+// GradientBoostedTrees is a gradient boosting classifier with logistic loss and regression trees as base learners.
+type GradientBoostedTrees struct {
+	NumTrees       int
+	MaxDepth       int
+	LearningRate   float64
+	MinSamplesLeaf int
+
+	InitialScore float64
+	Trees        []*TreeNode
+	dims         int
+}
+
+// NewGradientBoostedTrees creates a new GradientBoostedTrees classifier.
+//
+// Parameters:
+// - numTrees: The number of boosting rounds, positive.
+// - maxDepth: The maximum depth of every tree, positive.
+// - learningRate: The shrinkage applied to every tree, in (0, 1].
+// - minSamplesLeaf: The minimum number of training rows in a leaf, positive.
+// Returns a pointer to the newly created GradientBoostedTrees, or an error if the parameters are invalid.
+func NewGradientBoostedTrees(numTrees, maxDepth int, learningRate float64, minSamplesLeaf int) (*GradientBoostedTrees, error) {
+	if numTrees < 1 || maxDepth < 1 || minSamplesLeaf < 1 || learningRate <= 0 || learningRate > 1 {
+		return nil, fmt.Errorf("invalid parameters: trees %d, depth %d, learning rate %v, min samples leaf %d", numTrees, maxDepth, learningRate, minSamplesLeaf)
+	}
+	return &GradientBoostedTrees{
+		NumTrees:       numTrees,
+		MaxDepth:       maxDepth,
+		LearningRate:   learningRate,
+		MinSamplesLeaf: minSamplesLeaf,
+	}, nil
+}
+
+// Fit trains the ensemble on the rows of X and the binary labels y.
+func (g *GradientBoostedTrees) Fit(X [][]float64, y []float64) error {
+	if err := validateTrainingSet(X, y); err != nil {
+		return err
+	}
+
+	// Start from the log-odds of the base rate, clipped to keep it finite
+	mean := 0.0
+	for _, label := range y {
+		mean += label
+	}
+	mean = math.Min(math.Max(mean/float64(len(y)), 1e-6), 1-1e-6)
+	g.InitialScore = math.Log(mean / (1 - mean))
+	g.Trees = nil
+	g.dims = len(X[0])
+
+	scores := make([]float64, len(X))
+	for i := range scores {
+		scores[i] = g.InitialScore
+	}
+
+	rows := make([]int, len(X))
+	for i := range rows {
+		rows[i] = i
+	}
+	residuals := make([]float64, len(X))
+	hessians := make([]float64, len(X))
+	for round := 0; round < g.NumTrees; round++ {
+		for i := range X {
+			p := sigmoid(scores[i])
+			residuals[i] = y[i] - p
+			hessians[i] = p * (1 - p)
+		}
+
+		tree := g.buildTree(X, residuals, hessians, rows, 0)
+		g.Trees = append(g.Trees, tree)
+		for i, row := range X {
+			scores[i] += g.LearningRate * tree.Evaluate(row)
+		}
+	}
+	return nil
+}
+
+// Predict returns the probability of label 1 for the features x.
+func (g *GradientBoostedTrees) Predict(x []float64) (float64, error) {
+	if err := validateInput(x, g.dims); err != nil {
+		return 0, err
+	}
+
+	score := g.InitialScore
+	for _, tree := range g.Trees {
+		score += g.LearningRate * tree.Evaluate(x)
+	}
+	return sigmoid(score), nil
+}
+
+// buildTree grows a regression tree on the residuals of the given rows. Splits minimize the squared
+// error of the residuals and leaves hold the Newton step sum(residuals) / sum(hessians).
+func (g *GradientBoostedTrees) buildTree(X [][]float64, residuals, hessians []float64, rows []int, depth int) *TreeNode {
+	if depth >= g.MaxDepth || len(rows) < 2*g.MinSamplesLeaf {
+		return g.leaf(residuals, hessians, rows)
+	}
+
+	total := 0.0
+	for _, i := range rows {
+		total += residuals[i]
+	}
+
+	bestGain := 0.0
+	bestFeature, bestSplit := -1, 0
+	var bestThreshold float64
+	sorted := make([]int, len(rows))
+	for feature := 0; feature < len(X[0]); feature++ {
+		copy(sorted, rows)
+		sort.SliceStable(sorted, func(a, b int) bool { return X[sorted[a]][feature] < X[sorted[b]][feature] })
+
+		left := 0.0
+		for k := 0; k < len(sorted)-1; k++ {
+			left += residuals[sorted[k]]
+			nLeft := k + 1
+			nRight := len(sorted) - nLeft
+			if nLeft < g.MinSamplesLeaf || nRight < g.MinSamplesLeaf {
+				continue
+			}
+			current, next := X[sorted[k]][feature], X[sorted[k+1]][feature]
+			if current == next {
+				continue
+			}
+
+			// Reduction of the squared error relative to a single leaf
+			right := total - left
+			gain := left*left/float64(nLeft) + right*right/float64(nRight) - total*total/float64(len(sorted))
+			if gain > bestGain {
+				bestGain = gain
+				bestFeature = feature
+				bestSplit = nLeft
+				bestThreshold = (current + next) / 2
+			}
+		}
+	}
+
+	if bestFeature < 0 {
+		return g.leaf(residuals, hessians, rows)
+	}
+
+	var leftRows, rightRows []int
+	for _, i := range rows {
+		if X[i][bestFeature] <= bestThreshold {
+			leftRows = append(leftRows, i)
+		} else {
+			rightRows = append(rightRows, i)
+		}
+	}
+	if len(leftRows) != bestSplit {
+		return g.leaf(residuals, hessians, rows)
+	}
+
+	return &TreeNode{
+		Feature:   bestFeature,
+		Threshold: bestThreshold,
+		Left:      g.buildTree(X, residuals, hessians, leftRows, depth+1),
+		Right:     g.buildTree(X, residuals, hessians, rightRows, depth+1),
+	}
+}
+
+// leaf returns a leaf holding the Newton step of the given rows.
+func (g *GradientBoostedTrees) leaf(residuals, hessians []float64, rows []int) *TreeNode {
+	sumResiduals, sumHessians := 0.0, 0.0
+	for _, i := range rows {
+		sumResiduals += residuals[i]
+		sumHessians += hessians[i]
+	}
+	value := 0.0
+	if sumHessians > 1e-12 {
+		value = sumResiduals / sumHessians
+	}
+	return &TreeNode{Leaf: true, Value: value}
+}
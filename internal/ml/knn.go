@@ -0,0 +1,72 @@
+package ml
+
+import (
+	"fmt"
+	"sort"
+)
+
+// KNearestNeighbors predicts the share of label 1 among the K closest training rows in standardized
+// Euclidean distance.
+type KNearestNeighbors struct {
+	K int
+
+	Scaler Scaler
+	rows   [][]float64
+	labels []float64
+}
+
+// NewKNearestNeighbors creates a new KNearestNeighbors model with the given number of neighbors.
+func NewKNearestNeighbors(k int) (*KNearestNeighbors, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got %d", k)
+	}
+	return &KNearestNeighbors{K: k}, nil
+}
+
+// Fit stores the standardized training rows and their labels.
+func (knn *KNearestNeighbors) Fit(X [][]float64, y []float64) error {
+	if err := validateTrainingSet(X, y); err != nil {
+		return err
+	}
+
+	knn.Scaler = FitScaler(X)
+	knn.rows = make([][]float64, len(X))
+	for i, row := range X {
+		knn.rows[i] = knn.Scaler.Transform(row)
+	}
+	knn.labels = append([]float64(nil), y...)
+	return nil
+}
+
+// Predict returns the share of label 1 among the nearest neighbors of x. Ties in distance are
+// broken by the order of the training rows.
+func (knn *KNearestNeighbors) Predict(x []float64) (float64, error) {
+	dims := 0
+	if len(knn.rows) > 0 {
+		dims = len(knn.rows[0])
+	}
+	if err := validateInput(x, dims); err != nil {
+		return 0, err
+	}
+
+	scaled := knn.Scaler.Transform(x)
+	distances := make([]float64, len(knn.rows))
+	order := make([]int, len(knn.rows))
+	for i, row := range knn.rows {
+		for j, v := range row {
+			distances[i] += (v - scaled[j]) * (v - scaled[j])
+		}
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return distances[order[a]] < distances[order[b]] })
+
+	k := knn.K
+	if k > len(order) {
+		k = len(order)
+	}
+	positive := 0.0
+	for _, i := range order[:k] {
+		positive += knn.labels[i]
+	}
+	return positive / float64(k), nil
+}
@@ -0,0 +1,86 @@
+package ml
+
+import "fmt"
+
+// LogisticRegression is an L2-regularized logistic regression trained by batch gradient descent
+// on standardized features.
+type LogisticRegression struct {
+	LearningRate float64
+	Iterations   int
+	L2           float64
+
+	Scaler    Scaler
+	Weights   []float64
+	Intercept float64
+}
+
+// NewLogisticRegression creates a new LogisticRegression.
+//
+// Parameters:
+// - learningRate: The gradient descent step size, positive.
+// - iterations: The number of gradient descent iterations, positive.
+// - l2: The L2 penalty on the weights, non-negative.
+// Returns a pointer to the newly created LogisticRegression, or an error if the parameters are invalid.
+func NewLogisticRegression(learningRate float64, iterations int, l2 float64) (*LogisticRegression, error) {
+	if learningRate <= 0 || iterations <= 0 || l2 < 0 {
+		return nil, fmt.Errorf("invalid parameters: learning rate %v, iterations %d, l2 %v", learningRate, iterations, l2)
+	}
+	return &LogisticRegression{LearningRate: learningRate, Iterations: iterations, L2: l2}, nil
+}
+
+// Fit trains the model on the rows of X and the binary labels y.
+func (lr *LogisticRegression) Fit(X [][]float64, y []float64) error {
+	if err := validateTrainingSet(X, y); err != nil {
+		return err
+	}
+
+	lr.Scaler = FitScaler(X)
+	scaled := make([][]float64, len(X))
+	for i, row := range X {
+		scaled[i] = lr.Scaler.Transform(row)
+	}
+
+	dims := len(X[0])
+	lr.Weights = make([]float64, dims)
+	lr.Intercept = 0
+	n := float64(len(X))
+
+	gradient := make([]float64, dims)
+	for iter := 0; iter < lr.Iterations; iter++ {
+		for j := range gradient {
+			gradient[j] = lr.L2 * lr.Weights[j]
+		}
+		gradientIntercept := 0.0
+
+		for i, row := range scaled {
+			err := lr.score(row) - y[i]
+			for j, v := range row {
+				gradient[j] += err * v / n
+			}
+			gradientIntercept += err / n
+		}
+
+		for j := range lr.Weights {
+			lr.Weights[j] -= lr.LearningRate * gradient[j]
+		}
+		lr.Intercept -= lr.LearningRate * gradientIntercept
+	}
+	return nil
+}
+
+// Predict returns the probability of label 1 for the features x.
+func (lr *LogisticRegression) Predict(x []float64) (float64, error) {
+	if err := validateInput(x, len(lr.Weights)); err != nil {
+		return 0, err
+	}
+	return lr.score(lr.Scaler.Transform(x)), nil
+}
+
+// score returns the predicted probability for already standardized features.
+func (lr *LogisticRegression) score(scaled []float64) float64 {
+	z := lr.Intercept
+	for j, v := range scaled {
+		z += lr.Weights[j] * v
+	}
+	return sigmoid(z)
+}
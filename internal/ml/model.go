@@ -0,0 +1,104 @@
+package ml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Model is a supervised learning model for binary classification.
+// Labels are 0 or 1 and predictions are the probability of label 1.
+type Model interface {
+	Fit(X [][]float64, y []float64) error
+	Predict(x []float64) (float64, error)
+}
+
+// validateTrainingSet checks that X is a non-empty rectangular matrix of finite values with one binary label per row.
+func validateTrainingSet(X [][]float64, y []float64) error {
+	if len(X) == 0 {
+		return errors.New("empty training set")
+	}
+	if len(X) != len(y) {
+		return fmt.Errorf("got %d rows and %d labels", len(X), len(y))
+	}
+	dims := len(X[0])
+	if dims == 0 {
+		return errors.New("training set has no features")
+	}
+	for i, row := range X {
+		if len(row) != dims {
+			return fmt.Errorf("row %d has %d features, expected %d", i, len(row), dims)
+		}
+		for _, v := range row {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("row %d contains a non-finite value", i)
+			}
+		}
+		if y[i] != 0 && y[i] != 1 {
+			return fmt.Errorf("label %d must be 0 or 1, got %v", i, y[i])
+		}
+	}
+	return nil
+}
+
+// validateInput checks that x has the expected number of finite features.
+func validateInput(x []float64, dims int) error {
+	if dims == 0 {
+		return errors.New("model is not trained")
+	}
+	if len(x) != dims {
+		return fmt.Errorf("got %d features, expected %d", len(x), dims)
+	}
+	for _, v := range x {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("input contains a non-finite value")
+		}
+	}
+	return nil
+}
+
+// Scaler standardizes features to zero mean and unit variance.
+type Scaler struct {
+	Mean   []float64
+	StdDev []float64
+}
+
+// FitScaler estimates the mean and standard deviation of every column of X.
+func FitScaler(X [][]float64) Scaler {
+	dims := len(X[0])
+	s := Scaler{Mean: make([]float64, dims), StdDev: make([]float64, dims)}
+	for _, row := range X {
+		for j, v := range row {
+			s.Mean[j] += v
+		}
+	}
+	for j := range s.Mean {
+		s.Mean[j] /= float64(len(X))
+	}
+	for _, row := range X {
+		for j, v := range row {
+			s.StdDev[j] += (v - s.Mean[j]) * (v - s.Mean[j])
+		}
+	}
+	for j := range s.StdDev {
+		s.StdDev[j] = math.Sqrt(s.StdDev[j] / float64(len(X)))
+		if s.StdDev[j] == 0 {
+			s.StdDev[j] = 1 // Constant column, only center it
+		}
+	}
+	return s
+}
+
+// Transform returns the standardized copy of x.
+func (s Scaler) Transform(x []float64) []float64 {
+	scaled := make([]float64, len(x))
+	for j, v := range x {
+		scaled[j] = (v - s.Mean[j]) / s.StdDev[j]
+	}
+	return scaled
+}
+
+// sigmoid is the logistic function.
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,608 @@
+package ml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// positiveCategories are the target categories of a classification model treated as an up move.
+var positiveCategories = []string{"1", "true", "True", "up", "Up"}
+
+// PMMLModel is a pre-trained model loaded from a PMML document. It supports RegressionModel, TreeModel
+// and MiningModel with sum, average, weightedAverage and modelChain segmentation, which covers linear and
+// logistic regressions, decision trees, random forests and gradient-boosted trees exported by common tools.
+type PMMLModel struct {
+	Features       []string
+	Classification bool
+
+	root pmmlEvaluator
+}
+
+// LoadPMMLModel loads a pre-trained model from a PMML file.
+func LoadPMMLModel(path string) (*PMMLModel, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading model file: %v", err)
+	}
+	return ParsePMMLModel(raw)
+}
+
+// ParsePMMLModel parses a pre-trained model from a PMML document.
+func ParsePMMLModel(raw []byte) (*PMMLModel, error) {
+	var doc pmmlDocument
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("error unmarshaling PMML: %v", err)
+	}
+
+	var model *pmmlModel
+	switch {
+	case doc.RegressionModel != nil:
+		model = &pmmlModel{Regression: doc.RegressionModel}
+	case doc.TreeModel != nil:
+		model = &pmmlModel{Tree: doc.TreeModel}
+	case doc.MiningModel != nil:
+		model = &pmmlModel{Mining: doc.MiningModel}
+	default:
+		return nil, errors.New("PMML document contains no supported model")
+	}
+
+	root, err := model.evaluator()
+	if err != nil {
+		return nil, err
+	}
+
+	header := model.header()
+	var features []string
+	for _, field := range header.MiningSchema.Fields {
+		if field.Usage == "" || field.Usage == "active" {
+			features = append(features, field.Name)
+		}
+	}
+	if len(features) == 0 {
+		return nil, errors.New("PMML model has no active fields")
+	}
+
+	return &PMMLModel{
+		Features:       features,
+		Classification: header.FunctionName == "classification",
+		root:           root,
+	}, nil
+}
+
+// Fit always fails since the model is pre-trained.
+func (m *PMMLModel) Fit(X [][]float64, y []float64) error { return ErrPretrained }
+
+// FeatureNames returns the names of the active fields in the order expected by Predict.
+func (m *PMMLModel) FeatureNames() []string { return m.Features }
+
+// Probabilistic reports whether the model is a classifier returning the probability of the positive category.
+func (m *PMMLModel) Probabilistic() bool { return m.Classification }
+
+// Predict evaluates the model on the features x.
+func (m *PMMLModel) Predict(x []float64) (float64, error) {
+	if err := validateInput(x, len(m.Features)); err != nil {
+		return 0, err
+	}
+	fields := make(map[string]float64, len(m.Features))
+	for i, name := range m.Features {
+		fields[name] = x[i]
+	}
+	return m.root.evaluate(fields)
+}
+
+// pmmlDocument is the subset of the PMML schema understood by ParsePMMLModel.
+type pmmlDocument struct {
+	XMLName         xml.Name             `xml:"PMML"`
+	RegressionModel *pmmlRegressionModel `xml:"RegressionModel"`
+	TreeModel       *pmmlTreeModel       `xml:"TreeModel"`
+	MiningModel     *pmmlMiningModel     `xml:"MiningModel"`
+}
+
+type pmmlHeader struct {
+	FunctionName string `xml:"functionName,attr"`
+	MiningSchema struct {
+		Fields []struct {
+			Name  string `xml:"name,attr"`
+			Usage string `xml:"usageType,attr"`
+		} `xml:"MiningField"`
+	} `xml:"MiningSchema"`
+	Output struct {
+		Fields []struct {
+			Name    string `xml:"name,attr"`
+			Feature string `xml:"feature,attr"`
+			Value   string `xml:"value,attr"`
+		} `xml:"OutputField"`
+	} `xml:"Output"`
+}
+
+type pmmlRegressionModel struct {
+	pmmlHeader
+	Normalization string `xml:"normalizationMethod,attr"`
+	Tables        []struct {
+		Intercept      float64 `xml:"intercept,attr"`
+		TargetCategory string  `xml:"targetCategory,attr"`
+		Predictors     []struct {
+			Name        string   `xml:"name,attr"`
+			Coefficient float64  `xml:"coefficient,attr"`
+			Exponent    *float64 `xml:"exponent,attr"`
+		} `xml:"NumericPredictor"`
+	} `xml:"RegressionTable"`
+}
+
+type pmmlTreeModel struct {
+	pmmlHeader
+	Node pmmlNode `xml:"Node"`
+}
+
+type pmmlNode struct {
+	pmmlPredicate
+	Score         string     `xml:"score,attr"`
+	Nodes         []pmmlNode `xml:"Node"`
+	Distributions []struct {
+		Value       string   `xml:"value,attr"`
+		RecordCount float64  `xml:"recordCount,attr"`
+		Probability *float64 `xml:"probability,attr"`
+	} `xml:"ScoreDistribution"`
+}
+
+type pmmlPredicate struct {
+	Simple   *pmmlSimplePredicate   `xml:"SimplePredicate"`
+	Compound *pmmlCompoundPredicate `xml:"CompoundPredicate"`
+	True     *struct{}              `xml:"True"`
+	False    *struct{}              `xml:"False"`
+}
+
+type pmmlSimplePredicate struct {
+	Field    string `xml:"field,attr"`
+	Operator string `xml:"operator,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+type pmmlCompoundPredicate struct {
+	Operator string                  `xml:"booleanOperator,attr"`
+	Simple   []pmmlSimplePredicate   `xml:"SimplePredicate"`
+	Compound []pmmlCompoundPredicate `xml:"CompoundPredicate"`
+	True     []struct{}              `xml:"True"`
+	False    []struct{}              `xml:"False"`
+}
+
+type pmmlMiningModel struct {
+	pmmlHeader
+	Segmentation struct {
+		Method   string `xml:"multipleModelMethod,attr"`
+		Segments []struct {
+			pmmlPredicate
+			Weight *float64 `xml:"weight,attr"`
+			pmmlModel
+		} `xml:"Segment"`
+	} `xml:"Segmentation"`
+}
+
+// pmmlModel holds exactly one of the supported model elements.
+type pmmlModel struct {
+	Regression *pmmlRegressionModel `xml:"RegressionModel"`
+	Tree       *pmmlTreeModel       `xml:"TreeModel"`
+	Mining     *pmmlMiningModel     `xml:"MiningModel"`
+}
+
+// header returns the common attributes of the model.
+func (m *pmmlModel) header() pmmlHeader {
+	switch {
+	case m.Regression != nil:
+		return m.Regression.pmmlHeader
+	case m.Tree != nil:
+		return m.Tree.pmmlHeader
+	case m.Mining != nil:
+		return m.Mining.pmmlHeader
+	default:
+		return pmmlHeader{}
+	}
+}
+
+// evaluator builds the evaluator of the model.
+func (m *pmmlModel) evaluator() (pmmlEvaluator, error) {
+	switch {
+	case m.Regression != nil:
+		return newRegressionEvaluator(m.Regression)
+	case m.Tree != nil:
+		return &treeEvaluator{model: m.Tree}, nil
+	case m.Mining != nil:
+		return newSegmentationEvaluator(m.Mining)
+	default:
+		return nil, errors.New("segment contains no supported model")
+	}
+}
+
+// pmmlEvaluator evaluates a PMML model on named input fields.
+type pmmlEvaluator interface {
+	evaluate(fields map[string]float64) (float64, error)
+}
+
+// regressionEvaluator evaluates a RegressionModel.
+type regressionEvaluator struct {
+	model    *pmmlRegressionModel
+	positive int
+}
+
+func newRegressionEvaluator(model *pmmlRegressionModel) (*regressionEvaluator, error) {
+	if len(model.Tables) == 0 {
+		return nil, errors.New("regression model has no regression table")
+	}
+	e := &regressionEvaluator{model: model}
+	if model.FunctionName == "classification" {
+		e.positive = -1
+		for i, table := range model.Tables {
+			if isPositiveCategory(table.TargetCategory) {
+				e.positive = i
+			}
+		}
+		if e.positive < 0 {
+			return nil, errors.New("classification model has no regression table for the positive category")
+		}
+		switch model.Normalization {
+		case "", "none", "softmax", "simplemax", "logit", "probit", "cloglog", "loglog", "cauchit":
+		default:
+			return nil, fmt.Errorf("unsupported normalization method %q for classification", model.Normalization)
+		}
+	}
+	return e, nil
+}
+
+func (e *regressionEvaluator) evaluate(fields map[string]float64) (float64, error) {
+	scores := make([]float64, len(e.model.Tables))
+	for i, table := range e.model.Tables {
+		scores[i] = table.Intercept
+		for _, p := range table.Predictors {
+			v, ok := fields[p.Name]
+			if !ok {
+				return 0, fmt.Errorf("missing field %q", p.Name)
+			}
+			if p.Exponent != nil {
+				v = math.Pow(v, *p.Exponent)
+			}
+			scores[i] += p.Coefficient * v
+		}
+	}
+
+	if e.model.FunctionName != "classification" {
+		return normalizeScore(e.model.Normalization, scores[0]), nil
+	}
+
+	switch e.model.Normalization {
+	case "softmax":
+		maxScore := scores[0]
+		for _, s := range scores {
+			maxScore = math.Max(maxScore, s)
+		}
+		total := 0.0
+		for _, s := range scores {
+			total += math.Exp(s - maxScore)
+		}
+		return math.Exp(scores[e.positive]-maxScore) / total, nil
+	case "simplemax":
+		total := 0.0
+		for _, s := range scores {
+			total += s
+		}
+		return scores[e.positive] / total, nil
+	}
+
+	// The other methods turn the score of every table but the last into its probability, and the last
+	// category, often a reference table without predictors, takes the rest
+	last := len(scores) - 1
+	if e.positive != last {
+		return normalizeScore(e.model.Normalization, scores[e.positive]), nil
+	}
+	rest := 1.0
+	for _, s := range scores[:last] {
+		rest -= normalizeScore(e.model.Normalization, s)
+	}
+	return rest, nil
+}
+
+// normalizeScore applies a PMML normalization method to a single score. An empty method means none.
+func normalizeScore(method string, score float64) float64 {
+	switch method {
+	case "logit", "softmax":
+		return sigmoid(score)
+	case "exp":
+		return math.Exp(score)
+	case "probit":
+		return 0.5 * math.Erfc(-score/math.Sqrt2)
+	case "cloglog":
+		return 1 - math.Exp(-math.Exp(score))
+	case "loglog":
+		return math.Exp(-math.Exp(-score))
+	case "cauchit":
+		return 0.5 + math.Atan(score)/math.Pi
+	default:
+		return score
+	}
+}
+
+// treeEvaluator evaluates a TreeModel. If no child of a node matches, the score of the last matching node is used.
+type treeEvaluator struct {
+	model *pmmlTreeModel
+}
+
+func (e *treeEvaluator) evaluate(fields map[string]float64) (float64, error) {
+	node := &e.model.Node
+	matched, err := node.matches(fields)
+	if err != nil {
+		return 0, err
+	}
+	if !matched {
+		return 0, errors.New("root node does not match")
+	}
+
+	for {
+		var next *pmmlNode
+		for i := range node.Nodes {
+			ok, err := node.Nodes[i].matches(fields)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				next = &node.Nodes[i]
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return node.value(e.model.FunctionName == "classification")
+}
+
+// value returns the probability of the positive category for classification trees and the score otherwise.
+func (n *pmmlNode) value(classification bool) (float64, error) {
+	if !classification {
+		score, err := strconv.ParseFloat(n.Score, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid score %q: %v", n.Score, err)
+		}
+		return score, nil
+	}
+
+	total, positive := 0.0, 0.0
+	for _, d := range n.Distributions {
+		if d.Probability != nil && isPositiveCategory(d.Value) {
+			return *d.Probability, nil
+		}
+		total += d.RecordCount
+		if isPositiveCategory(d.Value) {
+			positive += d.RecordCount
+		}
+	}
+	if total > 0 {
+		return positive / total, nil
+	}
+	if isPositiveCategory(n.Score) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// matches evaluates the predicate of the node.
+func (p *pmmlPredicate) matches(fields map[string]float64) (bool, error) {
+	switch {
+	case p.Simple != nil:
+		return p.Simple.matches(fields)
+	case p.Compound != nil:
+		return p.Compound.matches(fields)
+	case p.True != nil:
+		return true, nil
+	case p.False != nil:
+		return false, nil
+	default:
+		return false, errors.New("node has no supported predicate")
+	}
+}
+
+func (p *pmmlSimplePredicate) matches(fields map[string]float64) (bool, error) {
+	v, ok := fields[p.Field]
+	missing := !ok || math.IsNaN(v)
+	switch p.Operator {
+	case "isMissing":
+		return missing, nil
+	case "isNotMissing":
+		return !missing, nil
+	}
+	if missing {
+		return false, nil
+	}
+
+	threshold, err := strconv.ParseFloat(p.Value, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid predicate value %q: %v", p.Value, err)
+	}
+	switch p.Operator {
+	case "equal":
+		return v == threshold, nil
+	case "notEqual":
+		return v != threshold, nil
+	case "lessThan":
+		return v < threshold, nil
+	case "lessOrEqual":
+		return v <= threshold, nil
+	case "greaterThan":
+		return v > threshold, nil
+	case "greaterOrEqual":
+		return v >= threshold, nil
+	default:
+		return false, fmt.Errorf("unsupported operator %q", p.Operator)
+	}
+}
+
+func (p *pmmlCompoundPredicate) matches(fields map[string]float64) (bool, error) {
+	var results []bool
+	for i := range p.Simple {
+		ok, err := p.Simple[i].matches(fields)
+		if err != nil {
+			return false, err
+		}
+		results = append(results, ok)
+	}
+	for i := range p.Compound {
+		ok, err := p.Compound[i].matches(fields)
+		if err != nil {
+			return false, err
+		}
+		results = append(results, ok)
+	}
+	for range p.True {
+		results = append(results, true)
+	}
+	for range p.False {
+		results = append(results, false)
+	}
+
+	switch p.Operator {
+	case "and":
+		for _, r := range results {
+			if !r {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "or":
+		for _, r := range results {
+			if r {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "xor":
+		count := 0
+		for _, r := range results {
+			if r {
+				count++
+			}
+		}
+		return count%2 == 1, nil
+	default:
+		return false, fmt.Errorf("unsupported boolean operator %q", p.Operator)
+	}
+}
+
+// segmentationEvaluator evaluates a MiningModel.
+type segmentationEvaluator struct {
+	method     string
+	predicates []*pmmlPredicate
+	weights    []float64
+	evaluators []pmmlEvaluator
+	outputs    [][]string
+}
+
+func newSegmentationEvaluator(model *pmmlMiningModel) (*segmentationEvaluator, error) {
+	e := &segmentationEvaluator{method: model.Segmentation.Method}
+	switch e.method {
+	case "sum", "average", "weightedAverage", "modelChain":
+	default:
+		return nil, fmt.Errorf("unsupported segmentation method %q", e.method)
+	}
+	if len(model.Segmentation.Segments) == 0 {
+		return nil, errors.New("segmentation has no segments")
+	}
+
+	for i := range model.Segmentation.Segments {
+		segment := &model.Segmentation.Segments[i]
+		evaluator, err := segment.pmmlModel.evaluator()
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %v", i, err)
+		}
+
+		weight := 1.0
+		if segment.Weight != nil {
+			weight = *segment.Weight
+		}
+
+		// Output fields carrying the predicted value feed later segments of a model chain
+		var outputs []string
+		for _, field := range segment.pmmlModel.header().Output.Fields {
+			if field.Feature == "" || field.Feature == "predictedValue" || (field.Feature == "probability" && isPositiveCategory(field.Value)) {
+				outputs = append(outputs, field.Name)
+			}
+		}
+
+		e.predicates = append(e.predicates, &segment.pmmlPredicate)
+		e.weights = append(e.weights, weight)
+		e.evaluators = append(e.evaluators, evaluator)
+		e.outputs = append(e.outputs, outputs)
+	}
+	return e, nil
+}
+
+func (e *segmentationEvaluator) evaluate(fields map[string]float64) (float64, error) {
+	if e.method == "modelChain" {
+		// Later segments may read the outputs of earlier ones, so work on a copy of the fields
+		chained := make(map[string]float64, len(fields))
+		for k, v := range fields {
+			chained[k] = v
+		}
+		fields = chained
+	}
+
+	total, totalWeight, last := 0.0, 0.0, 0.0
+	evaluated := 0
+	for i, evaluator := range e.evaluators {
+		ok, err := e.predicates[i].matches(fields)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+
+		value, err := evaluator.evaluate(fields)
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range e.outputs[i] {
+			fields[name] = value
+		}
+
+		// Weights only apply to weightedAverage
+		if e.method == "weightedAverage" {
+			total += e.weights[i] * value
+		} else {
+			total += value
+		}
+		totalWeight += e.weights[i]
+		last = value
+		evaluated++
+	}
+
+	if evaluated == 0 {
+		return 0, errors.New("no segment matched")
+	}
+	switch e.method {
+	case "sum":
+		return total, nil
+	case "average":
+		return total / float64(evaluated), nil
+	case "weightedAverage":
+		if totalWeight == 0 {
+			return 0, errors.New("segment weights sum to zero")
+		}
+		return total / totalWeight, nil
+	default:
+		return last, nil
+	}
+}
+
+// isPositiveCategory reports whether a target category denotes an up move.
+func isPositiveCategory(category string) bool {
+	for _, c := range positiveCategories {
+		if category == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package ml
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pmmlDoc wraps a model element into a PMML document.
+func pmmlDoc(model string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<PMML xmlns="http://www.dmg.org/PMML-4_4" version="4.4">
+  <Header description="test"/>
+  <DataDictionary numberOfFields="3">
+    <DataField name="x" optype="continuous" dataType="double"/>
+    <DataField name="z" optype="continuous" dataType="double"/>
+    <DataField name="y" optype="categorical" dataType="string"/>
+  </DataDictionary>
+` + model + `
+</PMML>`)
+}
+
+// miningSchema declares the active fields x and z and the target y.
+const miningSchema = `<MiningSchema>
+      <MiningField name="x"/>
+      <MiningField name="z" usageType="active"/>
+      <MiningField name="y" usageType="target"/>
+    </MiningSchema>`
+
+// assertPredictions evaluates a model on x, with z zero, and compares the results.
+func assertPredictions(t *testing.T, name string, model *PMMLModel, xs, want []float64) {
+	t.Helper()
+	for i, x := range xs {
+		got, err := model.Predict([]float64{x, 0})
+		if err != nil {
+			t.Fatalf("%s: x = %v: %v", name, x, err)
+		}
+		if math.Abs(got-want[i]) > 1e-9 {
+			t.Errorf("%s: x = %v: got %.6f, want %.6f", name, x, got, want[i])
+		}
+	}
+}
+
+func parsePMML(t *testing.T, model string) *PMMLModel {
+	t.Helper()
+	m, err := ParsePMMLModel(pmmlDoc(model))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPMMLRegressionClassification(t *testing.T) {
+	xs := []float64{-3, 0, 3}
+	score := func(x float64) float64 { return 0.5 + 2*x }
+	tests := []struct {
+		name, normalization, tables string
+		want                        func(x float64) float64
+	}{
+		{
+			// The positive category is the reference table: p(1) = 1 - sigmoid(y0)
+			"logit, positive last", `normalizationMethod="logit"`,
+			`<RegressionTable targetCategory="0" intercept="0.5"><NumericPredictor name="x" coefficient="2"/></RegressionTable>
+    <RegressionTable targetCategory="1" intercept="0"/>`,
+			func(x float64) float64 { return 1 - sigmoid(score(x)) },
+		},
+		{
+			"logit, positive first", `normalizationMethod="logit"`,
+			`<RegressionTable targetCategory="up" intercept="0.5"><NumericPredictor name="x" coefficient="2"/></RegressionTable>
+    <RegressionTable targetCategory="down" intercept="0"/>`,
+			func(x float64) float64 { return sigmoid(score(x)) },
+		},
+		{
+			"softmax", `normalizationMethod="softmax"`,
+			`<RegressionTable targetCategory="down" intercept="0.1"><NumericPredictor name="x" coefficient="-1"/></RegressionTable>
+    <RegressionTable targetCategory="up" intercept="0.5"><NumericPredictor name="x" coefficient="2"/></RegressionTable>
+    <RegressionTable targetCategory="flat" intercept="0"/>`,
+			func(x float64) float64 {
+				down, up := math.Exp(0.1-x), math.Exp(score(x))
+				return up / (down + up + 1)
+			},
+		},
+		{
+			// No normalization method means none: scores are probabilities and the last category takes the rest
+			"none, positive last", "",
+			`<RegressionTable targetCategory="0" intercept="0.4"><NumericPredictor name="x" coefficient="0.1"/></RegressionTable>
+    <RegressionTable targetCategory="1" intercept="0"/>`,
+			func(x float64) float64 { return 0.6 - 0.1*x },
+		},
+		{
+			"none, positive first", `normalizationMethod="none"`,
+			`<RegressionTable targetCategory="1" intercept="0.4"><NumericPredictor name="x" coefficient="0.1"/></RegressionTable>
+    <RegressionTable targetCategory="0" intercept="0"/>`,
+			func(x float64) float64 { return 0.4 + 0.1*x },
+		},
+	}
+	for _, tt := range tests {
+		model := parsePMML(t, `<RegressionModel functionName="classification" `+tt.normalization+`>
+    `+miningSchema+`
+    `+tt.tables+`
+  </RegressionModel>`)
+		if !model.Probabilistic() {
+			t.Errorf("%s: not probabilistic", tt.name)
+		}
+		want := make([]float64, len(xs))
+		for i, x := range xs {
+			want[i] = tt.want(x)
+		}
+		assertPredictions(t, tt.name, model, xs, want)
+	}
+
+	// The reference values of the positive-last logit model
+	model := parsePMML(t, `<RegressionModel functionName="classification" normalizationMethod="logit">
+    `+miningSchema+`
+    <RegressionTable targetCategory="0" intercept="0.5"><NumericPredictor name="x" coefficient="2"/></RegressionTable>
+    <RegressionTable targetCategory="1" intercept="0"/>
+  </RegressionModel>`)
+	for i, want := range []float64{0.9959, 0.3775, 0.0015} {
+		got, _ := model.Predict([]float64{xs[i], 0})
+		if math.Abs(got-want) > 1e-4 {
+			t.Errorf("x = %v: got %.4f, want %.4f", xs[i], got, want)
+		}
+	}
+}
+
+func TestPMMLRegression(t *testing.T) {
+	model := parsePMML(t, `<RegressionModel functionName="regression">
+    `+miningSchema+`
+    <RegressionTable intercept="1">
+      <NumericPredictor name="x" exponent="2" coefficient="2"/>
+      <NumericPredictor name="z" coefficient="-1"/>
+    </RegressionTable>
+  </RegressionModel>`)
+	if model.Probabilistic() || strings.Join(model.FeatureNames(), ",") != "x,z" {
+		t.Fatalf("got features %v, probabilistic %v", model.FeatureNames(), model.Probabilistic())
+	}
+	got, err := model.Predict([]float64{3, 4})
+	if err != nil || got != 15 {
+		t.Errorf("got %v, %v, want 15", got, err)
+	}
+	if err := model.Fit(nil, nil); !errors.Is(err, ErrPretrained) {
+		t.Errorf("Fit: got %v, want ErrPretrained", err)
+	}
+	if _, err := model.Predict([]float64{3}); err == nil {
+		t.Error("Predict accepted one feature of two")
+	}
+}
+
+func TestPMMLRegressionInvalid(t *testing.T) {
+	tests := []struct{ name, model string }{
+		{"no positive category", `<RegressionModel functionName="classification" normalizationMethod="logit">
+    ` + miningSchema + `
+    <RegressionTable targetCategory="a" intercept="0"/>
+    <RegressionTable targetCategory="b" intercept="0"/>
+  </RegressionModel>`},
+		{"unsupported normalization", `<RegressionModel functionName="classification" normalizationMethod="exp">
+    ` + miningSchema + `
+    <RegressionTable targetCategory="1" intercept="0"/>
+    <RegressionTable targetCategory="0" intercept="0"/>
+  </RegressionModel>`},
+		{"no table", `<RegressionModel functionName="regression">
+    ` + miningSchema + `
+  </RegressionModel>`},
+		{"no model", `<NaiveBayesModel functionName="classification"/>`},
+	}
+	for _, tt := range tests {
+		if _, err := ParsePMMLModel(pmmlDoc(tt.model)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestPMMLTree(t *testing.T) {
+	regression := parsePMML(t, `<TreeModel functionName="regression">
+    `+miningSchema+`
+    <Node score="0"><True/>
+      <Node score="1"><SimplePredicate field="x" operator="lessThan" value="0"/>
+        <Node score="2"><SimplePredicate field="x" operator="lessOrEqual" value="-2"/></Node>
+      </Node>
+      <Node score="3"><SimplePredicate field="x" operator="greaterOrEqual" value="0"/></Node>
+    </Node>
+  </TreeModel>`)
+	// A node without a matching child keeps its own score
+	assertPredictions(t, "regression tree", regression, []float64{-3, -1, 0, 5}, []float64{2, 1, 3, 3})
+
+	// Probabilities of the positive category from record counts or given explicitly
+	classification := parsePMML(t, `<TreeModel functionName="classification">
+    `+miningSchema+`
+    <Node><True/>
+      <Node>
+        <CompoundPredicate booleanOperator="or">
+          <SimplePredicate field="x" operator="lessOrEqual" value="-2"/>
+          <SimplePredicate field="z" operator="equal" value="1"/>
+        </CompoundPredicate>
+        <ScoreDistribution value="0" recordCount="30"/>
+        <ScoreDistribution value="1" recordCount="10"/>
+      </Node>
+      <Node>
+        <SimplePredicate field="x" operator="lessThan" value="0"/>
+        <ScoreDistribution value="down" recordCount="5" probability="0.2"/>
+        <ScoreDistribution value="up" recordCount="20" probability="0.8"/>
+      </Node>
+      <Node score="1"><True/></Node>
+    </Node>
+  </TreeModel>`)
+	tests := []struct{ x, z, want float64 }{
+		{-3, 0, 0.25},
+		{-1, 1, 0.25},
+		{-1, 0, 0.8},
+		{1, 0, 1},
+	}
+	for _, tt := range tests {
+		got, err := classification.Predict([]float64{tt.x, tt.z})
+		if err != nil || got != tt.want {
+			t.Errorf("classification x = %v, z = %v: got %v, %v, want %v", tt.x, tt.z, got, err, tt.want)
+		}
+	}
+}
+
+// segment is a tree splitting on x at 0 wrapped into a segment.
+func segment(attributes, output string, below, above float64) string {
+	return `<Segment ` + attributes + `><True/>
+      <TreeModel functionName="regression">
+        ` + miningSchema + output + `
+        <Node score="0"><True/>
+          <Node score="` + strconv.FormatFloat(below, 'f', -1, 64) + `"><SimplePredicate field="x" operator="lessThan" value="0"/></Node>
+          <Node score="` + strconv.FormatFloat(above, 'f', -1, 64) + `"><True/></Node>
+        </Node>
+      </TreeModel>
+    </Segment>`
+}
+
+func TestPMMLSegmentation(t *testing.T) {
+	mining := func(method, segments string) *PMMLModel {
+		return parsePMML(t, `<MiningModel functionName="regression">
+    `+miningSchema+`
+    <Segmentation multipleModelMethod="`+method+`">
+    `+segments+`
+    </Segmentation>
+  </MiningModel>`)
+	}
+	trees := segment("", "", 1, 2) + segment(`weight="3"`, "", 5, -2)
+	assertPredictions(t, "sum", mining("sum", trees), []float64{-1, 1}, []float64{6, 0})
+	assertPredictions(t, "average", mining("average", trees), []float64{-1, 1}, []float64{3, 0})
+	assertPredictions(t, "weightedAverage", mining("weightedAverage", trees), []float64{-1, 1}, []float64{4, -1})
+
+	// Segments whose predicate does not match are left out
+	filtered := strings.Replace(trees, `<Segment weight="3"><True/>`, `<Segment weight="3"><SimplePredicate field="z" operator="greaterThan" value="0"/>`, 1)
+	assertPredictions(t, "filtered sum", mining("sum", filtered), []float64{-1, 1}, []float64{1, 2})
+
+	// The regression of the second segment reads the prediction of the first
+	chain := mining("modelChain", segment("", `<Output><OutputField name="tree" feature="predictedValue"/></Output>`, 1, 2)+`
+    <Segment><True/>
+      <RegressionModel functionName="regression">
+        <MiningSchema><MiningField name="tree"/></MiningSchema>
+        <RegressionTable intercept="0.5"><NumericPredictor name="tree" coefficient="10"/></RegressionTable>
+      </RegressionModel>
+    </Segment>`)
+	assertPredictions(t, "modelChain", chain, []float64{-1, 1}, []float64{10.5, 20.5})
+
+	if _, err := ParsePMMLModel(pmmlDoc(`<MiningModel functionName="regression">
+    ` + miningSchema + `
+    <Segmentation multipleModelMethod="majorityVote">` + trees + `</Segmentation>
+  </MiningModel>`)); err == nil {
+		t.Error("majorityVote: no error")
+	}
+}
@@ -0,0 +1,215 @@
+package ml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// ErrPretrained is returned when Fit is called on a model that was loaded from a file.
+var ErrPretrained = errors.New("pre-trained models cannot be refit")
+
+// Link is the function applied to the raw score of a pre-trained model.
+type Link string
+
+const (
+	// IdentityLink returns the raw score, e.g. a predicted return.
+	IdentityLink Link = "identity"
+	// LogitLink maps the raw score to a probability with the logistic function.
+	LogitLink Link = "logit"
+)
+
+// apply applies the link function to a raw score.
+func (l Link) apply(score float64) float64 {
+	if l == LogitLink {
+		return sigmoid(score)
+	}
+	return score
+}
+
+// PretrainedModel is a model trained outside goquant. Its inputs are identified by name so that they
+// can be matched to the columns of a feature Pipeline.
+type PretrainedModel interface {
+	Model
+	FeatureNames() []string
+	// Probabilistic reports whether Predict returns a probability of an up move rather than a raw score.
+	Probabilistic() bool
+}
+
+// LinearModel is a pre-trained linear or logistic regression.
+type LinearModel struct {
+	Features     []string
+	Intercept    float64
+	Coefficients []float64
+	Link         Link
+}
+
+// Fit always fails since the model is pre-trained.
+func (m *LinearModel) Fit(X [][]float64, y []float64) error { return ErrPretrained }
+
+// FeatureNames returns the names of the inputs in the order expected by Predict.
+func (m *LinearModel) FeatureNames() []string { return m.Features }
+
+// Probabilistic reports whether the model uses the logit link.
+func (m *LinearModel) Probabilistic() bool { return m.Link == LogitLink }
+
+// Predict returns the linked score of the features x.
+func (m *LinearModel) Predict(x []float64) (float64, error) {
+	if err := validateInput(x, len(m.Coefficients)); err != nil {
+		return 0, err
+	}
+	score := m.Intercept
+	for i, c := range m.Coefficients {
+		score += c * x[i]
+	}
+	return m.Link.apply(score), nil
+}
+
+// Aggregation determines how the outputs of the trees of a TreeEnsemble are combined.
+type Aggregation string
+
+const (
+	// SumAggregation adds the tree outputs, as in gradient boosting.
+	SumAggregation Aggregation = "sum"
+	// AverageAggregation averages the tree outputs, as in random forests.
+	AverageAggregation Aggregation = "average"
+)
+
+// TreeEnsemble is a pre-trained ensemble of regression trees.
+type TreeEnsemble struct {
+	Features    []string
+	BaseScore   float64
+	Aggregation Aggregation
+	Trees       []*TreeNode
+	Link        Link
+}
+
+// Fit always fails since the model is pre-trained.
+func (m *TreeEnsemble) Fit(X [][]float64, y []float64) error { return ErrPretrained }
+
+// FeatureNames returns the names of the inputs in the order expected by Predict.
+func (m *TreeEnsemble) FeatureNames() []string { return m.Features }
+
+// Probabilistic reports whether the model uses the logit link.
+func (m *TreeEnsemble) Probabilistic() bool { return m.Link == LogitLink }
+
+// Predict returns the linked, aggregated tree output for the features x.
+func (m *TreeEnsemble) Predict(x []float64) (float64, error) {
+	if err := validateInput(x, len(m.Features)); err != nil {
+		return 0, err
+	}
+	if len(m.Trees) == 0 {
+		return 0, errors.New("ensemble has no trees")
+	}
+
+	score := 0.0
+	for _, tree := range m.Trees {
+		score += tree.Evaluate(x)
+	}
+	if m.Aggregation == AverageAggregation {
+		score /= float64(len(m.Trees))
+	}
+	return m.Link.apply(m.BaseScore + score), nil
+}
+
+// jsonModel is the documented JSON model format read by LoadJSONModel.
+type jsonModel struct {
+	Type         string      `json:"type"`
+	Features     []string    `json:"features"`
+	Link         Link        `json:"link"`
+	Intercept    float64     `json:"intercept"`
+	Coefficients []float64   `json:"coefficients"`
+	BaseScore    float64     `json:"base_score"`
+	Aggregation  Aggregation `json:"aggregation"`
+	Trees        []*TreeNode `json:"trees"`
+}
+
+// LoadJSONModel loads a pre-trained model from a JSON file.
+//
+// Linear models use the format
+//
+//	{"type": "linear", "features": ["return_lag_0", "rsi_14"], "link": "logit",
+//	 "intercept": -0.1, "coefficients": [2.5, -0.01]}
+//
+// and tree ensembles the format
+//
+//	{"type": "tree_ensemble", "features": ["return_lag_0", "rsi_14"], "link": "logit",
+//	 "base_score": 0, "aggregation": "sum", "trees": [
+//	   {"feature": 1, "threshold": 30, "left": {"leaf": true, "value": 0.4}, "right": {"leaf": true, "value": -0.1}}]}
+//
+// where a tree node sends an input to "left" if input[feature] <= threshold, "feature" indexes "features",
+// "link" is "identity" or "logit" and "aggregation" is "sum" or "average".
+func LoadJSONModel(path string) (PretrainedModel, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading model file: %v", err)
+	}
+	return ParseJSONModel(raw)
+}
+
+// ParseJSONModel parses a pre-trained model in the format documented at LoadJSONModel.
+func ParseJSONModel(raw []byte) (PretrainedModel, error) {
+	var m jsonModel
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("error unmarshaling model: %v", err)
+	}
+	if len(m.Features) == 0 {
+		return nil, errors.New("model has no features")
+	}
+	if m.Link == "" {
+		m.Link = IdentityLink
+	}
+	if m.Link != IdentityLink && m.Link != LogitLink {
+		return nil, fmt.Errorf("unknown link: %q", m.Link)
+	}
+
+	switch m.Type {
+	case "linear":
+		if len(m.Coefficients) != len(m.Features) {
+			return nil, fmt.Errorf("got %d coefficients for %d features", len(m.Coefficients), len(m.Features))
+		}
+		return &LinearModel{Features: m.Features, Intercept: m.Intercept, Coefficients: m.Coefficients, Link: m.Link}, nil
+
+	case "tree_ensemble":
+		if m.Aggregation == "" {
+			m.Aggregation = SumAggregation
+		}
+		if m.Aggregation != SumAggregation && m.Aggregation != AverageAggregation {
+			return nil, fmt.Errorf("unknown aggregation: %q", m.Aggregation)
+		}
+		if len(m.Trees) == 0 {
+			return nil, errors.New("ensemble has no trees")
+		}
+		for i, tree := range m.Trees {
+			if err := validateTree(tree, len(m.Features)); err != nil {
+				return nil, fmt.Errorf("tree %d: %v", i, err)
+			}
+		}
+		return &TreeEnsemble{Features: m.Features, BaseScore: m.BaseScore, Aggregation: m.Aggregation, Trees: m.Trees, Link: m.Link}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown model type: %q", m.Type)
+	}
+}
+
+// validateTree checks that every inner node has two children and a valid feature index.
+func validateTree(node *TreeNode, features int) error {
+	if node == nil {
+		return errors.New("missing node")
+	}
+	if node.Leaf {
+		return nil
+	}
+	if node.Feature < 0 || node.Feature >= features {
+		return fmt.Errorf("feature index %d out of range", node.Feature)
+	}
+	if math.IsNaN(node.Threshold) {
+		return errors.New("threshold is NaN")
+	}
+	if err := validateTree(node.Left, features); err != nil {
+		return err
+	}
+	return validateTree(node.Right, features)
+}
@@ -0,0 +1,158 @@
+package options
+
+import (
+	"math"
+
+	data_types "goquant/pkg/data"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// BlackScholesMerton prices European options on an asset with a continuous dividend yield.
+type BlackScholesMerton struct{}
+
+// Price returns the Black-Scholes-Merton value of a European option.
+func (BlackScholesMerton) Price(in Inputs) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	if in.Style == data_types.American {
+		return 0, ErrAmericanUnsupported
+	}
+	forward := in.Underlying * math.Exp((in.Rate-in.Dividend)*in.Expiry)
+	return black(in.Type, forward, in.Strike, in.Expiry, in.Volatility, math.Exp(-in.Rate*in.Expiry)), nil
+}
+
+// Greeks returns the closed-form Black-Scholes-Merton Greeks of a European option.
+func (BlackScholesMerton) Greeks(in Inputs) (Greeks, error) {
+	if err := in.validate(); err != nil {
+		return Greeks{}, err
+	}
+	if in.Style == data_types.American {
+		return Greeks{}, ErrAmericanUnsupported
+	}
+
+	s, k, t, r, q, vol := in.Underlying, in.Strike, in.Expiry, in.Rate, in.Dividend, in.Volatility
+	discount, carry := math.Exp(-r*t), math.Exp(-q*t)
+	if t == 0 || vol == 0 {
+		return degenerateGreeks(in, s*math.Exp((r-q)*t), discount, carry), nil
+	}
+
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(s/k) + (r-q+vol*vol/2)*t) / (vol * sqrtT)
+	d2 := d1 - vol*sqrtT
+	n := distuv.UnitNormal
+
+	greeks := Greeks{
+		Gamma: carry * n.Prob(d1) / (s * vol * sqrtT),
+		Vega:  s * carry * n.Prob(d1) * sqrtT,
+	}
+	decay := -s * carry * n.Prob(d1) * vol / (2 * sqrtT)
+	if in.Type == data_types.Call {
+		greeks.Delta = carry * n.CDF(d1)
+		greeks.Theta = decay + q*s*carry*n.CDF(d1) - r*k*discount*n.CDF(d2)
+		greeks.Rho = k * t * discount * n.CDF(d2)
+	} else {
+		greeks.Delta = -carry * n.CDF(-d1)
+		greeks.Theta = decay - q*s*carry*n.CDF(-d1) + r*k*discount*n.CDF(-d2)
+		greeks.Rho = -k * t * discount * n.CDF(-d2)
+	}
+	return greeks, nil
+}
+
+// Black76 prices European options on futures and forwards. The Underlying of the Inputs is the forward
+// price and the Dividend is ignored.
+type Black76 struct{}
+
+// Price returns the Black-76 value of a European option.
+func (Black76) Price(in Inputs) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	if in.Style == data_types.American {
+		return 0, ErrAmericanUnsupported
+	}
+	return black(in.Type, in.Underlying, in.Strike, in.Expiry, in.Volatility, math.Exp(-in.Rate*in.Expiry)), nil
+}
+
+// Greeks returns the closed-form Black-76 Greeks of a European option. Delta and Gamma are with respect to the forward.
+func (Black76) Greeks(in Inputs) (Greeks, error) {
+	if err := in.validate(); err != nil {
+		return Greeks{}, err
+	}
+	if in.Style == data_types.American {
+		return Greeks{}, ErrAmericanUnsupported
+	}
+
+	f, k, t, r, vol := in.Underlying, in.Strike, in.Expiry, in.Rate, in.Volatility
+	discount := math.Exp(-r * t)
+	price := black(in.Type, f, k, t, vol, discount)
+	if t == 0 || vol == 0 {
+		// Without time value the option is a discounted forward position or worthless
+		greeks := Greeks{Theta: r * price, Rho: -t * price}
+		if price > 0 {
+			greeks.Delta = discount
+			if in.Type == data_types.Put {
+				greeks.Delta = -discount
+			}
+		}
+		return greeks, nil
+	}
+
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(f/k) + vol*vol*t/2) / (vol * sqrtT)
+	d2 := d1 - vol*sqrtT
+	n := distuv.UnitNormal
+
+	greeks := Greeks{
+		Gamma: discount * n.Prob(d1) / (f * vol * sqrtT),
+		Vega:  f * discount * n.Prob(d1) * sqrtT,
+		Rho:   -t * price,
+	}
+	decay := -f * discount * n.Prob(d1) * vol / (2 * sqrtT)
+	if in.Type == data_types.Call {
+		greeks.Delta = discount * n.CDF(d1)
+		greeks.Theta = decay - r*k*discount*n.CDF(d2) + r*f*discount*n.CDF(d1)
+	} else {
+		greeks.Delta = -discount * n.CDF(-d1)
+		greeks.Theta = decay + r*k*discount*n.CDF(-d2) - r*f*discount*n.CDF(-d1)
+	}
+	return greeks, nil
+}
+
+// black returns the discounted Black value of an option on a forward.
+func black(kind data_types.OptionType, forward, strike, t, vol, discount float64) float64 {
+	if t == 0 || vol == 0 {
+		if kind == data_types.Put {
+			return discount * math.Max(strike-forward, 0)
+		}
+		return discount * math.Max(forward-strike, 0)
+	}
+
+	stdDev := vol * math.Sqrt(t)
+	d1 := (math.Log(forward/strike) + stdDev*stdDev/2) / stdDev
+	d2 := d1 - stdDev
+	n := distuv.UnitNormal
+	if kind == data_types.Put {
+		return discount * (strike*n.CDF(-d2) - forward*n.CDF(-d1))
+	}
+	return discount * (forward*n.CDF(d1) - strike*n.CDF(d2))
+}
+
+// degenerateGreeks returns the Black-Scholes-Merton Greeks of an option without time value, which
+// behaves like a forward contract when in the money and is worthless otherwise.
+func degenerateGreeks(in Inputs, forward, discount, carry float64) Greeks {
+	inTheMoney := (in.Type == data_types.Call && forward > in.Strike) || (in.Type == data_types.Put && forward < in.Strike)
+	if !inTheMoney {
+		return Greeks{}
+	}
+	sign := 1.0
+	if in.Type == data_types.Put {
+		sign = -1
+	}
+	return Greeks{
+		Delta: sign * carry,
+		Theta: sign * (in.Dividend*in.Underlying*carry - in.Rate*in.Strike*discount),
+		Rho:   sign * in.Strike * in.Expiry * discount,
+	}
+}
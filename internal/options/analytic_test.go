@@ -0,0 +1,119 @@
+package options
+
+import (
+	"math"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+func TestBlackScholesMertonHull(t *testing.T) {
+	// Hull, Options, Futures, and Other Derivatives, Example 15.6
+	tests := []struct {
+		kind data_types.OptionType
+		want float64
+	}{
+		{data_types.Call, 4.7594},
+		{data_types.Put, 0.8086},
+	}
+	for _, tt := range tests {
+		in := Inputs{Type: tt.kind, Style: data_types.European, Underlying: 42, Strike: 40, Expiry: 0.5, Rate: 0.1, Volatility: 0.2}
+		got, err := BlackScholesMerton{}.Price(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("%v: got %.4f, want %.4f", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestBlackScholesMertonGreeksHull(t *testing.T) {
+	// Hull, Options, Futures, and Other Derivatives, chapter 19: S=49, K=50, r=5%, σ=20%, T=20 weeks
+	in := Inputs{Type: data_types.Call, Style: data_types.European, Underlying: 49, Strike: 50, Expiry: 0.3846, Rate: 0.05, Volatility: 0.2}
+	price, err := BlackScholesMerton{}.Price(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(price-2.40) > 0.005 {
+		t.Errorf("price: got %.4f, want 2.40", price)
+	}
+
+	greeks, err := BlackScholesMerton{}.Greeks(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want float64
+		tolerance float64
+	}{
+		{"delta", greeks.Delta, 0.522, 0.001},
+		{"gamma", greeks.Gamma, 0.066, 0.001},
+		{"vega", greeks.Vega, 12.1, 0.05},
+		{"theta", greeks.Theta, -4.31, 0.01},
+		{"rho", greeks.Rho, 8.91, 0.01},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > tt.tolerance {
+			t.Errorf("%s: got %.4f, want %.4f", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPutCallParity(t *testing.T) {
+	tests := []Inputs{
+		{Underlying: 100, Strike: 100, Expiry: 1, Rate: 0.05, Dividend: 0.02, Volatility: 0.25},
+		{Underlying: 80, Strike: 120, Expiry: 0.25, Rate: 0.01, Volatility: 0.6},
+		{Underlying: 150, Strike: 90, Expiry: 3, Rate: -0.005, Dividend: 0.04, Volatility: 0.1},
+	}
+	for _, in := range tests {
+		in.Style = data_types.European
+		in.Type = data_types.Call
+		call, err := BlackScholesMerton{}.Price(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.Type = data_types.Put
+		put, err := BlackScholesMerton{}.Price(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parity := in.Underlying*math.Exp(-in.Dividend*in.Expiry) - in.Strike*math.Exp(-in.Rate*in.Expiry)
+		if math.Abs(call-put-parity) > 1e-10 {
+			t.Errorf("%+v: call - put = %.12f, want %.12f", in, call-put, parity)
+		}
+	}
+}
+
+func TestBlack76Hull(t *testing.T) {
+	// Hull, Options, Futures, and Other Derivatives, Example 18.4: a put on a futures price
+	in := Inputs{Type: data_types.Put, Style: data_types.European, Underlying: 20, Strike: 20, Expiry: 4.0 / 12, Rate: 0.09, Volatility: 0.25}
+	got, err := Black76{}.Price(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got-1.1166) > 1e-4 {
+		t.Errorf("got %.4f, want 1.1166", got)
+	}
+}
+
+func TestAnalyticPricersRejectAmerican(t *testing.T) {
+	in := Inputs{Type: data_types.Put, Style: data_types.American, Underlying: 100, Strike: 100, Expiry: 1, Volatility: 0.2}
+	for _, pricer := range []Pricer{BlackScholesMerton{}, Black76{}} {
+		if _, err := pricer.Price(in); err != ErrAmericanUnsupported {
+			t.Errorf("%T: got %v, want ErrAmericanUnsupported", pricer, err)
+		}
+	}
+}
+
+func TestGreeksWithoutTimeValue(t *testing.T) {
+	in := Inputs{Type: data_types.Call, Style: data_types.European, Underlying: 110, Strike: 100, Rate: 0.05}
+	greeks, err := BlackScholesMerton{}.Greeks(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if greeks.Delta != 1 || greeks.Gamma != 0 || greeks.Vega != 0 {
+		t.Errorf("expired in-the-money call: got %+v", greeks)
+	}
+}
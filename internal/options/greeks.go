@@ -0,0 +1,109 @@
+package options
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bumps are the sizes of the finite differences used by FiniteDifferenceGreeks.
+type Bumps struct {
+	// Underlying is the relative bump of the underlying price, e.g. 0.01 for 1%.
+	Underlying float64
+	// Volatility is the absolute bump of the volatility.
+	Volatility float64
+	// Time is the absolute bump of the time to expiry in years.
+	Time float64
+	// Rate is the absolute bump of the rate.
+	Rate float64
+}
+
+// DefaultBumps returns bumps that work well for tree and closed-form pricers.
+func DefaultBumps() Bumps {
+	return Bumps{Underlying: 0.01, Volatility: 0.001, Time: 1.0 / 365, Rate: 0.0001}
+}
+
+// ComputeGreeks returns the closed-form Greeks if the pricer provides them, and finite-difference
+// Greeks with the default bumps otherwise.
+func ComputeGreeks(pricer Pricer, in Inputs) (Greeks, error) {
+	if analytic, ok := pricer.(AnalyticGreeker); ok {
+		return analytic.Greeks(in)
+	}
+	return FiniteDifferenceGreeks(pricer, in, DefaultBumps())
+}
+
+// FiniteDifferenceGreeks computes the Greeks of any pricer with central differences, or a backward
+// difference in time near expiry.
+//
+// Parameters:
+// - pricer: The pricer to differentiate.
+// - in: The inputs to compute the Greeks at.
+// - bumps: The finite-difference step sizes, all positive.
+// Returns the Greeks, or an error if a bump is invalid or a bumped price cannot be computed.
+func FiniteDifferenceGreeks(pricer Pricer, in Inputs, bumps Bumps) (Greeks, error) {
+	if bumps.Underlying <= 0 || bumps.Volatility <= 0 || bumps.Time <= 0 || bumps.Rate <= 0 {
+		return Greeks{}, fmt.Errorf("bumps must be positive, got %+v", bumps)
+	}
+	base, err := pricer.Price(in)
+	if err != nil {
+		return Greeks{}, err
+	}
+
+	price := func(bump func(*Inputs)) (float64, error) {
+		bumped := in
+		bump(&bumped)
+		return pricer.Price(bumped)
+	}
+
+	h := in.Underlying * bumps.Underlying
+	up, err := price(func(b *Inputs) { b.Underlying += h })
+	if err != nil {
+		return Greeks{}, err
+	}
+	down, err := price(func(b *Inputs) { b.Underlying -= h })
+	if err != nil {
+		return Greeks{}, err
+	}
+
+	// Keep the volatility non-negative by using a one-sided difference at zero
+	dv := bumps.Volatility
+	volUp, err := price(func(b *Inputs) { b.Volatility += dv })
+	if err != nil {
+		return Greeks{}, err
+	}
+	volDown, volSpan := base, dv
+	if in.Volatility >= dv {
+		if volDown, err = price(func(b *Inputs) { b.Volatility -= dv }); err != nil {
+			return Greeks{}, err
+		}
+		volSpan = 2 * dv
+	}
+
+	dr := bumps.Rate
+	rateUp, err := price(func(b *Inputs) { b.Rate += dr })
+	if err != nil {
+		return Greeks{}, err
+	}
+	rateDown, err := price(func(b *Inputs) { b.Rate -= dr })
+	if err != nil {
+		return Greeks{}, err
+	}
+
+	// Theta is the change of value as expiry approaches
+	dt := math.Min(bumps.Time, in.Expiry)
+	theta := 0.0
+	if dt > 0 {
+		shorter, err := price(func(b *Inputs) { b.Expiry -= dt })
+		if err != nil {
+			return Greeks{}, err
+		}
+		theta = (shorter - base) / dt
+	}
+
+	return Greeks{
+		Delta: (up - down) / (2 * h),
+		Gamma: (up - 2*base + down) / (h * h),
+		Vega:  (volUp - volDown) / volSpan,
+		Theta: theta,
+		Rho:   (rateUp - rateDown) / (2 * dr),
+	}, nil
+}
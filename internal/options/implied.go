@@ -0,0 +1,95 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoImpliedVolatility is returned when no volatility reproduces the price, e.g. below intrinsic value.
+var ErrNoImpliedVolatility = errors.New("price is outside the range of attainable option values")
+
+// impliedBounds bracket the implied volatility search.
+const (
+	minImpliedVolatility = 1e-6
+	maxImpliedVolatility = 10.0
+	impliedTolerance     = 1e-10
+	impliedIterations    = 200
+)
+
+// ImpliedVolatility finds the volatility at which the pricer reproduces the price. The Volatility of
+// the inputs is ignored. Pricers with closed-form Greeks use Newton's method safeguarded by bisection,
+// other pricers use bisection only.
+//
+// Parameters:
+// - pricer: The pricer, e.g. BlackScholesMerton{} or a BinomialTree for American options.
+// - in: The option inputs.
+// - price: The observed option price.
+// Returns the implied volatility, or ErrNoImpliedVolatility if the price cannot be attained.
+func ImpliedVolatility(pricer Pricer, in Inputs, price float64) (float64, error) {
+	if math.IsNaN(price) || price <= 0 {
+		return 0, fmt.Errorf("price must be positive, got %v", price)
+	}
+	if in.Expiry <= 0 {
+		return 0, errors.New("option has expired")
+	}
+
+	value := func(vol float64) (float64, error) {
+		in.Volatility = vol
+		return pricer.Price(in)
+	}
+
+	low, high := minImpliedVolatility, maxImpliedVolatility
+	lowValue, err := value(low)
+	if err != nil {
+		return 0, err
+	}
+	highValue, err := value(high)
+	if err != nil {
+		return 0, err
+	}
+	if price < lowValue-impliedTolerance || price > highValue+impliedTolerance {
+		return 0, ErrNoImpliedVolatility
+	}
+
+	analytic, hasVega := pricer.(AnalyticGreeker)
+	vol := initialGuess(in, price)
+	for i := 0; i < impliedIterations; i++ {
+		current, err := value(vol)
+		if err != nil {
+			return 0, err
+		}
+		diff := current - price
+		if math.Abs(diff) < impliedTolerance {
+			return vol, nil
+		}
+		if diff > 0 {
+			high = vol
+		} else {
+			low = vol
+		}
+		if high-low < impliedTolerance {
+			return vol, nil
+		}
+
+		next := (low + high) / 2
+		if hasVega {
+			in.Volatility = vol
+			greeks, err := analytic.Greeks(in)
+			if err == nil && greeks.Vega > 0 {
+				newton := vol - diff/greeks.Vega
+				if newton > low && newton < high {
+					next = newton
+				}
+			}
+		}
+		vol = next
+	}
+	return vol, nil
+}
+
+// initialGuess returns the Brenner-Subrahmanyam approximation of the implied volatility, clamped to the search range.
+func initialGuess(in Inputs, price float64) float64 {
+	guess := price / in.Underlying * math.Sqrt(2*math.Pi/in.Expiry)
+	return math.Max(math.Min(guess, 2), 0.05)
+}
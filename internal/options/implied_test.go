@@ -0,0 +1,44 @@
+package options
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+func TestImpliedVolatilityRoundTrip(t *testing.T) {
+	tree, _ := NewBinomialTree(200)
+	tests := []struct {
+		name   string
+		pricer Pricer
+		in     Inputs
+	}{
+		{"bsm atm call", BlackScholesMerton{}, Inputs{Type: data_types.Call, Underlying: 100, Strike: 100, Expiry: 1, Rate: 0.03, Volatility: 0.2}},
+		{"bsm otm put", BlackScholesMerton{}, Inputs{Type: data_types.Put, Underlying: 100, Strike: 70, Expiry: 0.1, Rate: 0.03, Volatility: 0.65}},
+		{"bsm itm call", BlackScholesMerton{}, Inputs{Type: data_types.Call, Underlying: 100, Strike: 60, Expiry: 2, Rate: 0.05, Dividend: 0.02, Volatility: 0.15}},
+		{"black76 put", Black76{}, Inputs{Type: data_types.Put, Underlying: 20, Strike: 20, Expiry: 4.0 / 12, Rate: 0.09, Volatility: 0.25}},
+		{"american put", tree, Inputs{Type: data_types.Put, Style: data_types.American, Underlying: 50, Strike: 50, Expiry: 5.0 / 12, Rate: 0.1, Volatility: 0.4}},
+	}
+	for _, tt := range tests {
+		price, err := tt.pricer.Price(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ImpliedVolatility(tt.pricer, tt.in, price)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if math.Abs(got-tt.in.Volatility) > 1e-6 {
+			t.Errorf("%s: got %.8f, want %.8f", tt.name, got, tt.in.Volatility)
+		}
+	}
+}
+
+func TestImpliedVolatilityBelowIntrinsic(t *testing.T) {
+	in := Inputs{Type: data_types.Call, Underlying: 100, Strike: 80, Expiry: 1}
+	if _, err := ImpliedVolatility(BlackScholesMerton{}, in, 10); !errors.Is(err, ErrNoImpliedVolatility) {
+		t.Errorf("got %v, want ErrNoImpliedVolatility", err)
+	}
+}
@@ -0,0 +1,76 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	data_types "goquant/pkg/data"
+)
+
+// MonteCarlo prices European options by simulating the terminal price under geometric Brownian motion.
+// Every call with the same Seed uses the same random numbers, so finite-difference Greeks of a
+// MonteCarlo pricer are computed with common random numbers.
+type MonteCarlo struct {
+	Paths int
+	Seed  int64
+	// Antithetic pairs every draw with its negative to reduce the variance.
+	Antithetic bool
+}
+
+// NewMonteCarlo creates a MonteCarlo pricer with antithetic variates.
+//
+// Parameters:
+// - paths: The number of simulated paths, at least 1.
+// - seed: The seed of the random number generator.
+// Returns a pointer to the newly created MonteCarlo, or an error if the number of paths is invalid.
+func NewMonteCarlo(paths int, seed int64) (*MonteCarlo, error) {
+	if paths < 1 {
+		return nil, fmt.Errorf("paths must be at least 1, got %d", paths)
+	}
+	return &MonteCarlo{Paths: paths, Seed: seed, Antithetic: true}, nil
+}
+
+// Price returns the Monte Carlo estimate of the value of a European option.
+func (mc *MonteCarlo) Price(in Inputs) (float64, error) {
+	price, _, err := mc.PriceWithError(in)
+	return price, err
+}
+
+// PriceWithError returns the Monte Carlo estimate of the value of a European option and its standard error.
+func (mc *MonteCarlo) PriceWithError(in Inputs) (float64, float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, 0, err
+	}
+	if in.Style == data_types.American {
+		return 0, 0, ErrAmericanUnsupported
+	}
+	if mc.Paths < 1 {
+		return 0, 0, fmt.Errorf("paths must be at least 1, got %d", mc.Paths)
+	}
+
+	drift := (in.Rate - in.Dividend - in.Volatility*in.Volatility/2) * in.Expiry
+	diffusion := in.Volatility * math.Sqrt(in.Expiry)
+	discount := math.Exp(-in.Rate * in.Expiry)
+
+	rng := rand.New(rand.NewSource(mc.Seed))
+	sum, sumSquares := 0.0, 0.0
+	for i := 0; i < mc.Paths; i++ {
+		z := rng.NormFloat64()
+		payoff := in.intrinsic(in.Underlying * math.Exp(drift+diffusion*z))
+		if mc.Antithetic {
+			payoff = (payoff + in.intrinsic(in.Underlying*math.Exp(drift-diffusion*z))) / 2
+		}
+		sum += payoff
+		sumSquares += payoff * payoff
+	}
+
+	n := float64(mc.Paths)
+	mean := sum / n
+	stdErr := 0.0
+	if mc.Paths > 1 {
+		variance := (sumSquares - n*mean*mean) / (n - 1)
+		stdErr = math.Sqrt(math.Max(variance, 0) / n)
+	}
+	return discount * mean, discount * stdErr, nil
+}
@@ -0,0 +1,55 @@
+package options
+
+import (
+	"math"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+func TestMonteCarloMatchesBlackScholesMerton(t *testing.T) {
+	mc, err := NewMonteCarlo(200000, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []data_types.OptionType{data_types.Call, data_types.Put} {
+		in := Inputs{Type: kind, Underlying: 42, Strike: 40, Expiry: 0.5, Rate: 0.1, Volatility: 0.2}
+		want, _ := BlackScholesMerton{}.Price(in)
+		got, stdErr, err := mc.PriceWithError(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-want) > 4*stdErr {
+			t.Errorf("%v: got %.4f ± %.4f, want %.4f", kind, got, stdErr, want)
+		}
+	}
+}
+
+func TestFiniteDifferenceGreeksMatchAnalytic(t *testing.T) {
+	in := Inputs{Type: data_types.Put, Underlying: 49, Strike: 50, Expiry: 0.3846, Rate: 0.05, Dividend: 0.01, Volatility: 0.2}
+	want, err := BlackScholesMerton{}.Greeks(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bumps := DefaultBumps()
+	bumps.Time = 1e-5
+	got, err := FiniteDifferenceGreeks(BlackScholesMerton{}, in, bumps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"delta", got.Delta, want.Delta},
+		{"gamma", got.Gamma, want.Gamma},
+		{"vega", got.Vega, want.Vega},
+		{"theta", got.Theta, want.Theta},
+		{"rho", got.Rho, want.Rho},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-3*math.Max(1, math.Abs(tt.want)) {
+			t.Errorf("%s: got %.6f, want %.6f", tt.name, tt.got, tt.want)
+		}
+	}
+}
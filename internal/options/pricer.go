@@ -0,0 +1,111 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	data_types "goquant/pkg/data"
+)
+
+// Inputs holds everything a Pricer needs to value an option.
+type Inputs struct {
+	Type  data_types.OptionType
+	Style data_types.ExerciseStyle
+	// Underlying is the spot price, or the forward price for Black76.
+	Underlying float64
+	Strike     float64
+	// Expiry is the time to expiry in years.
+	Expiry float64
+	// Rate is the continuously compounded risk-free rate.
+	Rate float64
+	// Dividend is the continuously compounded dividend yield, or the foreign rate for currencies.
+	Dividend   float64
+	Volatility float64
+}
+
+// NewInputs creates Inputs for a contract at a point in time.
+//
+// Parameters:
+// - contract: The option contract.
+// - timestamp: The Unix timestamp of the valuation, e.g. the Timestamp of the underlying's MarketData.
+// - underlying: The spot price, or the forward price for Black76.
+// - rate: The continuously compounded risk-free rate.
+// - dividend: The continuously compounded dividend yield.
+// - volatility: The annualized volatility.
+// Returns the Inputs.
+func NewInputs(contract data_types.OptionContract, timestamp int64, underlying, rate, dividend, volatility float64) Inputs {
+	return Inputs{
+		Type:       contract.Type,
+		Style:      contract.Style,
+		Underlying: underlying,
+		Strike:     contract.Strike,
+		Expiry:     contract.TimeToExpiry(timestamp),
+		Rate:       rate,
+		Dividend:   dividend,
+		Volatility: volatility,
+	}
+}
+
+// validate checks that the inputs are finite and in range.
+func (in Inputs) validate() error {
+	for name, v := range map[string]float64{
+		"underlying": in.Underlying, "strike": in.Strike, "expiry": in.Expiry,
+		"rate": in.Rate, "dividend": in.Dividend, "volatility": in.Volatility,
+	} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%s must be finite, got %v", name, v)
+		}
+	}
+	if in.Underlying <= 0 {
+		return fmt.Errorf("underlying must be positive, got %v", in.Underlying)
+	}
+	if in.Strike <= 0 {
+		return fmt.Errorf("strike must be positive, got %v", in.Strike)
+	}
+	if in.Expiry < 0 {
+		return fmt.Errorf("expiry must be non-negative, got %v", in.Expiry)
+	}
+	if in.Volatility < 0 {
+		return fmt.Errorf("volatility must be non-negative, got %v", in.Volatility)
+	}
+	if in.Type != data_types.Call && in.Type != data_types.Put {
+		return fmt.Errorf("unknown option type: %v", in.Type)
+	}
+	if in.Style != data_types.European && in.Style != data_types.American {
+		return fmt.Errorf("unknown exercise style: %v", in.Style)
+	}
+	return nil
+}
+
+// intrinsic returns the exercise value of the option at the given underlying price.
+func (in Inputs) intrinsic(underlying float64) float64 {
+	if in.Type == data_types.Put {
+		return math.Max(in.Strike-underlying, 0)
+	}
+	return math.Max(underlying-in.Strike, 0)
+}
+
+// Pricer values an option.
+type Pricer interface {
+	Price(in Inputs) (float64, error)
+}
+
+// Greeks are the sensitivities of an option price. Vega and Rho are per unit (1.0 = 100%) change of
+// volatility and rate, and Theta is the change of value per year as time passes.
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+	Rho   float64
+}
+
+// AnalyticGreeker is implemented by pricers with closed-form Greeks.
+type AnalyticGreeker interface {
+	Pricer
+	Greeks(in Inputs) (Greeks, error)
+}
+
+// ErrAmericanUnsupported is returned by pricers that can only value European options.
+var ErrAmericanUnsupported = errors.New("pricer does not support American exercise")
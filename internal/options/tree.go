@@ -0,0 +1,129 @@
+package options
+
+import (
+	"fmt"
+	"math"
+
+	data_types "goquant/pkg/data"
+)
+
+// BinomialTree prices European and American options on a Cox-Ross-Rubinstein binomial tree centered
+// on the forward, so the risk-neutral probabilities stay valid for any volatility and carry.
+type BinomialTree struct {
+	Steps int
+}
+
+// NewBinomialTree creates a BinomialTree with the given number of time steps, at least 1.
+func NewBinomialTree(steps int) (*BinomialTree, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	return &BinomialTree{Steps: steps}, nil
+}
+
+// Price returns the value of the option by backward induction, checking early exercise at every node
+// of American options.
+func (bt *BinomialTree) Price(in Inputs) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	if bt.Steps < 1 {
+		return 0, fmt.Errorf("steps must be at least 1, got %d", bt.Steps)
+	}
+	if in.Expiry == 0 || in.Volatility == 0 {
+		return withoutTimeValue(in)
+	}
+
+	dt := in.Expiry / float64(bt.Steps)
+	drift := (in.Rate - in.Dividend) * dt
+	dx := in.Volatility * math.Sqrt(dt)
+	// With u = g e^dx and d = g e^-dx the growth g cancels out of the probability
+	p := (1 - math.Exp(-dx)) / (math.Exp(dx) - math.Exp(-dx))
+	discount := math.Exp(-in.Rate * dt)
+
+	// Node j at step n has log price x0 + n drift + (2j - n) dx
+	price := func(step, j int) float64 {
+		return in.Underlying * math.Exp(float64(step)*drift+float64(2*j-step)*dx)
+	}
+	values := make([]float64, bt.Steps+1)
+	for j := range values {
+		values[j] = in.intrinsic(price(bt.Steps, j))
+	}
+	for step := bt.Steps - 1; step >= 0; step-- {
+		for j := 0; j <= step; j++ {
+			values[j] = discount * (p*values[j+1] + (1-p)*values[j])
+			if in.Style == data_types.American {
+				values[j] = math.Max(values[j], in.intrinsic(price(step, j)))
+			}
+		}
+	}
+	return values[0], nil
+}
+
+// TrinomialTree prices European and American options on a trinomial tree in log price centered on the
+// drift, which converges more smoothly than a binomial tree with the same number of steps.
+type TrinomialTree struct {
+	Steps int
+}
+
+// NewTrinomialTree creates a TrinomialTree with the given number of time steps, at least 1.
+func NewTrinomialTree(steps int) (*TrinomialTree, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	return &TrinomialTree{Steps: steps}, nil
+}
+
+// Price returns the value of the option by backward induction, checking early exercise at every node
+// of American options.
+func (tt *TrinomialTree) Price(in Inputs) (float64, error) {
+	if err := in.validate(); err != nil {
+		return 0, err
+	}
+	if tt.Steps < 1 {
+		return 0, fmt.Errorf("steps must be at least 1, got %d", tt.Steps)
+	}
+	if in.Expiry == 0 || in.Volatility == 0 {
+		return withoutTimeValue(in)
+	}
+
+	dt := in.Expiry / float64(tt.Steps)
+	drift := (in.Rate - in.Dividend - in.Volatility*in.Volatility/2) * dt
+	// With dx = σ√(3dt) the moments match for probabilities 1/6, 2/3 and 1/6
+	dx := in.Volatility * math.Sqrt(3*dt)
+	const pUp, pMid, pDown = 1.0 / 6, 2.0 / 3, 1.0 / 6
+	discount := math.Exp(-in.Rate * dt)
+
+	// Node j at step n has log price x0 + n drift + (j - n) dx
+	price := func(step, j int) float64 {
+		return in.Underlying * math.Exp(float64(step)*drift+float64(j-step)*dx)
+	}
+	values := make([]float64, 2*tt.Steps+1)
+	for j := range values {
+		values[j] = in.intrinsic(price(tt.Steps, j))
+	}
+	for step := tt.Steps - 1; step >= 0; step-- {
+		for j := 0; j <= 2*step; j++ {
+			values[j] = discount * (pUp*values[j+2] + pMid*values[j+1] + pDown*values[j])
+			if in.Style == data_types.American {
+				values[j] = math.Max(values[j], in.intrinsic(price(step, j)))
+			}
+		}
+	}
+	return values[0], nil
+}
+
+// withoutTimeValue prices an option at expiry or with zero volatility, where the underlying follows its
+// forward. American options are worth at least their immediate exercise value.
+func withoutTimeValue(in Inputs) (float64, error) {
+	european := in
+	european.Style = data_types.European
+	price, err := BlackScholesMerton{}.Price(european)
+	if err != nil {
+		return 0, err
+	}
+	if in.Style == data_types.American {
+		price = math.Max(price, in.intrinsic(in.Underlying))
+	}
+	return price, nil
+}
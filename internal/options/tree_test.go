@@ -0,0 +1,72 @@
+package options
+
+import (
+	"math"
+	"testing"
+
+	data_types "goquant/pkg/data"
+)
+
+func TestTreesConvergeToBlackScholesMerton(t *testing.T) {
+	binomial, _ := NewBinomialTree(1000)
+	trinomial, _ := NewTrinomialTree(500)
+	for _, kind := range []data_types.OptionType{data_types.Call, data_types.Put} {
+		in := Inputs{Type: kind, Style: data_types.European, Underlying: 42, Strike: 40, Expiry: 0.5, Rate: 0.1, Dividend: 0.03, Volatility: 0.2}
+		want, err := BlackScholesMerton{}.Price(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pricer := range []Pricer{binomial, trinomial} {
+			got, err := pricer.Price(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-want) > 0.005 {
+				t.Errorf("%T %v: got %.4f, want %.4f", pricer, kind, got, want)
+			}
+		}
+	}
+}
+
+func TestAmericanPutHull(t *testing.T) {
+	// Hull, Options, Futures, and Other Derivatives, Example 21.1, whose value converges to about 4.28
+	in := Inputs{Type: data_types.Put, Style: data_types.American, Underlying: 50, Strike: 50, Expiry: 5.0 / 12, Rate: 0.1, Volatility: 0.4}
+	binomial, _ := NewBinomialTree(500)
+	trinomial, _ := NewTrinomialTree(500)
+	for _, pricer := range []Pricer{binomial, trinomial} {
+		got, err := pricer.Price(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-4.28) > 0.01 {
+			t.Errorf("%T: got %.4f, want 4.28", pricer, got)
+		}
+	}
+
+}
+
+func TestAmericanCallWithoutDividendsIsEuropean(t *testing.T) {
+	in := Inputs{Type: data_types.Call, Style: data_types.American, Underlying: 100, Strike: 95, Expiry: 1, Rate: 0.05, Volatility: 0.3}
+	tree, _ := NewBinomialTree(400)
+	american, err := tree.Price(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Style = data_types.European
+	european, err := tree.Price(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(american-european) > 1e-9 {
+		t.Errorf("american %.6f, european %.6f", american, european)
+	}
+}
+
+func TestNewTreeRejectsSteps(t *testing.T) {
+	if _, err := NewBinomialTree(0); err == nil {
+		t.Error("binomial tree with 0 steps: expected an error")
+	}
+	if _, err := NewTrinomialTree(-1); err == nil {
+		t.Error("trinomial tree with -1 steps: expected an error")
+	}
+}
@@ -0,0 +1,83 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Constraints bounds the weight of every asset. Weights always sum to one.
+type Constraints struct {
+	Lower []float64
+	Upper []float64
+}
+
+// LongOnly returns constraints that allow any non-negative weights for n assets.
+func LongOnly(n int) Constraints {
+	return Bounds(n, 0, 1)
+}
+
+// Bounds returns constraints with the same lower and upper bound for n assets.
+func Bounds(n int, lower, upper float64) Constraints {
+	c := Constraints{Lower: make([]float64, n), Upper: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		c.Lower[i] = lower
+		c.Upper[i] = upper
+	}
+	return c
+}
+
+// validate checks that the constraints cover n assets and admit a fully invested portfolio.
+func (c Constraints) validate(n int) error {
+	if len(c.Lower) != n || len(c.Upper) != n {
+		return fmt.Errorf("constraints cover %d and %d assets, expected %d", len(c.Lower), len(c.Upper), n)
+	}
+	lowerSum, upperSum := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		if math.IsNaN(c.Lower[i]) || math.IsNaN(c.Upper[i]) || c.Lower[i] > c.Upper[i] {
+			return fmt.Errorf("invalid bounds for asset %d: [%v, %v]", i, c.Lower[i], c.Upper[i])
+		}
+		lowerSum += c.Lower[i]
+		upperSum += c.Upper[i]
+	}
+	if lowerSum > 1+1e-12 || upperSum < 1-1e-12 {
+		return errors.New("constraints do not admit weights summing to one")
+	}
+	return nil
+}
+
+// project returns the Euclidean projection of v onto {w : sum(w) = 1, Lower <= w <= Upper}.
+// The projection has the form clip(v - tau) and tau is found by bisection.
+func (c Constraints) project(v []float64) []float64 {
+	clipped := func(tau float64) ([]float64, float64) {
+		w := make([]float64, len(v))
+		total := 0.0
+		for i := range v {
+			w[i] = math.Min(math.Max(v[i]-tau, c.Lower[i]), c.Upper[i])
+			total += w[i]
+		}
+		return w, total
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i := range v {
+		lo = math.Min(lo, v[i]-c.Upper[i])
+		hi = math.Max(hi, v[i]-c.Lower[i])
+	}
+
+	var w []float64
+	for iter := 0; iter < 100; iter++ {
+		tau := (lo + hi) / 2
+		var total float64
+		w, total = clipped(tau)
+		if math.Abs(total-1) < 1e-12 {
+			break
+		}
+		if total > 1 {
+			lo = tau
+		} else {
+			hi = tau
+		}
+	}
+	return w
+}
@@ -0,0 +1,282 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// maxIterations and tolerance control the projected gradient solver.
+const (
+	maxIterations = 20000
+	tolerance     = 1e-12
+)
+
+// FrontierPoint is a portfolio on the efficient frontier.
+type FrontierPoint struct {
+	Weights    []float64
+	Return     float64
+	Volatility float64
+}
+
+// MeanVariance returns the weights that minimize w'Σw - riskAversion * μ'w subject to the constraints.
+//
+// Parameters:
+// - mu: The expected return of every asset.
+// - cov: The covariance matrix of the asset returns.
+// - riskAversion: The trade-off between return and variance; zero yields the minimum variance portfolio.
+// - cons: The weight bounds of every asset.
+// Returns the optimal weights, or an error if the inputs are inconsistent.
+func MeanVariance(mu []float64, cov mat.Symmetric, riskAversion float64, cons Constraints) ([]float64, error) {
+	if err := validateInputs(mu, cov, cons); err != nil {
+		return nil, err
+	}
+	if riskAversion < 0 || math.IsNaN(riskAversion) {
+		return nil, fmt.Errorf("risk aversion must be non-negative, got %v", riskAversion)
+	}
+	return solveQuadratic(mu, cov, riskAversion, cons), nil
+}
+
+// MinimumVariance returns the weights with the lowest variance subject to the constraints.
+func MinimumVariance(cov mat.Symmetric, cons Constraints) ([]float64, error) {
+	return MeanVariance(make([]float64, cov.Symmetric()), cov, 0, cons)
+}
+
+// TargetReturn returns the minimum variance weights whose expected return is at least target,
+// or an error if no admissible portfolio reaches the target.
+func TargetReturn(mu []float64, cov mat.Symmetric, target float64, cons Constraints) ([]float64, error) {
+	if err := validateInputs(mu, cov, cons); err != nil {
+		return nil, err
+	}
+
+	minVar := solveQuadratic(mu, cov, 0, cons)
+	if dot(mu, minVar) >= target {
+		return minVar, nil
+	}
+	maxRet := maxReturn(mu, cons)
+	if dot(mu, maxRet) < target-1e-10 {
+		return nil, fmt.Errorf("target return %v exceeds the maximum attainable return %v", target, dot(mu, maxRet))
+	}
+
+	// The expected return grows with the risk aversion, so bisect on it
+	lo, hi := 0.0, 1.0
+	for dot(mu, solveQuadratic(mu, cov, hi, cons)) < target && hi < 1e12 {
+		hi *= 10
+	}
+	weights := maxRet
+	for iter := 0; iter < 100; iter++ {
+		mid := (lo + hi) / 2
+		w := solveQuadratic(mu, cov, mid, cons)
+		if dot(mu, w) >= target {
+			hi = mid
+			weights = w
+		} else {
+			lo = mid
+		}
+		if hi-lo < 1e-10*hi {
+			break
+		}
+	}
+	return weights, nil
+}
+
+// EfficientFrontier returns the given number of portfolios with target returns evenly spaced between
+// the minimum variance portfolio and the maximum return portfolio.
+func EfficientFrontier(mu []float64, cov mat.Symmetric, cons Constraints, points int) ([]FrontierPoint, error) {
+	if err := validateInputs(mu, cov, cons); err != nil {
+		return nil, err
+	}
+	if points < 2 {
+		return nil, fmt.Errorf("need at least 2 frontier points, got %d", points)
+	}
+
+	lowest := dot(mu, solveQuadratic(mu, cov, 0, cons))
+	highest := dot(mu, maxReturn(mu, cons))
+
+	frontier := make([]FrontierPoint, 0, points)
+	for i := 0; i < points; i++ {
+		target := lowest + (highest-lowest)*float64(i)/float64(points-1)
+		w, err := TargetReturn(mu, cov, target, cons)
+		if err != nil {
+			return nil, err
+		}
+		frontier = append(frontier, FrontierPoint{
+			Weights:    w,
+			Return:     dot(mu, w),
+			Volatility: math.Sqrt(quadForm(cov, w)),
+		})
+	}
+	return frontier, nil
+}
+
+// MaximumSharpe returns the weights with the highest Sharpe ratio (μ'w - riskFree) / sqrt(w'Σw) subject to
+// the constraints. The tangency portfolio lies on the frontier, so the search runs over the risk aversion.
+func MaximumSharpe(mu []float64, cov mat.Symmetric, riskFree float64, cons Constraints) ([]float64, error) {
+	if err := validateInputs(mu, cov, cons); err != nil {
+		return nil, err
+	}
+
+	sharpe := func(w []float64) float64 {
+		vol := math.Sqrt(quadForm(cov, w))
+		if vol == 0 {
+			return math.Inf(-1)
+		}
+		return (dot(mu, w) - riskFree) / vol
+	}
+
+	// Coarse grid over log10 of the risk aversion, then golden section refinement around the best point
+	best, bestLog := solveQuadratic(mu, cov, 0, cons), math.Inf(-1)
+	bestSharpe := sharpe(best)
+	for logRA := -4.0; logRA <= 6; logRA += 0.5 {
+		w := solveQuadratic(mu, cov, math.Pow(10, logRA), cons)
+		if s := sharpe(w); s > bestSharpe {
+			best, bestSharpe, bestLog = w, s, logRA
+		}
+	}
+	if math.IsInf(bestLog, -1) {
+		return best, nil
+	}
+
+	golden := (math.Sqrt(5) - 1) / 2
+	a, b := bestLog-0.5, bestLog+0.5
+	for iter := 0; iter < 40; iter++ {
+		c := b - golden*(b-a)
+		d := a + golden*(b-a)
+		wc := solveQuadratic(mu, cov, math.Pow(10, c), cons)
+		wd := solveQuadratic(mu, cov, math.Pow(10, d), cons)
+		if sharpe(wc) > sharpe(wd) {
+			b = d
+		} else {
+			a = c
+		}
+	}
+	if w := solveQuadratic(mu, cov, math.Pow(10, (a+b)/2), cons); sharpe(w) > bestSharpe {
+		best = w
+	}
+	return best, nil
+}
+
+// solveQuadratic minimizes w'Σw - riskAversion * μ'w over the constraints with accelerated projected gradient descent.
+func solveQuadratic(mu []float64, cov mat.Symmetric, riskAversion float64, cons Constraints) []float64 {
+	n := len(mu)
+
+	// The gradient 2Σw - λμ is Lipschitz with constant 2 * largest eigenvalue of Σ
+	var eig mat.EigenSym
+	lipschitz := 0.0
+	if eig.Factorize(cov, false) {
+		values := eig.Values(nil)
+		lipschitz = 2 * values[len(values)-1]
+	}
+	if lipschitz <= 0 {
+		lipschitz = 2 * mat.Trace(cov)
+	}
+	if lipschitz <= 0 {
+		lipschitz = 1
+	}
+	step := 1 / lipschitz
+
+	start := make([]float64, n)
+	for i := range start {
+		start[i] = 1 / float64(n)
+	}
+	w := cons.project(start)
+	y := append([]float64(nil), w...)
+	t := 1.0
+
+	gradient := make([]float64, n)
+	candidate := make([]float64, n)
+	for iter := 0; iter < maxIterations; iter++ {
+		sigmaY := mat.NewVecDense(n, nil)
+		sigmaY.MulVec(cov, mat.NewVecDense(n, y))
+		for i := range gradient {
+			gradient[i] = 2*sigmaY.AtVec(i) - riskAversion*mu[i]
+			candidate[i] = y[i] - step*gradient[i]
+		}
+		next := cons.project(candidate)
+
+		nextT := (1 + math.Sqrt(1+4*t*t)) / 2
+		change := 0.0
+		for i := range y {
+			y[i] = next[i] + (t-1)/nextT*(next[i]-w[i])
+			change += (next[i] - w[i]) * (next[i] - w[i])
+		}
+		w, t = next, nextT
+		if change < tolerance*tolerance {
+			break
+		}
+	}
+	return w
+}
+
+// maxReturn returns the admissible weights with the highest expected return by filling the best assets up to their bounds.
+func maxReturn(mu []float64, cons Constraints) []float64 {
+	n := len(mu)
+	w := append([]float64(nil), cons.Lower...)
+	remaining := 1.0
+	for _, l := range cons.Lower {
+		remaining -= l
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && mu[order[j]] > mu[order[j-1]]; j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+	for _, i := range order {
+		add := math.Min(remaining, cons.Upper[i]-w[i])
+		w[i] += add
+		remaining -= add
+	}
+	return w
+}
+
+// validateInputs checks that the expected returns, covariance and constraints have matching dimensions.
+func validateInputs(mu []float64, cov mat.Symmetric, cons Constraints) error {
+	if cov == nil {
+		return errors.New("covariance matrix must not be nil")
+	}
+	n := cov.Symmetric()
+	if n == 0 {
+		return errors.New("covariance matrix is empty")
+	}
+	if len(mu) != n {
+		return fmt.Errorf("got %d expected returns for %d assets", len(mu), n)
+	}
+	for i := 0; i < n; i++ {
+		if math.IsNaN(mu[i]) {
+			return fmt.Errorf("expected return of asset %d is NaN", i)
+		}
+		for j := 0; j <= i; j++ {
+			if math.IsNaN(cov.At(i, j)) {
+				return fmt.Errorf("covariance entry (%d, %d) is NaN", i, j)
+			}
+		}
+	}
+	return cons.validate(n)
+}
+
+// dot returns the inner product of two vectors.
+func dot(a, b []float64) float64 {
+	total := 0.0
+	for i := range a {
+		total += a[i] * b[i]
+	}
+	return total
+}
+
+// quadForm returns w'Σw.
+func quadForm(cov mat.Symmetric, w []float64) float64 {
+	v := mat.NewVecDense(len(w), w)
+	return mat.Inner(v, cov, v)
+}
+
+// Volatility returns the standard deviation of the portfolio with the given weights.
+func Volatility(cov mat.Symmetric, w []float64) float64 {
+	return math.Sqrt(quadForm(cov, w))
+}
@@ -0,0 +1,135 @@
+package portfolio
+
+import (
+	"fmt"
+	"goquant/internal/covariance"
+
+	"github.com/go-gota/gota/dataframe"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Method selects the optimizer used by a RebalancingStrategy.
+type Method int
+
+const (
+	// MinimumVarianceMethod minimizes the portfolio variance.
+	MinimumVarianceMethod Method = iota
+	// MeanVarianceMethod trades off expected return and variance with the strategy's RiskAversion.
+	MeanVarianceMethod
+	// MaximumSharpeMethod maximizes the Sharpe ratio against the strategy's RiskFreeRate.
+	MaximumSharpeMethod
+	// EqualRiskContributionMethod equalizes the risk contributions.
+	EqualRiskContributionMethod
+	// HierarchicalRiskParityMethod uses hierarchical risk parity.
+	HierarchicalRiskParityMethod
+)
+
+// RebalancingStrategy computes target weights from the trailing returns of every ticker. Its Allocate
+// method is a backtest_types.AllocationFunction and can be passed to backtest.BacktestPortfolio.
+type RebalancingStrategy struct {
+	Method Method
+	// Lookback is the number of trailing returns used to estimate the moments.
+	Lookback int
+	// Constraints bound the weights of the optimizer methods, in alphabetical ticker order.
+	// Nil bounds mean long-only. Risk parity methods are always long-only.
+	Constraints *Constraints
+	// Estimator estimates the covariance matrix. Nil means the sample covariance.
+	Estimator    covariance.Estimator
+	RiskAversion float64
+	RiskFreeRate float64
+}
+
+// NewRebalancingStrategy creates a new RebalancingStrategy with long-only constraints.
+//
+// Parameters:
+// - method: The optimizer used to compute the weights.
+// - lookback: The number of trailing returns used to estimate the moments, at least 2.
+// Returns a pointer to the newly created RebalancingStrategy, or an error if the lookback is too short.
+func NewRebalancingStrategy(method Method, lookback int) (*RebalancingStrategy, error) {
+	if lookback < 2 {
+		return nil, fmt.Errorf("lookback must be at least 2, got %d", lookback)
+	}
+	if method < MinimumVarianceMethod || method > HierarchicalRiskParityMethod {
+		return nil, fmt.Errorf("unknown method: %d", method)
+	}
+	return &RebalancingStrategy{Method: method, Lookback: lookback, RiskAversion: 1}, nil
+}
+
+// Allocate returns the target weights per ticker. Until enough history is available, or if the
+// optimizer fails, it returns equal weights.
+func (rs *RebalancingStrategy) Allocate(history map[string]dataframe.DataFrame) map[string]float64 {
+	tickers := sortedKeys(history)
+	if len(tickers) == 0 {
+		return nil
+	}
+	equal := make(map[string]float64, len(tickers))
+	for _, ticker := range tickers {
+		equal[ticker] = 1 / float64(len(tickers))
+	}
+
+	returns := trailingReturns(history, tickers, rs.Lookback)
+	if returns == nil {
+		return equal
+	}
+
+	weights, err := rs.weights(returns)
+	if err != nil {
+		return equal
+	}
+
+	allocation := make(map[string]float64, len(tickers))
+	for j, ticker := range tickers {
+		allocation[ticker] = weights[j]
+	}
+	return allocation
+}
+
+// weights runs the configured optimizer on the returns.
+func (rs *RebalancingStrategy) weights(returns *mat.Dense) ([]float64, error) {
+	mu, cov := SampleMoments(returns)
+	if rs.Estimator != nil {
+		estimated, err := rs.Estimator.Estimate(returns)
+		if err != nil {
+			return nil, err
+		}
+		cov = estimated
+	}
+
+	cons := LongOnly(len(mu))
+	if rs.Constraints != nil {
+		cons = *rs.Constraints
+	}
+
+	switch rs.Method {
+	case MeanVarianceMethod:
+		return MeanVariance(mu, cov, rs.RiskAversion, cons)
+	case MaximumSharpeMethod:
+		return MaximumSharpe(mu, cov, rs.RiskFreeRate, cons)
+	case EqualRiskContributionMethod:
+		return EqualRiskContribution(cov, nil)
+	case HierarchicalRiskParityMethod:
+		return HierarchicalRiskParity(cov)
+	default:
+		return MinimumVariance(cov, cons)
+	}
+}
+
+// trailingReturns returns the last lookback close-to-close returns of the aligned dataframes,
+// or nil if there is not enough history.
+func trailingReturns(history map[string]dataframe.DataFrame, tickers []string, lookback int) *mat.Dense {
+	returns := mat.NewDense(lookback, len(tickers), nil)
+	for j, ticker := range tickers {
+		closes := history[ticker].Col("Close").Float()
+		if len(closes) < lookback+1 {
+			return nil
+		}
+		closes = closes[len(closes)-lookback-1:]
+		for i := 1; i < len(closes); i++ {
+			if closes[i-1] == 0 {
+				return nil
+			}
+			returns.Set(i-1, j, closes[i]/closes[i-1]-1)
+		}
+	}
+	return returns
+}
@@ -0,0 +1,87 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	data_types "goquant/pkg/data"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// ReturnsMatrix builds a matrix of simple close-to-close returns from the market data of several tickers.
+// The series are aligned on the timestamps present for every ticker.
+//
+// Parameters:
+// - data: The market data per ticker.
+// Returns:
+// - *mat.Dense: The returns, one row per aligned period and one column per ticker.
+// - []string: The tickers in column order, sorted alphabetically.
+// - []int64: The timestamp at the end of every period.
+// - error: An error if there are fewer than two tickers or fewer than two common timestamps.
+func ReturnsMatrix(data map[string][]data_types.MarketData) (*mat.Dense, []string, []int64, error) {
+	if len(data) < 2 {
+		return nil, nil, nil, fmt.Errorf("need at least 2 tickers, got %d", len(data))
+	}
+
+	tickers := make([]string, 0, len(data))
+	for ticker := range data {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	// Closing prices by timestamp for each ticker
+	closes := make([]map[int64]float64, len(tickers))
+	counts := make(map[int64]int)
+	for i, ticker := range tickers {
+		closes[i] = make(map[int64]float64, len(data[ticker]))
+		for _, d := range data[ticker] {
+			if _, seen := closes[i][d.Timestamp]; !seen {
+				counts[d.Timestamp]++
+			}
+			closes[i][d.Timestamp] = d.Close
+		}
+	}
+
+	var timestamps []int64
+	for ts, count := range counts {
+		if count == len(tickers) {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	sort.Slice(timestamps, func(a, b int) bool { return timestamps[a] < timestamps[b] })
+	if len(timestamps) < 2 {
+		return nil, nil, nil, errors.New("need at least 2 common timestamps")
+	}
+
+	returns := mat.NewDense(len(timestamps)-1, len(tickers), nil)
+	for t := 1; t < len(timestamps); t++ {
+		for j := range tickers {
+			prev := closes[j][timestamps[t-1]]
+			if prev == 0 {
+				return nil, nil, nil, fmt.Errorf("zero price for %s at %d", tickers[j], timestamps[t-1])
+			}
+			returns.Set(t-1, j, closes[j][timestamps[t]]/prev-1)
+		}
+	}
+
+	return returns, tickers, timestamps[1:], nil
+}
+
+// SampleMoments returns the mean return of every column and the sample covariance matrix of the returns.
+func SampleMoments(returns mat.Matrix) ([]float64, *mat.SymDense) {
+	rows, cols := returns.Dims()
+	mu := make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			mu[j] += returns.At(i, j)
+		}
+		mu[j] /= float64(rows)
+	}
+
+	cov := mat.NewSymDense(cols, nil)
+	stat.CovarianceMatrix(cov, returns, nil)
+	return mu, cov
+}
@@ -0,0 +1,201 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// EqualRiskContribution returns the long-only weights whose risk contributions w_i * (Σw)_i are proportional
+// to the budgets. Nil budgets give every asset the same contribution.
+//
+// It solves min ½ y'Σy - Σ b_i ln(y_i) by cyclical coordinate descent and normalizes y to sum to one.
+func EqualRiskContribution(cov mat.Symmetric, budgets []float64) ([]float64, error) {
+	if cov == nil || cov.Symmetric() == 0 {
+		return nil, errors.New("covariance matrix is empty")
+	}
+	n := cov.Symmetric()
+
+	if budgets == nil {
+		budgets = make([]float64, n)
+		for i := range budgets {
+			budgets[i] = 1 / float64(n)
+		}
+	}
+	if len(budgets) != n {
+		return nil, fmt.Errorf("got %d budgets for %d assets", len(budgets), n)
+	}
+	total := 0.0
+	for i, b := range budgets {
+		if b <= 0 || math.IsNaN(b) {
+			return nil, fmt.Errorf("budget %d must be positive, got %v", i, b)
+		}
+		total += b
+	}
+	for i := 0; i < n; i++ {
+		if cov.At(i, i) <= 0 {
+			return nil, fmt.Errorf("asset %d has no variance", i)
+		}
+	}
+
+	// Start from inverse volatility
+	y := make([]float64, n)
+	for i := range y {
+		y[i] = 1 / math.Sqrt(cov.At(i, i))
+	}
+
+	for iter := 0; iter < maxIterations; iter++ {
+		change := 0.0
+		for i := 0; i < n; i++ {
+			// Solve Σ_ii y_i² + c y_i - b_i = 0 for the positive root
+			c := 0.0
+			for j := 0; j < n; j++ {
+				if j != i {
+					c += cov.At(i, j) * y[j]
+				}
+			}
+			a := cov.At(i, i)
+			next := (-c + math.Sqrt(c*c+4*a*budgets[i]/total)) / (2 * a)
+			change = math.Max(change, math.Abs(next-y[i])/y[i])
+			y[i] = next
+		}
+		if change < 1e-12 {
+			break
+		}
+	}
+
+	sum := 0.0
+	for _, v := range y {
+		sum += v
+	}
+	for i := range y {
+		y[i] /= sum
+	}
+	return y, nil
+}
+
+// RiskContributions returns the share of the portfolio variance contributed by every asset.
+func RiskContributions(cov mat.Symmetric, w []float64) []float64 {
+	n := len(w)
+	sigmaW := mat.NewVecDense(n, nil)
+	sigmaW.MulVec(cov, mat.NewVecDense(n, w))
+	variance := quadForm(cov, w)
+
+	contributions := make([]float64, n)
+	for i := range w {
+		if variance > 0 {
+			contributions[i] = w[i] * sigmaW.AtVec(i) / variance
+		}
+	}
+	return contributions
+}
+
+// HierarchicalRiskParity returns the weights of López de Prado's hierarchical risk parity: assets are
+// clustered by correlation distance with single linkage, ordered so that similar assets are adjacent,
+// and capital is split by recursive bisection in inverse proportion to the variance of each half.
+func HierarchicalRiskParity(cov mat.Symmetric) ([]float64, error) {
+	if cov == nil || cov.Symmetric() == 0 {
+		return nil, errors.New("covariance matrix is empty")
+	}
+	n := cov.Symmetric()
+	for i := 0; i < n; i++ {
+		if cov.At(i, i) <= 0 {
+			return nil, fmt.Errorf("asset %d has no variance", i)
+		}
+	}
+
+	order := quasiDiagonalOrder(cov)
+
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 1
+	}
+
+	clusters := [][]int{order}
+	for len(clusters) > 0 {
+		var next [][]int
+		for _, cluster := range clusters {
+			if len(cluster) < 2 {
+				continue
+			}
+			left, right := cluster[:len(cluster)/2], cluster[len(cluster)/2:]
+			varLeft, varRight := clusterVariance(cov, left), clusterVariance(cov, right)
+			alpha := 1 - varLeft/(varLeft+varRight)
+			for _, i := range left {
+				w[i] *= alpha
+			}
+			for _, i := range right {
+				w[i] *= 1 - alpha
+			}
+			next = append(next, left, right)
+		}
+		clusters = next
+	}
+	return w, nil
+}
+
+// quasiDiagonalOrder returns the leaf order of a single-linkage clustering on the distance sqrt((1-ρ)/2).
+func quasiDiagonalOrder(cov mat.Symmetric) []int {
+	n := cov.Symmetric()
+	distance := func(i, j int) float64 {
+		rho := cov.At(i, j) / math.Sqrt(cov.At(i, i)*cov.At(j, j))
+		return math.Sqrt(math.Max(0, (1-rho)/2))
+	}
+
+	// Every cluster keeps its members in leaf order; merging concatenates them
+	clusters := make([][]int, n)
+	for i := range clusters {
+		clusters[i] = []int{i}
+	}
+	for len(clusters) > 1 {
+		bestA, bestB, bestDist := 0, 1, math.Inf(1)
+		for a := 0; a < len(clusters); a++ {
+			for b := a + 1; b < len(clusters); b++ {
+				d := math.Inf(1)
+				for _, i := range clusters[a] {
+					for _, j := range clusters[b] {
+						d = math.Min(d, distance(i, j))
+					}
+				}
+				if d < bestDist {
+					bestA, bestB, bestDist = a, b, d
+				}
+			}
+		}
+
+		merged := append(append([]int(nil), clusters[bestA]...), clusters[bestB]...)
+		clusters[bestA] = merged
+		clusters = append(clusters[:bestB], clusters[bestB+1:]...)
+	}
+	return clusters[0]
+}
+
+// clusterVariance returns the variance of the inverse-variance portfolio of the cluster.
+func clusterVariance(cov mat.Symmetric, cluster []int) float64 {
+	w := make([]float64, len(cluster))
+	total := 0.0
+	for k, i := range cluster {
+		w[k] = 1 / cov.At(i, i)
+		total += w[k]
+	}
+	variance := 0.0
+	for a, i := range cluster {
+		for b, j := range cluster {
+			variance += w[a] / total * w[b] / total * cov.At(i, j)
+		}
+	}
+	return variance
+}
+
+// sortedKeys returns the keys of a map in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,442 @@
+package regime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// minVariance is the floor applied to every emission variance to keep the likelihood finite.
+const minVariance = 1e-10
+
+// GaussianHMM is a hidden Markov model with Gaussian emissions and diagonal covariances.
+type GaussianHMM struct {
+	// States is the number of hidden states.
+	States int
+	// Initial holds the initial state probabilities.
+	Initial []float64
+	// Transition holds the state transition probabilities, Transition[i][j] = P(j | i).
+	Transition [][]float64
+	// Means and Variances hold the per-state emission means and variances of each feature.
+	Means     [][]float64
+	Variances [][]float64
+}
+
+// NewGaussianHMM creates an untrained GaussianHMM with the given number of hidden states.
+//
+// Parameters:
+// - states: The number of hidden states, at least 2.
+// Returns a pointer to the newly created GaussianHMM, or an error if the number of states is invalid.
+func NewGaussianHMM(states int) (*GaussianHMM, error) {
+	if states < 2 {
+		return nil, fmt.Errorf("a hidden Markov model requires at least 2 states, got %d", states)
+	}
+	return &GaussianHMM{States: states}, nil
+}
+
+// Fit trains the model on a sequence of observations using the Baum-Welch algorithm.
+//
+// The parameters are initialized deterministically by splitting the observations into equally
+// sized groups ordered by their last feature, so repeated fits on the same data give the same model.
+//
+// Parameters:
+// - observations: The observation sequence, one feature vector per time step.
+// - maxIter: The maximum number of EM iterations.
+// - tol: The minimum improvement of the log-likelihood to continue iterating.
+// Returns the log-likelihood of the trained model and an error if the observations are invalid.
+func (h *GaussianHMM) Fit(observations [][]float64, maxIter int, tol float64) (float64, error) {
+	if err := h.validate(observations); err != nil {
+		return 0, err
+	}
+	if len(observations) < 2*h.States {
+		return 0, fmt.Errorf("need at least %d observations to fit %d states, got %d", 2*h.States, h.States, len(observations))
+	}
+
+	h.initialize(observations)
+
+	prevLogLik := math.Inf(-1)
+	logLik := prevLogLik
+	for iter := 0; iter < maxIter; iter++ {
+		emissions, offsets := h.emissions(observations)
+		alpha, scale := h.forward(emissions)
+		beta := h.backward(emissions, scale)
+
+		logLik = logLikelihood(scale, offsets)
+
+		h.reestimate(observations, emissions, alpha, beta)
+
+		if logLik-prevLogLik < tol {
+			break
+		}
+		prevLogLik = logLik
+	}
+
+	return logLik, nil
+}
+
+// LogLikelihood returns the log-likelihood of the observations under the model.
+func (h *GaussianHMM) LogLikelihood(observations [][]float64) (float64, error) {
+	if err := h.checkTrained(observations); err != nil {
+		return 0, err
+	}
+
+	emissions, offsets := h.emissions(observations)
+	_, scale := h.forward(emissions)
+	return logLikelihood(scale, offsets), nil
+}
+
+// Filter runs the forward algorithm and returns the filtered state probabilities P(state_t | obs_1..t)
+// for every time step. Unlike smoothing, the probabilities at time t never depend on later observations.
+func (h *GaussianHMM) Filter(observations [][]float64) ([][]float64, error) {
+	if err := h.checkTrained(observations); err != nil {
+		return nil, err
+	}
+
+	emissions, _ := h.emissions(observations)
+	alpha, _ := h.forward(emissions)
+	return alpha, nil
+}
+
+// Viterbi returns the most likely sequence of hidden states for the observations.
+func (h *GaussianHMM) Viterbi(observations [][]float64) ([]int, error) {
+	if err := h.checkTrained(observations); err != nil {
+		return nil, err
+	}
+
+	T := len(observations)
+	delta := make([][]float64, T)
+	psi := make([][]int, T)
+	for t := range delta {
+		delta[t] = make([]float64, h.States)
+		psi[t] = make([]int, h.States)
+	}
+
+	for i := 0; i < h.States; i++ {
+		delta[0][i] = safeLog(h.Initial[i]) + h.logDensity(i, observations[0])
+	}
+	for t := 1; t < T; t++ {
+		for j := 0; j < h.States; j++ {
+			best := math.Inf(-1)
+			for i := 0; i < h.States; i++ {
+				if v := delta[t-1][i] + safeLog(h.Transition[i][j]); v > best {
+					best = v
+					psi[t][j] = i
+				}
+			}
+			delta[t][j] = best + h.logDensity(j, observations[t])
+		}
+	}
+
+	path := make([]int, T)
+	for i := 1; i < h.States; i++ {
+		if delta[T-1][i] > delta[T-1][path[T-1]] {
+			path[T-1] = i
+		}
+	}
+	for t := T - 1; t > 0; t-- {
+		path[t-1] = psi[t][path[t]]
+	}
+	return path, nil
+}
+
+// SortStates reorders the hidden states by ascending mean of the given feature, so that state
+// indices have a stable meaning across fits, e.g. state 0 being the calmest regime.
+func (h *GaussianHMM) SortStates(feature int) {
+	order := make([]int, h.States)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return h.Means[order[a]][feature] < h.Means[order[b]][feature]
+	})
+
+	initial := make([]float64, h.States)
+	transition := make([][]float64, h.States)
+	means := make([][]float64, h.States)
+	variances := make([][]float64, h.States)
+	for newI, oldI := range order {
+		initial[newI] = h.Initial[oldI]
+		means[newI] = h.Means[oldI]
+		variances[newI] = h.Variances[oldI]
+		transition[newI] = make([]float64, h.States)
+		for newJ, oldJ := range order {
+			transition[newI][newJ] = h.Transition[oldI][oldJ]
+		}
+	}
+
+	h.Initial, h.Transition, h.Means, h.Variances = initial, transition, means, variances
+}
+
+// validate checks that the observations are non-empty, rectangular and finite.
+func (h *GaussianHMM) validate(observations [][]float64) error {
+	if len(observations) == 0 {
+		return errors.New("no observations")
+	}
+	dims := len(observations[0])
+	if dims == 0 {
+		return errors.New("observations must have at least one feature")
+	}
+	for t, obs := range observations {
+		if len(obs) != dims {
+			return fmt.Errorf("observation %d has %d features, expected %d", t, len(obs), dims)
+		}
+		for _, v := range obs {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("observation %d contains a non-finite value", t)
+			}
+		}
+	}
+	return nil
+}
+
+// checkTrained validates the observations and checks that they match the trained model.
+func (h *GaussianHMM) checkTrained(observations [][]float64) error {
+	if h.Means == nil {
+		return errors.New("model is not trained")
+	}
+	if err := h.validate(observations); err != nil {
+		return err
+	}
+	if len(observations[0]) != len(h.Means[0]) {
+		return fmt.Errorf("model was trained on %d features, got %d", len(h.Means[0]), len(observations[0]))
+	}
+	return nil
+}
+
+// initialize sets uniform initial probabilities, sticky transitions and emission parameters
+// estimated from equally sized groups of observations ordered by their last feature.
+func (h *GaussianHMM) initialize(observations [][]float64) {
+	dims := len(observations[0])
+	sortFeature := dims - 1
+
+	order := make([]int, len(observations))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return observations[order[a]][sortFeature] < observations[order[b]][sortFeature]
+	})
+
+	h.Initial = make([]float64, h.States)
+	h.Transition = make([][]float64, h.States)
+	h.Means = make([][]float64, h.States)
+	h.Variances = make([][]float64, h.States)
+
+	for i := 0; i < h.States; i++ {
+		h.Initial[i] = 1 / float64(h.States)
+
+		h.Transition[i] = make([]float64, h.States)
+		for j := range h.Transition[i] {
+			if i == j {
+				h.Transition[i][j] = 0.9
+			} else {
+				h.Transition[i][j] = 0.1 / float64(h.States-1)
+			}
+		}
+
+		group := order[i*len(order)/h.States : (i+1)*len(order)/h.States]
+		h.Means[i] = make([]float64, dims)
+		h.Variances[i] = make([]float64, dims)
+		for d := 0; d < dims; d++ {
+			mean := 0.0
+			for _, t := range group {
+				mean += observations[t][d]
+			}
+			mean /= float64(len(group))
+
+			variance := 0.0
+			for _, t := range group {
+				variance += (observations[t][d] - mean) * (observations[t][d] - mean)
+			}
+			h.Means[i][d] = mean
+			h.Variances[i][d] = math.Max(variance/float64(len(group)), minVariance)
+		}
+	}
+}
+
+// emissions returns the emission densities of every observation under every state. To avoid
+// underflow the densities of each time step are divided by their maximum, whose log is returned as offset.
+func (h *GaussianHMM) emissions(observations [][]float64) ([][]float64, []float64) {
+	emissions := make([][]float64, len(observations))
+	offsets := make([]float64, len(observations))
+	for t, obs := range observations {
+		emissions[t] = make([]float64, h.States)
+		offsets[t] = math.Inf(-1)
+		for i := 0; i < h.States; i++ {
+			emissions[t][i] = h.logDensity(i, obs)
+			offsets[t] = math.Max(offsets[t], emissions[t][i])
+		}
+		for i := range emissions[t] {
+			emissions[t][i] = math.Exp(emissions[t][i] - offsets[t])
+		}
+	}
+	return emissions, offsets
+}
+
+// logLikelihood combines the forward scale factors and the emission offsets into the log-likelihood.
+func logLikelihood(scale, offsets []float64) float64 {
+	logLik := 0.0
+	for t, c := range scale {
+		logLik += offsets[t] - safeLog(c)
+	}
+	return logLik
+}
+
+// logDensity returns the log of the Gaussian emission density of the observation under state i.
+func (h *GaussianHMM) logDensity(i int, obs []float64) float64 {
+	logDensity := 0.0
+	for d, v := range obs {
+		variance := h.Variances[i][d]
+		diff := v - h.Means[i][d]
+		logDensity -= 0.5 * (math.Log(2*math.Pi*variance) + diff*diff/variance)
+	}
+	return logDensity
+}
+
+// forward runs the scaled forward algorithm. The returned alpha rows are normalized filtered
+// probabilities and scale holds the normalization factor of every time step.
+func (h *GaussianHMM) forward(emissions [][]float64) ([][]float64, []float64) {
+	T := len(emissions)
+	alpha := make([][]float64, T)
+	scale := make([]float64, T)
+
+	for t := 0; t < T; t++ {
+		alpha[t] = make([]float64, h.States)
+		for j := 0; j < h.States; j++ {
+			if t == 0 {
+				alpha[t][j] = h.Initial[j] * emissions[t][j]
+				continue
+			}
+			prior := 0.0
+			for i := 0; i < h.States; i++ {
+				prior += alpha[t-1][i] * h.Transition[i][j]
+			}
+			alpha[t][j] = prior * emissions[t][j]
+		}
+		scale[t] = normalize(alpha[t])
+	}
+	return alpha, scale
+}
+
+// backward runs the backward algorithm using the scale factors of the forward pass.
+func (h *GaussianHMM) backward(emissions [][]float64, scale []float64) [][]float64 {
+	T := len(emissions)
+	beta := make([][]float64, T)
+	beta[T-1] = make([]float64, h.States)
+	for i := range beta[T-1] {
+		beta[T-1][i] = scale[T-1]
+	}
+
+	for t := T - 2; t >= 0; t-- {
+		beta[t] = make([]float64, h.States)
+		for i := 0; i < h.States; i++ {
+			for j := 0; j < h.States; j++ {
+				beta[t][i] += h.Transition[i][j] * emissions[t+1][j] * beta[t+1][j]
+			}
+			beta[t][i] *= scale[t]
+		}
+	}
+	return beta
+}
+
+// reestimate performs the M-step of Baum-Welch.
+func (h *GaussianHMM) reestimate(observations, emissions, alpha, beta [][]float64) {
+	T := len(observations)
+	dims := len(observations[0])
+
+	// gamma[t][i] = P(state_t = i | observations)
+	gamma := make([][]float64, T)
+	for t := 0; t < T; t++ {
+		gamma[t] = make([]float64, h.States)
+		for i := 0; i < h.States; i++ {
+			gamma[t][i] = alpha[t][i] * beta[t][i]
+		}
+		normalize(gamma[t])
+	}
+
+	// Expected transition counts
+	xiSum := make([][]float64, h.States)
+	for i := range xiSum {
+		xiSum[i] = make([]float64, h.States)
+	}
+	xi := make([][]float64, h.States)
+	for i := range xi {
+		xi[i] = make([]float64, h.States)
+	}
+	for t := 0; t < T-1; t++ {
+		total := 0.0
+		for i := 0; i < h.States; i++ {
+			for j := 0; j < h.States; j++ {
+				xi[i][j] = alpha[t][i] * h.Transition[i][j] * emissions[t+1][j] * beta[t+1][j]
+				total += xi[i][j]
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		for i := 0; i < h.States; i++ {
+			for j := 0; j < h.States; j++ {
+				xiSum[i][j] += xi[i][j] / total
+			}
+		}
+	}
+
+	copy(h.Initial, gamma[0])
+	for i := 0; i < h.States; i++ {
+		if normalize(xiSum[i]) != 0 {
+			h.Transition[i] = xiSum[i]
+		}
+
+		weight := 0.0
+		for t := 0; t < T; t++ {
+			weight += gamma[t][i]
+		}
+		if weight == 0 {
+			continue // State was never visited, keep its emission parameters
+		}
+
+		for d := 0; d < dims; d++ {
+			mean := 0.0
+			for t := 0; t < T; t++ {
+				mean += gamma[t][i] * observations[t][d]
+			}
+			mean /= weight
+
+			variance := 0.0
+			for t := 0; t < T; t++ {
+				diff := observations[t][d] - mean
+				variance += gamma[t][i] * diff * diff
+			}
+			h.Means[i][d] = mean
+			h.Variances[i][d] = math.Max(variance/weight, minVariance)
+		}
+	}
+}
+
+// normalize scales the values to sum to one in place and returns the reciprocal of their sum.
+// If the values sum to zero they are replaced by a uniform distribution and zero is returned.
+func normalize(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	if total == 0 || math.IsNaN(total) {
+		for i := range values {
+			values[i] = 1 / float64(len(values))
+		}
+		return 0
+	}
+	for i := range values {
+		values[i] /= total
+	}
+	return 1 / total
+}
+
+// safeLog returns the natural logarithm of v, or negative infinity for non-positive values.
+func safeLog(v float64) float64 {
+	if v <= 0 {
+		return math.Inf(-1)
+	}
+	return math.Log(v)
+}
@@ -0,0 +1,124 @@
+package regime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// Indicator detects market regimes from the returns and rolling volatility of the closing prices
+// using a GaussianHMM. Regimes are ordered by ascending volatility, so regime 0 is the calmest.
+type Indicator struct {
+	Model *GaussianHMM
+	// VolatilityWindow is the number of returns used for the rolling volatility feature.
+	VolatilityWindow int
+	// MaxIter and Tol control the Baum-Welch training.
+	MaxIter int
+	Tol     float64
+}
+
+// NewIndicator creates a new regime Indicator.
+//
+// Parameters:
+// - regimes: The number of regimes (hidden states), at least 2.
+// - volatilityWindow: The number of returns used for the rolling volatility, at least 2.
+// Returns a pointer to the newly created Indicator, or an error if the parameters are invalid.
+func NewIndicator(regimes, volatilityWindow int) (*Indicator, error) {
+	if volatilityWindow < 2 {
+		return nil, fmt.Errorf("volatility window must be at least 2, got %d", volatilityWindow)
+	}
+	model, err := NewGaussianHMM(regimes)
+	if err != nil {
+		return nil, err
+	}
+	return &Indicator{
+		Model:            model,
+		VolatilityWindow: volatilityWindow,
+		MaxIter:          100,
+		Tol:              1e-6,
+	}, nil
+}
+
+// Features converts closing prices into one [return, rolling volatility] observation per bar,
+// starting at the first bar with a full volatility window.
+func Features(prices []float64, volatilityWindow int) [][]float64 {
+	if len(prices) < volatilityWindow+1 {
+		return nil
+	}
+
+	rets := make([]float64, len(prices)-1)
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 && prices[i] > 0 {
+			rets[i-1] = math.Log(prices[i] / prices[i-1])
+		}
+	}
+
+	features := make([][]float64, 0, len(rets)-volatilityWindow+1)
+	for i := volatilityWindow - 1; i < len(rets); i++ {
+		window := rets[i-volatilityWindow+1 : i+1]
+		mean := 0.0
+		for _, r := range window {
+			mean += r
+		}
+		mean /= float64(len(window))
+		variance := 0.0
+		for _, r := range window {
+			variance += (r - mean) * (r - mean)
+		}
+		features = append(features, []float64{rets[i], math.Sqrt(variance / float64(len(window)-1))})
+	}
+	return features
+}
+
+// Fit trains the underlying model on the closing prices of the dataframe.
+func (ind *Indicator) Fit(df dataframe.DataFrame) error {
+	features := Features(df.Col("Close").Float(), ind.VolatilityWindow)
+	if features == nil {
+		return errors.New("not enough data to compute regime features")
+	}
+	if _, err := ind.Model.Fit(features, ind.MaxIter, ind.Tol); err != nil {
+		return err
+	}
+	ind.Model.SortStates(1)
+	return nil
+}
+
+// Filtered returns the filtered regime probabilities for every bar with a full volatility window.
+// The probabilities of a bar only depend on that bar and the bars before it.
+func (ind *Indicator) Filtered(df dataframe.DataFrame) ([][]float64, error) {
+	features := Features(df.Col("Close").Float(), ind.VolatilityWindow)
+	if features == nil {
+		return nil, errors.New("not enough data to compute regime features")
+	}
+	return ind.Model.Filter(features)
+}
+
+// Current returns the most likely regime of the last bar according to the forward filter,
+// together with its probability.
+func (ind *Indicator) Current(df dataframe.DataFrame) (int, float64, error) {
+	filtered, err := ind.Filtered(df)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	last := filtered[len(filtered)-1]
+	best := 0
+	for i := range last {
+		if last[i] > last[best] {
+			best = i
+		}
+	}
+	return best, last[best], nil
+}
+
+// Path returns the most likely regime sequence according to the Viterbi algorithm.
+// Since it uses all observations it must not be used to generate trading signals in a backtest.
+func (ind *Indicator) Path(df dataframe.DataFrame) ([]int, error) {
+	features := Features(df.Col("Close").Float(), ind.VolatilityWindow)
+	if features == nil {
+		return nil, errors.New("not enough data to compute regime features")
+	}
+	return ind.Model.Viterbi(features)
+}
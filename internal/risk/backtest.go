@@ -0,0 +1,178 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// TestResult is the outcome of a likelihood ratio test.
+type TestResult struct {
+	Statistic float64
+	// DegreesOfFreedom of the chi-squared distribution of the statistic.
+	DegreesOfFreedom int
+	PValue           float64
+}
+
+// Reject reports whether the null hypothesis is rejected at the significance level.
+func (t TestResult) Reject(significance float64) bool {
+	return t.PValue < significance
+}
+
+// VaRBacktest holds the result of comparing rolling VaR forecasts with realized returns.
+type VaRBacktest struct {
+	// Forecasts holds the one-bar VaR forecast for every tested bar, estimated from the preceding window.
+	Forecasts []float64
+	// Exceptions marks the bars whose loss exceeded the forecast.
+	Exceptions   []bool
+	Observations int
+	Violations   int
+	ExpectedRate float64
+	ObservedRate float64
+	// Kupiec tests the unconditional coverage, i.e. whether the violation rate matches 1 - confidence.
+	Kupiec TestResult
+	// Independence is Christoffersen's test that violations do not cluster.
+	Independence TestResult
+	// ConditionalCoverage is Christoffersen's joint test of coverage and independence.
+	ConditionalCoverage TestResult
+}
+
+// RollingVaR forecasts the VaR of every bar after the first window bars from the preceding window of returns.
+//
+// Parameters:
+// - returns: The simple returns per bar, oldest first.
+// - window: The number of returns used for every forecast.
+// - method: The estimation method.
+// - cfg: The confidence level and simulation settings. The horizon must be 1.
+// Returns the forecasts for bars window..len(returns)-1, or an error if the inputs are invalid.
+func RollingVaR(returns []float64, window int, method Method, cfg Config) ([]float64, error) {
+	if cfg.Horizon != 1 {
+		return nil, fmt.Errorf("rolling VaR requires a horizon of 1, got %d", cfg.Horizon)
+	}
+	if window < 2 {
+		return nil, fmt.Errorf("window must be at least 2, got %d", window)
+	}
+	if len(returns) <= window {
+		return nil, fmt.Errorf("need more than %d returns, got %d", window, len(returns))
+	}
+
+	forecasts := make([]float64, len(returns)-window)
+	for t := window; t < len(returns); t++ {
+		measure, err := Estimate(returns[t-window:t], method, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("forecast for bar %d: %w", t, err)
+		}
+		forecasts[t-window] = measure.VaR
+	}
+	return forecasts, nil
+}
+
+// BacktestVaR forecasts rolling VaR and tests the forecasts with the Kupiec and Christoffersen tests.
+//
+// Parameters:
+// - returns: The simple returns per bar, oldest first.
+// - window: The number of returns used for every forecast.
+// - method: The estimation method.
+// - cfg: The confidence level and simulation settings. The horizon must be 1.
+// Returns the VaRBacktest, or an error if the inputs are invalid.
+func BacktestVaR(returns []float64, window int, method Method, cfg Config) (VaRBacktest, error) {
+	forecasts, err := RollingVaR(returns, window, method, cfg)
+	if err != nil {
+		return VaRBacktest{}, err
+	}
+
+	exceptions := make([]bool, len(forecasts))
+	violations := 0
+	for i, forecast := range forecasts {
+		if -returns[window+i] > forecast {
+			exceptions[i] = true
+			violations++
+		}
+	}
+
+	expected := 1 - cfg.Confidence
+	kupiec := Kupiec(exceptions, expected)
+	independence := Christoffersen(exceptions)
+	conditional := TestResult{
+		Statistic:        kupiec.Statistic + independence.Statistic,
+		DegreesOfFreedom: 2,
+	}
+	conditional.PValue = chiSquaredSurvival(conditional.Statistic, 2)
+
+	return VaRBacktest{
+		Forecasts:           forecasts,
+		Exceptions:          exceptions,
+		Observations:        len(exceptions),
+		Violations:          violations,
+		ExpectedRate:        expected,
+		ObservedRate:        float64(violations) / float64(len(exceptions)),
+		Kupiec:              kupiec,
+		Independence:        independence,
+		ConditionalCoverage: conditional,
+	}, nil
+}
+
+// Kupiec performs the proportion of failures test of the observed violation rate against the expected rate.
+func Kupiec(exceptions []bool, expectedRate float64) TestResult {
+	n := float64(len(exceptions))
+	x := 0.0
+	for _, e := range exceptions {
+		if e {
+			x++
+		}
+	}
+
+	observed := x / n
+	statistic := -2 * (binomialLogLikelihood(x, n, expectedRate) - binomialLogLikelihood(x, n, observed))
+	statistic = math.Max(statistic, 0)
+	return TestResult{Statistic: statistic, DegreesOfFreedom: 1, PValue: chiSquaredSurvival(statistic, 1)}
+}
+
+// Christoffersen tests the independence of violations against a first-order Markov alternative.
+func Christoffersen(exceptions []bool) TestResult {
+	var n00, n01, n10, n11 float64
+	for i := 1; i < len(exceptions); i++ {
+		switch {
+		case !exceptions[i-1] && !exceptions[i]:
+			n00++
+		case !exceptions[i-1] && exceptions[i]:
+			n01++
+		case exceptions[i-1] && !exceptions[i]:
+			n10++
+		default:
+			n11++
+		}
+	}
+
+	pi := (n01 + n11) / (n00 + n01 + n10 + n11)
+	pi0, pi1 := 0.0, 0.0
+	if n00+n01 > 0 {
+		pi0 = n01 / (n00 + n01)
+	}
+	if n10+n11 > 0 {
+		pi1 = n11 / (n10 + n11)
+	}
+
+	restricted := binomialLogLikelihood(n01+n11, n00+n01+n10+n11, pi)
+	unrestricted := binomialLogLikelihood(n01, n00+n01, pi0) + binomialLogLikelihood(n11, n10+n11, pi1)
+	statistic := math.Max(-2*(restricted-unrestricted), 0)
+	return TestResult{Statistic: statistic, DegreesOfFreedom: 1, PValue: chiSquaredSurvival(statistic, 1)}
+}
+
+// binomialLogLikelihood returns the log-likelihood of x successes in n trials with probability p, using 0·log(0) = 0.
+func binomialLogLikelihood(x, n, p float64) float64 {
+	ll := 0.0
+	if x > 0 {
+		ll += x * math.Log(p)
+	}
+	if n-x > 0 {
+		ll += (n - x) * math.Log(1-p)
+	}
+	return ll
+}
+
+// chiSquaredSurvival returns P(X > statistic) for a chi-squared distribution.
+func chiSquaredSurvival(statistic float64, degreesOfFreedom int) float64 {
+	return distuv.ChiSquared{K: float64(degreesOfFreedom)}.Survival(statistic)
+}
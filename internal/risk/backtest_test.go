@@ -0,0 +1,144 @@
+package risk
+
+import (
+	"math"
+	"testing"
+)
+
+// exceptionsAt returns n bars with exceptions at the given indices.
+func exceptionsAt(n int, indices ...int) []bool {
+	exceptions := make([]bool, n)
+	for _, i := range indices {
+		exceptions[i] = true
+	}
+	return exceptions
+}
+
+func TestKupiec(t *testing.T) {
+	// 250 bars at 99% expect 2.5 violations: none is rejected at 5% but not at 1%, ten are rejected at 1%
+	tests := []struct {
+		name       string
+		exceptions []bool
+		statistic  float64
+		pValue     float64
+	}{
+		{"0 of 250", exceptionsAt(250), 5.025167926750726, 0.02498150305344971},
+		{"10 of 250", exceptionsAt(250, 0, 25, 50, 75, 100, 125, 150, 175, 200, 225), 12.955491062356018, 0.0003189845082133835},
+		// The expected rate exactly
+		{"2 of 200", exceptionsAt(200, 10, 110), 0, 1},
+	}
+	for _, tt := range tests {
+		got := Kupiec(tt.exceptions, 0.01)
+		if math.Abs(got.Statistic-tt.statistic) > 1e-9 || math.Abs(got.PValue-tt.pValue) > 1e-9 || got.DegreesOfFreedom != 1 {
+			t.Errorf("%s: got %+v, want statistic %.6f, p-value %.6g", tt.name, got, tt.statistic, tt.pValue)
+		}
+	}
+	if result := Kupiec(exceptionsAt(250), 0.01); !result.Reject(0.05) || result.Reject(0.01) {
+		t.Errorf("0 of 250: got %+v, want a rejection at 5%% only", result)
+	}
+}
+
+func TestChristoffersen(t *testing.T) {
+	alternating := make([]bool, 20)
+	for i := range alternating {
+		alternating[i] = i%2 == 1
+	}
+	tests := []struct {
+		name       string
+		exceptions []bool
+		statistic  float64
+		reject     bool
+	}{
+		// Five violations in a row are far more likely after a violation than after a quiet bar
+		{"clustered", exceptionsAt(250, 100, 101, 102, 103, 104), 30.98481265704308, true},
+		// Violations 50 bars apart never follow each other, as expected of five in 250
+		{"spread", exceptionsAt(250, 25, 75, 125, 175, 225), 0.20493237652149787, false},
+		// Strict alternation is dependent too, a violation always following a quiet bar
+		{"alternating", alternating, 26.286936956391877, true},
+	}
+	for _, tt := range tests {
+		got := Christoffersen(tt.exceptions)
+		if math.Abs(got.Statistic-tt.statistic) > 1e-9 || got.Reject(0.01) != tt.reject {
+			t.Errorf("%s: got %+v, want statistic %.6f, rejected %v", tt.name, got, tt.statistic, tt.reject)
+		}
+	}
+	if got := Christoffersen(exceptionsAt(250)); got.Statistic != 0 || got.PValue != 1 {
+		t.Errorf("no violations: got %+v", got)
+	}
+}
+
+func TestRollingVaRNoLookahead(t *testing.T) {
+	// Every forecast is the estimate of the preceding window, whatever comes at or after its bar
+	const window = 50
+	returns := normalReturns(120, 0, 0.01, 3)
+	for _, method := range []Method{Historical, ParametricNormal} {
+		forecasts, err := RollingVaR(returns, window, method, DefaultConfig(0.99))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(forecasts) != len(returns)-window {
+			t.Fatalf("%s: got %d forecasts, want %d", method, len(forecasts), len(returns)-window)
+		}
+		for i, forecast := range forecasts {
+			want, _ := Estimate(returns[i:i+window], method, DefaultConfig(0.99))
+			if forecast != want.VaR {
+				t.Fatalf("%s: forecast %d: got %v, want %v", method, i, forecast, want.VaR)
+			}
+		}
+
+		// A crash at bar 80 changes the forecasts of the bars after it only
+		crashed := append([]float64(nil), returns...)
+		crashed[80] = -0.5
+		after, err := RollingVaR(crashed, window, method, DefaultConfig(0.99))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range forecasts {
+			bar := window + i
+			if changed := after[i] != forecasts[i]; changed != (bar > 80 && bar <= 80+window) {
+				t.Errorf("%s: forecast for bar %d changed: %v", method, bar, changed)
+			}
+		}
+	}
+
+	if _, err := RollingVaR(returns, window, Historical, Config{Confidence: 0.99, Horizon: 10}); err == nil {
+		t.Error("horizon 10: no error")
+	}
+	if _, err := RollingVaR(returns[:window], window, Historical, DefaultConfig(0.99)); err == nil {
+		t.Error("no bar to forecast: no error")
+	}
+}
+
+func TestBacktestVaR(t *testing.T) {
+	// Losses beyond the forecast of their bar are exceptions, and the tests are run on them
+	returns := normalReturns(300, 0, 0.01, 4)
+	returns[100], returns[101] = -0.08, -0.09
+	cfg := DefaultConfig(0.99)
+	backtest, err := BacktestVaR(returns, 50, ParametricNormal, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backtest.Observations != 250 || len(backtest.Exceptions) != 250 {
+		t.Fatalf("got %d observations, want 250", backtest.Observations)
+	}
+	violations := 0
+	for i, exception := range backtest.Exceptions {
+		if exception != (-returns[50+i] > backtest.Forecasts[i]) {
+			t.Errorf("bar %d: exception %v with return %v and forecast %v", 50+i, exception, returns[50+i], backtest.Forecasts[i])
+		}
+		if exception {
+			violations++
+		}
+	}
+	if !backtest.Exceptions[50] || !backtest.Exceptions[51] {
+		t.Error("the crash of bars 100 and 101 is not an exception")
+	}
+	if backtest.Violations != violations || backtest.ObservedRate != float64(violations)/250 || backtest.ExpectedRate != 1-cfg.Confidence {
+		t.Errorf("got %d violations at %v, want %d", backtest.Violations, backtest.ObservedRate, violations)
+	}
+	kupiec, independence := Kupiec(backtest.Exceptions, 1-cfg.Confidence), Christoffersen(backtest.Exceptions)
+	if backtest.Kupiec != kupiec || backtest.Independence != independence ||
+		math.Abs(backtest.ConditionalCoverage.Statistic-kupiec.Statistic-independence.Statistic) > 1e-12 || backtest.ConditionalCoverage.DegreesOfFreedom != 2 {
+		t.Errorf("got tests %+v, %+v, %+v", backtest.Kupiec, backtest.Independence, backtest.ConditionalCoverage)
+	}
+}
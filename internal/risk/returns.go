@@ -0,0 +1,53 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+
+	data_types "goquant/pkg/data"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// InstrumentReturns returns the close-to-close returns of a single instrument, oldest first.
+func InstrumentReturns(data []data_types.MarketData) ([]float64, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("need at least 2 bars, got %d", len(data))
+	}
+	returns := make([]float64, len(data)-1)
+	for i := 1; i < len(data); i++ {
+		if data[i-1].Close == 0 {
+			return nil, fmt.Errorf("zero close at %d", data[i-1].Timestamp)
+		}
+		returns[i-1] = data[i].Close/data[i-1].Close - 1
+	}
+	return returns, nil
+}
+
+// EquityReturns returns the per-bar returns of a backtest equity curve. It accepts the trade log of any
+// backtest result, which records the ProfitLoss of every bar and the CurrentInvest after it.
+func EquityReturns(tradeLog dataframe.DataFrame) ([]float64, error) {
+	if tradeLog.Err != nil {
+		return nil, tradeLog.Err
+	}
+	profitLoss := tradeLog.Col("ProfitLoss")
+	invest := tradeLog.Col("CurrentInvest")
+	if profitLoss.Err != nil || invest.Err != nil {
+		return nil, errors.New("trade log must have ProfitLoss and CurrentInvest columns")
+	}
+
+	pnl := profitLoss.Float()
+	equity := invest.Float()
+	returns := make([]float64, 0, len(pnl))
+	for i := range pnl {
+		before := equity[i] - pnl[i]
+		if before <= 0 {
+			break
+		}
+		returns = append(returns, pnl[i]/before)
+	}
+	if len(returns) == 0 {
+		return nil, errors.New("trade log has no bars with a positive investment")
+	}
+	return returns, nil
+}
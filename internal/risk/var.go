@@ -0,0 +1,350 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"goquant/internal/covariance"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Method selects how Value-at-Risk and Expected Shortfall are estimated.
+type Method int
+
+const (
+	// Historical uses the empirical distribution of the (overlapping, compounded) horizon returns.
+	Historical Method = iota
+	// ParametricNormal assumes normally distributed returns scaled with the square root of the horizon.
+	ParametricNormal
+	// CornishFisher adjusts the normal quantile for the skewness and excess kurtosis of the returns.
+	CornishFisher
+	// MonteCarlo simulates compounded horizon returns from a fitted multivariate normal distribution.
+	MonteCarlo
+)
+
+// String returns the name of the method.
+func (m Method) String() string {
+	switch m {
+	case Historical:
+		return "Historical"
+	case ParametricNormal:
+		return "ParametricNormal"
+	case CornishFisher:
+		return "CornishFisher"
+	case MonteCarlo:
+		return "MonteCarlo"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// Config holds the parameters of a risk estimate.
+type Config struct {
+	// Confidence is the VaR confidence level in (0, 1), e.g. 0.99.
+	Confidence float64
+	// Horizon is the holding period in bars.
+	Horizon int
+	// Simulations is the number of Monte Carlo paths.
+	Simulations int
+	// Seed seeds the Monte Carlo random number generator.
+	Seed int64
+	// Estimator estimates the covariance matrix of multi-asset returns. Nil means the sample covariance.
+	Estimator covariance.Estimator
+}
+
+// DefaultConfig returns a one-bar configuration at the given confidence level with 10000 Monte Carlo paths.
+func DefaultConfig(confidence float64) Config {
+	return Config{Confidence: confidence, Horizon: 1, Simulations: 10000, Seed: 1}
+}
+
+// validate checks the configuration for the given method.
+func (c Config) validate(method Method) error {
+	if c.Confidence <= 0 || c.Confidence >= 1 || math.IsNaN(c.Confidence) {
+		return fmt.Errorf("confidence must be in (0, 1), got %v", c.Confidence)
+	}
+	if c.Horizon < 1 {
+		return fmt.Errorf("horizon must be at least 1, got %d", c.Horizon)
+	}
+	if method < Historical || method > MonteCarlo {
+		return fmt.Errorf("unknown method: %v", method)
+	}
+	if method == MonteCarlo && c.Simulations < 1 {
+		return fmt.Errorf("monte carlo requires at least 1 simulation, got %d", c.Simulations)
+	}
+	return nil
+}
+
+// Measure is a risk estimate. VaR and ES are losses expressed as positive fractions of the position value.
+type Measure struct {
+	Method     Method
+	Confidence float64
+	Horizon    int
+	VaR        float64
+	ES         float64
+}
+
+// Estimate computes Value-at-Risk and Expected Shortfall of a single return series.
+//
+// Parameters:
+// - returns: The simple returns per bar, oldest first.
+// - method: The estimation method.
+// - cfg: The confidence level, horizon and simulation settings.
+// Returns the Measure, or an error if the inputs are invalid.
+func Estimate(returns []float64, method Method, cfg Config) (Measure, error) {
+	if len(returns) == 0 {
+		return Measure{}, errors.New("returns must not be empty")
+	}
+	return EstimatePortfolio(mat.NewDense(len(returns), 1, returns), []float64{1}, method, cfg)
+}
+
+// EstimatePortfolio computes Value-at-Risk and Expected Shortfall of a portfolio with constant weights.
+// The parametric methods use the portfolio volatility implied by the covariance estimator of cfg.
+//
+// Parameters:
+// - returns: The aligned simple returns, one row per bar and one column per asset, e.g. from portfolio.ReturnsMatrix.
+// - weights: The portfolio weight of every asset.
+// - method: The estimation method.
+// - cfg: The confidence level, horizon, covariance estimator and simulation settings.
+// Returns the Measure, or an error if the inputs are invalid.
+func EstimatePortfolio(returns mat.Matrix, weights []float64, method Method, cfg Config) (Measure, error) {
+	if err := cfg.validate(method); err != nil {
+		return Measure{}, err
+	}
+	series, err := PortfolioReturns(returns, weights)
+	if err != nil {
+		return Measure{}, err
+	}
+	if len(series) < 2 {
+		return Measure{}, fmt.Errorf("need at least 2 returns, got %d", len(series))
+	}
+
+	measure := Measure{Method: method, Confidence: cfg.Confidence, Horizon: cfg.Horizon}
+	switch method {
+	case Historical:
+		horizonReturns := compound(series, cfg.Horizon)
+		if len(horizonReturns) == 0 {
+			return Measure{}, fmt.Errorf("need at least %d returns for a horizon of %d, got %d", cfg.Horizon, cfg.Horizon, len(series))
+		}
+		measure.VaR, measure.ES = empirical(horizonReturns, cfg.Confidence)
+	case ParametricNormal, CornishFisher:
+		mean := stat.Mean(series, nil)
+		volatility, err := portfolioVolatility(returns, weights, cfg.Estimator)
+		if err != nil {
+			return Measure{}, err
+		}
+		h := float64(cfg.Horizon)
+		skewness, excessKurtosis := 0.0, 0.0
+		if method == CornishFisher && volatility > 0 {
+			// Moments of a sum of h independent returns
+			skewness = stat.Skew(series, nil) / math.Sqrt(h)
+			excessKurtosis = stat.ExKurtosis(series, nil) / h
+		}
+		measure.VaR, measure.ES = parametric(mean*h, volatility*math.Sqrt(h), skewness, excessKurtosis, cfg.Confidence)
+	case MonteCarlo:
+		simulated, err := simulate(returns, weights, cfg)
+		if err != nil {
+			return Measure{}, err
+		}
+		measure.VaR, measure.ES = empirical(simulated, cfg.Confidence)
+	}
+	return measure, nil
+}
+
+// Report holds the estimates of every method for one confidence level and horizon.
+type Report struct {
+	Confidence    float64
+	Horizon       int
+	Historical    Measure
+	Normal        Measure
+	CornishFisher Measure
+	MonteCarlo    Measure
+}
+
+// NewReport estimates the risk of a portfolio with every method at each of the given confidence levels.
+//
+// Parameters:
+// - returns: The aligned simple returns, one row per bar and one column per asset.
+// - weights: The portfolio weight of every asset.
+// - confidences: The confidence levels to report.
+// - cfg: The horizon, covariance estimator and simulation settings. Its Confidence is ignored.
+// Returns one Report per confidence level, or an error if any estimate fails.
+func NewReport(returns mat.Matrix, weights []float64, confidences []float64, cfg Config) ([]Report, error) {
+	if len(confidences) == 0 {
+		return nil, errors.New("need at least one confidence level")
+	}
+	reports := make([]Report, len(confidences))
+	for i, confidence := range confidences {
+		cfg.Confidence = confidence
+		report := Report{Confidence: confidence, Horizon: cfg.Horizon}
+		for _, target := range []struct {
+			method  Method
+			measure *Measure
+		}{
+			{Historical, &report.Historical},
+			{ParametricNormal, &report.Normal},
+			{CornishFisher, &report.CornishFisher},
+			{MonteCarlo, &report.MonteCarlo},
+		} {
+			measure, err := EstimatePortfolio(returns, weights, target.method, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("%v at %v: %w", target.method, confidence, err)
+			}
+			*target.measure = measure
+		}
+		reports[i] = report
+	}
+	return reports, nil
+}
+
+// PortfolioReturns returns the per-bar returns of a portfolio rebalanced to constant weights.
+func PortfolioReturns(returns mat.Matrix, weights []float64) ([]float64, error) {
+	if returns == nil {
+		return nil, errors.New("returns must not be nil")
+	}
+	rows, cols := returns.Dims()
+	if len(weights) != cols {
+		return nil, fmt.Errorf("got %d weights for %d assets", len(weights), cols)
+	}
+	series := make([]float64, rows)
+	for i := 0; i < rows; i++ {
+		for j, w := range weights {
+			r := returns.At(i, j)
+			if math.IsNaN(r) || math.IsInf(r, 0) {
+				return nil, fmt.Errorf("return (%d, %d) is not finite", i, j)
+			}
+			series[i] += w * r
+		}
+	}
+	return series, nil
+}
+
+// compound returns the overlapping compounded returns over the horizon.
+func compound(returns []float64, horizon int) []float64 {
+	if horizon == 1 {
+		return returns
+	}
+	var compounded []float64
+	for start := 0; start+horizon <= len(returns); start++ {
+		growth := 1.0
+		for _, r := range returns[start : start+horizon] {
+			growth *= 1 + r
+		}
+		compounded = append(compounded, growth-1)
+	}
+	return compounded
+}
+
+// empirical returns the VaR and ES of a sample of returns at the confidence level.
+func empirical(returns []float64, confidence float64) (float64, float64) {
+	losses := make([]float64, len(returns))
+	for i, r := range returns {
+		losses[i] = -r
+	}
+	sort.Float64s(losses)
+
+	valueAtRisk := stat.Quantile(confidence, stat.Empirical, losses, nil)
+	tail, count := 0.0, 0
+	for _, loss := range losses {
+		if loss >= valueAtRisk {
+			tail += loss
+			count++
+		}
+	}
+	return valueAtRisk, tail / float64(count)
+}
+
+// parametric returns the VaR and ES of a distribution with the given moments. With zero skewness and
+// excess kurtosis this is the normal distribution, otherwise the Cornish-Fisher expansion is used and
+// the ES is integrated numerically over the tail.
+func parametric(mean, volatility, skewness, excessKurtosis, confidence float64) (float64, float64) {
+	standard := distuv.UnitNormal
+	tail := 1 - confidence
+
+	if skewness == 0 && excessKurtosis == 0 {
+		z := standard.Quantile(tail)
+		valueAtRisk := -(mean + volatility*z)
+		expectedShortfall := -mean + volatility*standard.Prob(z)/tail
+		return valueAtRisk, expectedShortfall
+	}
+
+	quantile := func(p float64) float64 {
+		z := standard.Quantile(p)
+		return z + (z*z-1)*skewness/6 + (z*z*z-3*z)*excessKurtosis/24 - (2*z*z*z-5*z)*skewness*skewness/36
+	}
+	valueAtRisk := -(mean + volatility*quantile(tail))
+
+	// Midpoint rule over the tail probabilities
+	const steps = 1000
+	sum := 0.0
+	for k := 0; k < steps; k++ {
+		sum += quantile(tail * (float64(k) + 0.5) / steps)
+	}
+	expectedShortfall := -(mean + volatility*sum/steps)
+	return valueAtRisk, expectedShortfall
+}
+
+// covarianceOf estimates the covariance matrix of the returns.
+func covarianceOf(returns mat.Matrix, estimator covariance.Estimator) (*mat.SymDense, error) {
+	if estimator == nil {
+		estimator = covariance.Sample{}
+	}
+	return estimator.Estimate(returns)
+}
+
+// portfolioVolatility returns the per-bar volatility of the portfolio.
+func portfolioVolatility(returns mat.Matrix, weights []float64, estimator covariance.Estimator) (float64, error) {
+	cov, err := covarianceOf(returns, estimator)
+	if err != nil {
+		return 0, err
+	}
+	w := mat.NewVecDense(len(weights), weights)
+	variance := mat.Inner(w, cov, w)
+	if variance < 0 {
+		variance = 0
+	}
+	return math.Sqrt(variance), nil
+}
+
+// simulate draws compounded horizon returns of the portfolio from a multivariate normal distribution
+// fitted to the returns. The weights are held constant over the horizon.
+func simulate(returns mat.Matrix, weights []float64, cfg Config) ([]float64, error) {
+	rows, cols := returns.Dims()
+	cov, err := covarianceOf(returns, cfg.Estimator)
+	if err != nil {
+		return nil, err
+	}
+
+	mean := make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			mean[j] += returns.At(i, j)
+		}
+		mean[j] /= float64(rows)
+	}
+
+	// The portfolio return of one bar is normal with mean w'mu and variance w'Σw
+	w := mat.NewVecDense(len(weights), weights)
+	variance := mat.Inner(w, cov, w)
+	volatility := math.Sqrt(math.Max(variance, 0))
+	portfolioMean := 0.0
+	for j, weight := range weights {
+		portfolioMean += weight * mean[j]
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	simulated := make([]float64, cfg.Simulations)
+	for s := range simulated {
+		growth := 1.0
+		for h := 0; h < cfg.Horizon; h++ {
+			growth *= 1 + portfolioMean + volatility*rng.NormFloat64()
+		}
+		simulated[s] = growth - 1
+	}
+	return simulated, nil
+}
@@ -0,0 +1,88 @@
+package risk
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// normalReturns returns n normal returns with the given mean and volatility.
+func normalReturns(n int, mean, volatility float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	returns := make([]float64, n)
+	for i := range returns {
+		returns[i] = mean + volatility*rng.NormFloat64()
+	}
+	return returns
+}
+
+func TestParametricVaR(t *testing.T) {
+	// VaR = -μh + zσ√h and ES = -μh + σ√h φ(z)/(1 - c) with the sample mean and volatility
+	returns := normalReturns(500, 0.0005, 0.01, 1)
+	mean, volatility := stat.Mean(returns, nil), stat.StdDev(returns, nil)
+	tests := []struct {
+		confidence float64
+		horizon    int
+		z, density float64
+	}{
+		{0.99, 1, 2.3263478740408408, 0.02665214220345808},
+		{0.95, 1, 1.6448536269514722, 0.10313564037537139},
+		{0.99, 10, 2.3263478740408408, 0.02665214220345808},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig(tt.confidence)
+		cfg.Horizon = tt.horizon
+		measure, err := Estimate(returns, ParametricNormal, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := float64(tt.horizon)
+		wantVaR := -mean*h + tt.z*volatility*math.Sqrt(h)
+		wantES := -mean*h + volatility*math.Sqrt(h)*tt.density/(1-tt.confidence)
+		if math.Abs(measure.VaR-wantVaR) > 1e-12 || math.Abs(measure.ES-wantES) > 1e-12 {
+			t.Errorf("%v over %d: got VaR %.8f, ES %.8f, want %.8f, %.8f", tt.confidence, tt.horizon, measure.VaR, measure.ES, wantVaR, wantES)
+		}
+	}
+}
+
+func TestHistoricalVaR(t *testing.T) {
+	// The 99% VaR of 100 returns from -1% to -100% is the loss at the 99th percentile
+	returns := make([]float64, 100)
+	for i := range returns {
+		returns[i] = -float64(i+1) / 100
+	}
+	measure, err := Estimate(returns, Historical, DefaultConfig(0.99))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if measure.VaR != 0.99 || measure.ES != (0.99+1)/2 {
+		t.Errorf("got VaR %v, ES %v, want 0.99, 0.995", measure.VaR, measure.ES)
+	}
+	if measure.ES < measure.VaR {
+		t.Errorf("ES %v below VaR %v", measure.ES, measure.VaR)
+	}
+}
+
+func TestEstimateInvalid(t *testing.T) {
+	returns := normalReturns(10, 0, 0.01, 2)
+	tests := []struct {
+		name    string
+		returns []float64
+		method  Method
+		cfg     Config
+	}{
+		{"no returns", nil, Historical, DefaultConfig(0.99)},
+		{"one return", returns[:1], Historical, DefaultConfig(0.99)},
+		{"confidence 1", returns, Historical, DefaultConfig(1)},
+		{"zero horizon", returns, ParametricNormal, Config{Confidence: 0.99}},
+		{"unknown method", returns, Method(9), DefaultConfig(0.99)},
+		{"no simulations", returns, MonteCarlo, Config{Confidence: 0.99, Horizon: 1}},
+	}
+	for _, tt := range tests {
+		if _, err := Estimate(tt.returns, tt.method, tt.cfg); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Process is a stochastic price process. Step advances the price by dt years and may update internal
+// state, such as a stochastic variance; Reset restores the initial state before a new path.
+type Process interface {
+	Step(rng *rand.Rand, price, dt float64) float64
+	Reset()
+}
+
+// GBM is geometric Brownian motion with annualized drift Mu and volatility Sigma.
+type GBM struct {
+	Mu    float64
+	Sigma float64
+}
+
+// NewGBM creates a GBM process.
+//
+// Parameters:
+// - mu: The annualized drift.
+// - sigma: The annualized volatility, non-negative.
+// Returns a pointer to the newly created GBM, or an error if the volatility is negative.
+func NewGBM(mu, sigma float64) (*GBM, error) {
+	if sigma < 0 || math.IsNaN(sigma) {
+		return nil, fmt.Errorf("sigma must be non-negative, got %v", sigma)
+	}
+	return &GBM{Mu: mu, Sigma: sigma}, nil
+}
+
+// Step samples the exact lognormal transition.
+func (g *GBM) Step(rng *rand.Rand, price, dt float64) float64 {
+	return price * math.Exp((g.Mu-g.Sigma*g.Sigma/2)*dt+g.Sigma*math.Sqrt(dt)*rng.NormFloat64())
+}
+
+// Reset does nothing, GBM has no state.
+func (g *GBM) Reset() {}
+
+// Heston is the Heston stochastic volatility model. The variance mean-reverts to Theta at speed Kappa with
+// volatility of variance Xi, and its shocks have correlation Rho with the price shocks.
+type Heston struct {
+	Mu    float64
+	Kappa float64
+	Theta float64
+	Xi    float64
+	Rho   float64
+	// V0 is the initial variance.
+	V0       float64
+	variance float64
+}
+
+// NewHeston creates a Heston process.
+//
+// Parameters:
+// - mu: The annualized drift.
+// - kappa: The speed of mean reversion of the variance, non-negative.
+// - theta: The long-run variance, non-negative.
+// - xi: The volatility of the variance, non-negative.
+// - rho: The correlation of price and variance shocks, in [-1, 1].
+// - v0: The initial variance, non-negative.
+// Returns a pointer to the newly created Heston, or an error if a parameter is out of range.
+func NewHeston(mu, kappa, theta, xi, rho, v0 float64) (*Heston, error) {
+	if kappa < 0 || theta < 0 || xi < 0 || v0 < 0 {
+		return nil, fmt.Errorf("kappa, theta, xi and v0 must be non-negative, got %v, %v, %v, %v", kappa, theta, xi, v0)
+	}
+	if rho < -1 || rho > 1 {
+		return nil, fmt.Errorf("rho must be in [-1, 1], got %v", rho)
+	}
+	return &Heston{Mu: mu, Kappa: kappa, Theta: theta, Xi: xi, Rho: rho, V0: v0, variance: v0}, nil
+}
+
+// Step advances the log price and the variance with the full truncation Euler scheme.
+func (h *Heston) Step(rng *rand.Rand, price, dt float64) float64 {
+	z1 := rng.NormFloat64()
+	z2 := h.Rho*z1 + math.Sqrt(1-h.Rho*h.Rho)*rng.NormFloat64()
+
+	v := math.Max(h.variance, 0)
+	next := price * math.Exp((h.Mu-v/2)*dt+math.Sqrt(v*dt)*z1)
+	h.variance += h.Kappa*(h.Theta-v)*dt + h.Xi*math.Sqrt(v*dt)*z2
+	return next
+}
+
+// Reset restores the initial variance.
+func (h *Heston) Reset() {
+	h.variance = h.V0
+}
+
+// Variance returns the current variance.
+func (h *Heston) Variance() float64 {
+	return math.Max(h.variance, 0)
+}
+
+// MertonJumpDiffusion is geometric Brownian motion with lognormal jumps arriving at annual intensity
+// Lambda. The log jump sizes are normal with mean JumpMean and standard deviation JumpStd, and the drift
+// is compensated so that Mu remains the expected return.
+type MertonJumpDiffusion struct {
+	Mu       float64
+	Sigma    float64
+	Lambda   float64
+	JumpMean float64
+	JumpStd  float64
+}
+
+// NewMertonJumpDiffusion creates a MertonJumpDiffusion process.
+//
+// Parameters:
+// - mu: The annualized expected return.
+// - sigma: The annualized diffusion volatility, non-negative.
+// - lambda: The expected number of jumps per year, non-negative.
+// - jumpMean: The mean of the log jump size.
+// - jumpStd: The standard deviation of the log jump size, non-negative.
+// Returns a pointer to the newly created MertonJumpDiffusion, or an error if a parameter is out of range.
+func NewMertonJumpDiffusion(mu, sigma, lambda, jumpMean, jumpStd float64) (*MertonJumpDiffusion, error) {
+	if sigma < 0 || lambda < 0 || jumpStd < 0 {
+		return nil, fmt.Errorf("sigma, lambda and jumpStd must be non-negative, got %v, %v, %v", sigma, lambda, jumpStd)
+	}
+	return &MertonJumpDiffusion{Mu: mu, Sigma: sigma, Lambda: lambda, JumpMean: jumpMean, JumpStd: jumpStd}, nil
+}
+
+// Step samples the diffusion exactly and adds a Poisson number of jumps.
+func (m *MertonJumpDiffusion) Step(rng *rand.Rand, price, dt float64) float64 {
+	compensator := m.Lambda * (math.Exp(m.JumpMean+m.JumpStd*m.JumpStd/2) - 1)
+	logReturn := (m.Mu-compensator-m.Sigma*m.Sigma/2)*dt + m.Sigma*math.Sqrt(dt)*rng.NormFloat64()
+	for jumps := poisson(rng, m.Lambda*dt); jumps > 0; jumps-- {
+		logReturn += m.JumpMean + m.JumpStd*rng.NormFloat64()
+	}
+	return price * math.Exp(logReturn)
+}
+
+// Reset does nothing, the jump diffusion has no state.
+func (m *MertonJumpDiffusion) Reset() {}
+
+// poisson samples a Poisson random variable with Knuth's method, which is fast for the small means of a time step.
+func poisson(rng *rand.Rand, mean float64) int {
+	limit := math.Exp(-mean)
+	count, product := 0, rng.Float64()
+	for product > limit {
+		count++
+		product *= rng.Float64()
+	}
+	return count
+}
+
+// GARCH is a GARCH(1,1) process of log returns. Its parameters are per step rather than annualized:
+// r = Mu + sqrt(h) z and h' = Omega + Alpha (r - Mu)² + Beta h.
+type GARCH struct {
+	Mu       float64
+	Omega    float64
+	Alpha    float64
+	Beta     float64
+	variance float64
+}
+
+// NewGARCH creates a GARCH(1,1) process that starts at its unconditional variance.
+//
+// Parameters:
+// - mu: The mean log return per step.
+// - omega: The constant of the variance equation, positive.
+// - alpha: The weight of the last squared shock, non-negative.
+// - beta: The weight of the last variance, non-negative, with alpha + beta < 1.
+// Returns a pointer to the newly created GARCH, or an error if the process is not stationary.
+func NewGARCH(mu, omega, alpha, beta float64) (*GARCH, error) {
+	if omega <= 0 || alpha < 0 || beta < 0 {
+		return nil, fmt.Errorf("omega must be positive and alpha, beta non-negative, got %v, %v, %v", omega, alpha, beta)
+	}
+	if alpha+beta >= 1 {
+		return nil, fmt.Errorf("alpha + beta must be below 1 for stationarity, got %v", alpha+beta)
+	}
+	g := &GARCH{Mu: mu, Omega: omega, Alpha: alpha, Beta: beta}
+	g.Reset()
+	return g, nil
+}
+
+// Step draws one log return and updates the conditional variance. The time step is ignored.
+func (g *GARCH) Step(rng *rand.Rand, price, dt float64) float64 {
+	shock := math.Sqrt(g.variance) * rng.NormFloat64()
+	g.variance = g.Omega + g.Alpha*shock*shock + g.Beta*g.variance
+	return price * math.Exp(g.Mu+shock)
+}
+
+// Reset restores the unconditional variance.
+func (g *GARCH) Reset() {
+	g.variance = g.Omega / (1 - g.Alpha - g.Beta)
+}
+
+// OrnsteinUhlenbeck mean-reverts the log price towards log(Mean) at speed Kappa with annualized volatility
+// Sigma, which suits testing mean-reversion and pairs strategies.
+type OrnsteinUhlenbeck struct {
+	Kappa float64
+	Mean  float64
+	Sigma float64
+}
+
+// NewOrnsteinUhlenbeck creates an OrnsteinUhlenbeck process.
+//
+// Parameters:
+// - kappa: The annualized speed of mean reversion, positive.
+// - mean: The price level the process reverts to, positive.
+// - sigma: The annualized volatility of the log price, non-negative.
+// Returns a pointer to the newly created OrnsteinUhlenbeck, or an error if a parameter is out of range.
+func NewOrnsteinUhlenbeck(kappa, mean, sigma float64) (*OrnsteinUhlenbeck, error) {
+	if kappa <= 0 || mean <= 0 || sigma < 0 {
+		return nil, fmt.Errorf("kappa and mean must be positive and sigma non-negative, got %v, %v, %v", kappa, mean, sigma)
+	}
+	return &OrnsteinUhlenbeck{Kappa: kappa, Mean: mean, Sigma: sigma}, nil
+}
+
+// Step samples the exact Gaussian transition of the log price.
+func (o *OrnsteinUhlenbeck) Step(rng *rand.Rand, price, dt float64) float64 {
+	target := math.Log(o.Mean)
+	decay := math.Exp(-o.Kappa * dt)
+	stdDev := o.Sigma * math.Sqrt((1-decay*decay)/(2*o.Kappa))
+	return math.Exp(target + (math.Log(price)-target)*decay + stdDev*rng.NormFloat64())
+}
+
+// Reset does nothing, the process has no state besides the price.
+func (o *OrnsteinUhlenbeck) Reset() {}
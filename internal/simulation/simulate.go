@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// year is the length of the year that annualized process parameters refer to.
+const year = 365 * 24 * time.Hour
+
+// Config describes the bars produced by Simulate.
+type Config struct {
+	Ticker string
+	// Start is the timestamp of the first bar.
+	Start    time.Time
+	Interval time.Duration
+	Bars     int
+	// StartPrice is the open of the first bar.
+	StartPrice float64
+	// StepsPerBar is the number of process steps within a bar, which shape its high and low.
+	StepsPerBar int
+	// BaseVolume is the median volume of a bar with an average move.
+	BaseVolume float64
+	Seed       int64
+}
+
+// DefaultConfig returns a configuration for daily bars starting on 2020-01-01 at a price of 100.
+func DefaultConfig(ticker string, bars int, seed int64) Config {
+	return Config{
+		Ticker:      ticker,
+		Start:       time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Interval:    24 * time.Hour,
+		Bars:        bars,
+		StartPrice:  100,
+		StepsPerBar: 24,
+		BaseVolume:  1_000_000,
+		Seed:        seed,
+	}
+}
+
+// validate checks the configuration.
+func (c Config) validate() error {
+	switch {
+	case c.Bars < 1:
+		return fmt.Errorf("bars must be at least 1, got %d", c.Bars)
+	case c.Interval <= 0:
+		return fmt.Errorf("interval must be positive, got %v", c.Interval)
+	case c.StartPrice <= 0:
+		return fmt.Errorf("start price must be positive, got %v", c.StartPrice)
+	case c.StepsPerBar < 1:
+		return fmt.Errorf("steps per bar must be at least 1, got %d", c.StepsPerBar)
+	case c.BaseVolume < 0:
+		return fmt.Errorf("base volume must be non-negative, got %v", c.BaseVolume)
+	}
+	return nil
+}
+
+// Simulate generates OHLCV bars from a process. Every bar opens at the previous close and runs
+// StepsPerBar process steps; the high and low are the extremes of the path and the close its last price.
+// The volume is lognormal around BaseVolume and rises with the size of the bar's range, as in real markets.
+// The same process parameters, configuration and seed always produce the same bars.
+//
+// Parameters:
+// - process: The price process. It is reset before the path starts.
+// - cfg: The ticker, timing, starting price, volume level and seed.
+// Returns the bars, oldest first, or an error if the configuration is invalid.
+func Simulate(process Process, cfg Config) ([]data_types.MarketData, error) {
+	if process == nil {
+		return nil, errors.New("process must not be nil")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	process.Reset()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	dt := cfg.Interval.Seconds() / year.Seconds() / float64(cfg.StepsPerBar)
+
+	bars := make([]data_types.MarketData, cfg.Bars)
+	price := cfg.StartPrice
+	averageRange := 0.0
+	for i := range bars {
+		open := price
+		high, low := open, open
+		for s := 0; s < cfg.StepsPerBar; s++ {
+			price = process.Step(rng, price, dt)
+			high = math.Max(high, price)
+			low = math.Min(low, price)
+		}
+
+		// Scale the volume with the bar's range relative to a running average of ranges
+		logRange := math.Log(high / low)
+		if i == 0 {
+			averageRange = logRange
+		} else {
+			averageRange = 0.95*averageRange + 0.05*logRange
+		}
+		activity := 1.0
+		if averageRange > 0 {
+			activity = 0.5 + 0.5*logRange/averageRange
+		}
+		volume := cfg.BaseVolume * activity * math.Exp(0.25*rng.NormFloat64())
+
+		bars[i] = data_types.MarketData{
+			Ticker:    cfg.Ticker,
+			Timestamp: cfg.Start.Add(time.Duration(i) * cfg.Interval).Unix(),
+			Open:      open,
+			High:      high,
+			Low:       low,
+			Close:     price,
+			Volume:    math.Round(volume),
+		}
+	}
+	return bars, nil
+}
+
+// SimulateMany generates bars for several tickers from independent copies of the same configuration,
+// seeding each ticker with cfg.Seed plus its index.
+//
+// Parameters:
+// - processes: The process of every ticker, keyed by ticker.
+// - tickers: The tickers in the order used for seeding.
+// - cfg: The common configuration. Its Ticker is replaced.
+// Returns the bars per ticker, or an error if any simulation fails.
+func SimulateMany(processes map[string]Process, tickers []string, cfg Config) (map[string][]data_types.MarketData, error) {
+	result := make(map[string][]data_types.MarketData, len(tickers))
+	for i, ticker := range tickers {
+		process, ok := processes[ticker]
+		if !ok {
+			return nil, fmt.Errorf("no process for ticker %s", ticker)
+		}
+		tickerCfg := cfg
+		tickerCfg.Ticker = ticker
+		tickerCfg.Seed = cfg.Seed + int64(i)
+		bars, err := Simulate(process, tickerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ticker, err)
+		}
+		result[ticker] = bars
+	}
+	return result, nil
+}
@@ -0,0 +1,233 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	data_types "goquant/pkg/data"
+)
+
+// logReturns returns the close-to-close log returns of bars, the first one from the start price.
+func logReturns(bars []data_types.MarketData, startPrice float64) []float64 {
+	returns := make([]float64, len(bars))
+	previous := startPrice
+	for i, bar := range bars {
+		returns[i] = math.Log(bar.Close / previous)
+		previous = bar.Close
+	}
+	return returns
+}
+
+// meanVariance returns the sample mean and variance.
+func meanVariance(xs []float64) (float64, float64) {
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	variance := 0.0
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	return mean, variance / float64(len(xs)-1)
+}
+
+func simulate(t *testing.T, process Process, cfg Config) []data_types.MarketData {
+	t.Helper()
+	bars, err := Simulate(process, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bars
+}
+
+func TestSimulateSeed(t *testing.T) {
+	heston, _ := NewHeston(0.05, 2, 0.04, 0.3, -0.7, 0.04)
+	cfg := DefaultConfig("SIM", 250, 7)
+	first := simulate(t, heston, cfg)
+	// The process is reset, so running it again gives the same bars
+	again := simulate(t, heston, cfg)
+	for i := range first {
+		if first[i] != again[i] {
+			t.Fatalf("bar %d: got %+v, then %+v", i, first[i], again[i])
+		}
+	}
+
+	cfg.Seed = 8
+	other := simulate(t, heston, cfg)
+	same := 0
+	for i := range first {
+		if first[i].Close == other[i].Close {
+			same++
+		}
+	}
+	if same > 0 {
+		t.Errorf("seeds 7 and 8 share %d closes of %d", same, len(first))
+	}
+}
+
+func TestSimulateBars(t *testing.T) {
+	gbm, _ := NewGBM(0.05, 0.3)
+	heston, _ := NewHeston(0.05, 2, 0.04, 0.5, -0.7, 0.09)
+	merton, _ := NewMertonJumpDiffusion(0.05, 0.2, 5, -0.05, 0.1)
+	garch, _ := NewGARCH(0, 1e-5, 0.1, 0.85)
+	ou, _ := NewOrnsteinUhlenbeck(5, 100, 0.3)
+	processes := map[string]Process{"gbm": gbm, "heston": heston, "merton": merton, "garch": garch, "ou": ou}
+
+	cfg := DefaultConfig("SIM", 1000, 1)
+	cfg.Interval = time.Hour
+	cfg.StepsPerBar = 12
+	for name, process := range processes {
+		bars := simulate(t, process, cfg)
+		open := cfg.StartPrice
+		for i, bar := range bars {
+			if err := bar.Validate(); err != nil {
+				t.Fatalf("%s: bar %d: %v", name, i, err)
+			}
+			if bar.Low > math.Min(bar.Open, bar.Close) || bar.High < math.Max(bar.Open, bar.Close) || bar.Volume < 0 {
+				t.Fatalf("%s: bar %d: %+v", name, i, bar)
+			}
+			// Bars follow each other without gaps, each opening at the previous close
+			if bar.Open != open || bar.Timestamp != cfg.Start.Add(time.Duration(i)*cfg.Interval).Unix() || bar.Ticker != "SIM" {
+				t.Fatalf("%s: bar %d: %+v, want an open of %v", name, i, bar, open)
+			}
+			open = bar.Close
+		}
+	}
+}
+
+func TestSimulateGBMMoments(t *testing.T) {
+	// Daily log returns are normal with mean (μ - σ²/2)dt and variance σ²dt
+	mu, sigma := 2.0, 0.2
+	gbm, _ := NewGBM(mu, sigma)
+	cfg := DefaultConfig("SIM", 20000, 3)
+	mean, variance := meanVariance(logReturns(simulate(t, gbm, cfg), cfg.StartPrice))
+
+	dt := 1.0 / 365
+	wantMean, wantVariance := (mu-sigma*sigma/2)*dt, sigma*sigma*dt
+	// Four standard errors of the sample mean and variance
+	if se := math.Sqrt(wantVariance / float64(cfg.Bars)); math.Abs(mean-wantMean) > 4*se {
+		t.Errorf("mean: got %.6f, want %.6f ± %.6f", mean, wantMean, 4*se)
+	}
+	if se := wantVariance * math.Sqrt(2/float64(cfg.Bars)); math.Abs(variance-wantVariance) > 4*se {
+		t.Errorf("variance: got %.4g, want %.4g ± %.2g", variance, wantVariance, 4*se)
+	}
+}
+
+func TestSimulateOrnsteinUhlenbeck(t *testing.T) {
+	// Starting well above the mean, the log price decays towards log(Mean) by exp(-κ dt) a bar
+	kappa, mean, sigma := 5.0, 100.0, 0.1
+	ou, _ := NewOrnsteinUhlenbeck(kappa, mean, sigma)
+	cfg := DefaultConfig("SIM", 20000, 4)
+	cfg.StartPrice = 150
+	bars := simulate(t, ou, cfg)
+
+	// After a year, five times the relaxation time, the start is forgotten
+	target := math.Log(mean)
+	stationary := sigma / math.Sqrt(2*kappa)
+	if deviation := math.Log(bars[364].Close) - target; math.Abs(deviation) > 4*stationary {
+		t.Errorf("after a year: log deviation %.4f, want within %.4f", deviation, 4*stationary)
+	}
+
+	// The deviations form an AR(1) series with coefficient exp(-κ dt) and a mean of zero
+	var cross, squares, sum float64
+	previous := math.Log(cfg.StartPrice) - target
+	for _, bar := range bars {
+		deviation := math.Log(bar.Close) - target
+		cross += previous * deviation
+		squares += previous * previous
+		sum += deviation
+		previous = deviation
+	}
+	want := math.Exp(-kappa / 365)
+	if got := cross / squares; math.Abs(got-want) > 0.005 {
+		t.Errorf("autoregression: got %.4f, want %.4f", got, want)
+	}
+	if got := sum / float64(len(bars)); math.Abs(got) > 0.02 {
+		t.Errorf("mean log deviation: got %.4f, want 0", got)
+	}
+}
+
+func TestSimulateGARCH(t *testing.T) {
+	// With a step per bar the log returns are the GARCH returns, whose variance is ω / (1 - α - β)
+	mu, omega, alpha, beta := 0.0005, 1e-5, 0.1, 0.85
+	garch, _ := NewGARCH(mu, omega, alpha, beta)
+	cfg := DefaultConfig("SIM", 100000, 5)
+	cfg.StepsPerBar = 1
+	mean, variance := meanVariance(logReturns(simulate(t, garch, cfg), cfg.StartPrice))
+
+	want := omega / (1 - alpha - beta)
+	if math.Abs(variance-want) > 0.1*want {
+		t.Errorf("variance: got %.4g, want %.4g ± 10%%", variance, want)
+	}
+	if se := math.Sqrt(want / float64(cfg.Bars)); math.Abs(mean-mu) > 4*se {
+		t.Errorf("mean: got %.6f, want %.6f ± %.6f", mean, mu, 4*se)
+	}
+
+	if _, err := NewGARCH(mu, omega, 0.2, 0.8); err == nil {
+		t.Error("alpha + beta = 1: no error")
+	}
+}
+
+func TestSimulateMany(t *testing.T) {
+	// Each ticker is seeded with the seed of the configuration plus its index
+	gbm, _ := NewGBM(0.05, 0.2)
+	garch, _ := NewGARCH(0, 1e-5, 0.1, 0.85)
+	cfg := DefaultConfig("", 50, 10)
+	tickers := []string{"AAA", "BBB", "CCC"}
+	result, err := SimulateMany(map[string]Process{"AAA": gbm, "BBB": garch, "CCC": gbm}, tickers, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ticker := range tickers {
+		single := cfg
+		single.Ticker = ticker
+		single.Seed = cfg.Seed + int64(i)
+		process := Process(gbm)
+		if ticker == "BBB" {
+			process = garch
+		}
+		want := simulate(t, process, single)
+		got := result[ticker]
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d bars, want %d", ticker, len(got), len(want))
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("%s: bar %d: got %+v, want %+v", ticker, j, got[j], want[j])
+			}
+		}
+	}
+	if result["AAA"][49].Close == result["CCC"][49].Close {
+		t.Error("AAA and CCC share a process and got the same path")
+	}
+
+	if _, err := SimulateMany(map[string]Process{"AAA": gbm}, tickers, cfg); err == nil {
+		t.Error("missing process: no error")
+	}
+}
+
+func TestSimulateInvalid(t *testing.T) {
+	gbm, _ := NewGBM(0.05, 0.2)
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"no bars", func(c *Config) { c.Bars = 0 }},
+		{"no interval", func(c *Config) { c.Interval = 0 }},
+		{"zero price", func(c *Config) { c.StartPrice = 0 }},
+		{"no steps", func(c *Config) { c.StepsPerBar = 0 }},
+		{"negative volume", func(c *Config) { c.BaseVolume = -1 }},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig("SIM", 10, 1)
+		tt.modify(&cfg)
+		if _, err := Simulate(gbm, cfg); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	if _, err := Simulate(nil, DefaultConfig("SIM", 10, 1)); err == nil {
+		t.Error("nil process: no error")
+	}
+}
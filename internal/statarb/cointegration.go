@@ -0,0 +1,294 @@
+package statarb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// engleGrangerCriticalValues are the MacKinnon (2010) critical values at 1%, 5% and 10% of the
+// Engle-Granger test for two variables with a constant.
+var engleGrangerCriticalValues = [3]float64{-3.90, -3.34, -3.04}
+
+// johansenTraceCriticalValues and johansenMaxEigenCriticalValues are the 90%, 95% and 99% critical values
+// of the Johansen test with a constant (MacKinnon, Haug and Michelis, 1999), indexed by n-r-1.
+var johansenTraceCriticalValues = [][3]float64{
+	{2.7055, 3.8415, 6.6349},
+	{13.4294, 15.4943, 19.9349},
+	{27.0669, 29.7961, 35.4628},
+	{44.4929, 47.8545, 54.6815},
+	{65.8202, 69.8189, 77.8202},
+}
+
+var johansenMaxEigenCriticalValues = [][3]float64{
+	{2.7055, 3.8415, 6.6349},
+	{12.2971, 14.2639, 18.5200},
+	{18.8928, 21.1314, 25.8650},
+	{25.1236, 27.5858, 32.7172},
+	{31.2379, 33.8777, 39.3693},
+}
+
+// EngleGrangerResult holds the result of an Engle-Granger cointegration test.
+type EngleGrangerResult struct {
+	// Hedge is the cointegrating regression y = Alpha + Beta*x.
+	Hedge HedgeEstimate
+	// ADFStatistic is the augmented Dickey-Fuller t-statistic of the regression residuals.
+	ADFStatistic float64
+	// CriticalValues holds the 1%, 5% and 10% critical values.
+	CriticalValues [3]float64
+	Residuals      []float64
+}
+
+// Cointegrated reports whether the null hypothesis of no cointegration is rejected at the 5% level.
+func (r EngleGrangerResult) Cointegrated() bool {
+	return r.ADFStatistic < r.CriticalValues[1]
+}
+
+// EngleGranger runs the two-step Engle-Granger cointegration test of y and x.
+//
+// Parameters:
+// - y: The first price series.
+// - x: The second price series, of the same length as y.
+// - lags: The number of lagged differences in the augmented Dickey-Fuller regression of the residuals.
+// Returns the EngleGrangerResult, or an error if the series are unsuitable.
+func EngleGranger(y, x []float64, lags int) (EngleGrangerResult, error) {
+	hedge, err := OLS(y, x)
+	if err != nil {
+		return EngleGrangerResult{}, err
+	}
+
+	residuals := make([]float64, len(y))
+	for i := range y {
+		residuals[i] = y[i] - hedge.Alpha - hedge.Beta*x[i]
+	}
+
+	stat, err := ADF(residuals, lags)
+	if err != nil {
+		return EngleGrangerResult{}, err
+	}
+
+	return EngleGrangerResult{
+		Hedge:          hedge,
+		ADFStatistic:   stat,
+		CriticalValues: engleGrangerCriticalValues,
+		Residuals:      residuals,
+	}, nil
+}
+
+// ADF returns the augmented Dickey-Fuller t-statistic of the series, using a regression of the
+// first difference on a constant, the lagged level and the given number of lagged differences.
+func ADF(series []float64, lags int) (float64, error) {
+	if lags < 0 {
+		return 0, fmt.Errorf("lags must be non-negative, got %d", lags)
+	}
+
+	n := len(series) - 1 - lags
+	k := 2 + lags
+	if n <= k {
+		return 0, fmt.Errorf("series of length %d is too short for %d lags", len(series), lags)
+	}
+
+	diff := make([]float64, len(series)-1)
+	for i := 1; i < len(series); i++ {
+		diff[i-1] = series[i] - series[i-1]
+	}
+
+	X := mat.NewDense(n, k, nil)
+	y := make([]float64, n)
+	for row := 0; row < n; row++ {
+		t := row + lags // index into diff
+		y[row] = diff[t]
+		X.Set(row, 0, 1)
+		X.Set(row, 1, series[t])
+		for l := 1; l <= lags; l++ {
+			X.Set(row, 1+l, diff[t-l])
+		}
+	}
+
+	coef, stdErr, _, err := Regress(X, y)
+	if err != nil {
+		return 0, err
+	}
+	if stdErr[1] == 0 {
+		return 0, errors.New("degenerate series")
+	}
+	return coef[1] / stdErr[1], nil
+}
+
+// JohansenResult holds the result of a Johansen cointegration test.
+type JohansenResult struct {
+	// Eigenvalues are sorted in descending order.
+	Eigenvalues []float64
+	// Vectors holds the cointegrating vectors as columns, in the order of Eigenvalues.
+	Vectors *mat.Dense
+	// TraceStatistics and MaxEigenStatistics hold the statistics for the null hypotheses r <= 0, 1, ...
+	TraceStatistics    []float64
+	MaxEigenStatistics []float64
+	// TraceCriticalValues and MaxEigenCriticalValues hold the 90%, 95% and 99% critical values for each null hypothesis.
+	TraceCriticalValues    [][3]float64
+	MaxEigenCriticalValues [][3]float64
+}
+
+// Rank returns the cointegration rank selected by the trace test at the 95% level.
+func (r JohansenResult) Rank() int {
+	for i, stat := range r.TraceStatistics {
+		if stat < r.TraceCriticalValues[i][1] {
+			return i
+		}
+	}
+	return len(r.TraceStatistics)
+}
+
+// HedgeRatio returns the hedge ratio of the second series against the first implied by the
+// strongest cointegrating vector, so that series[0] - ratio*series[1] is stationary.
+func (r JohansenResult) HedgeRatio() float64 {
+	return -r.Vectors.At(1, 0) / r.Vectors.At(0, 0)
+}
+
+// Johansen runs the Johansen cointegration test with a constant on two to five price series.
+//
+// Parameters:
+// - series: The price series, all of the same length.
+// - lags: The number of lagged differences in the vector error correction model.
+// Returns the JohansenResult, or an error if the series are unsuitable.
+func Johansen(series [][]float64, lags int) (JohansenResult, error) {
+	k := len(series)
+	if k < 2 || k > len(johansenTraceCriticalValues) {
+		return JohansenResult{}, fmt.Errorf("johansen test supports 2 to %d series, got %d", len(johansenTraceCriticalValues), k)
+	}
+	if lags < 0 {
+		return JohansenResult{}, fmt.Errorf("lags must be non-negative, got %d", lags)
+	}
+	length := len(series[0])
+	for i, s := range series {
+		if len(s) != length {
+			return JohansenResult{}, fmt.Errorf("series %d has length %d, expected %d", i, len(s), length)
+		}
+	}
+
+	n := length - 1 - lags
+	regressors := 1 + k*lags
+	if n <= regressors+k {
+		return JohansenResult{}, fmt.Errorf("series of length %d are too short for %d lags", length, lags)
+	}
+
+	// Build the differenced and lagged level matrices and the short-run regressors
+	dY := mat.NewDense(n, k, nil)
+	yLag := mat.NewDense(n, k, nil)
+	Z := mat.NewDense(n, regressors, nil)
+	for row := 0; row < n; row++ {
+		t := row + lags + 1
+		Z.Set(row, 0, 1)
+		for j := 0; j < k; j++ {
+			dY.Set(row, j, series[j][t]-series[j][t-1])
+			yLag.Set(row, j, series[j][t-1])
+			for l := 1; l <= lags; l++ {
+				Z.Set(row, 1+(l-1)*k+j, series[j][t-l]-series[j][t-l-1])
+			}
+		}
+	}
+
+	R0, err := residualize(dY, Z)
+	if err != nil {
+		return JohansenResult{}, err
+	}
+	R1, err := residualize(yLag, Z)
+	if err != nil {
+		return JohansenResult{}, err
+	}
+
+	var S00, S01, S11 mat.Dense
+	S00.Mul(R0.T(), R0)
+	S00.Scale(1/float64(n), &S00)
+	S01.Mul(R0.T(), R1)
+	S01.Scale(1/float64(n), &S01)
+	S11.Mul(R1.T(), R1)
+	S11.Scale(1/float64(n), &S11)
+
+	// Solve |lambda*S11 - S10 S00^-1 S01| = 0 via the Cholesky factor of S11
+	var chol mat.Cholesky
+	if ok := chol.Factorize(mat.NewSymDense(k, S11.RawMatrix().Data)); !ok {
+		return JohansenResult{}, errors.New("lagged levels are collinear")
+	}
+	var L, LInv mat.TriDense
+	chol.LTo(&L)
+	if err := LInv.InverseTri(&L); err != nil {
+		return JohansenResult{}, err
+	}
+
+	var S00Inv mat.Dense
+	if err := S00Inv.Inverse(&S00); err != nil {
+		return JohansenResult{}, err
+	}
+	var M, C mat.Dense
+	M.Product(S01.T(), &S00Inv, &S01)
+	C.Product(&LInv, &M, LInv.T())
+
+	sym := mat.NewSymDense(k, nil)
+	for i := 0; i < k; i++ {
+		for j := i; j < k; j++ {
+			sym.SetSym(i, j, (C.At(i, j)+C.At(j, i))/2)
+		}
+	}
+	var eig mat.EigenSym
+	if ok := eig.Factorize(sym, true); !ok {
+		return JohansenResult{}, errors.New("eigendecomposition failed")
+	}
+	values := eig.Values(nil)
+	var vectors, betas mat.Dense
+	eig.VectorsTo(&vectors)
+	betas.Mul(LInv.T(), &vectors)
+
+	// Sort eigenvalues and vectors in descending order
+	order := make([]int, k)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return values[order[a]] > values[order[b]] })
+
+	result := JohansenResult{
+		Eigenvalues: make([]float64, k),
+		Vectors:     mat.NewDense(k, k, nil),
+	}
+	for i, idx := range order {
+		result.Eigenvalues[i] = math.Min(math.Max(values[idx], 0), 1-1e-12)
+		for j := 0; j < k; j++ {
+			result.Vectors.Set(j, i, betas.At(j, idx))
+		}
+	}
+
+	for r := 0; r < k; r++ {
+		trace := 0.0
+		for i := r; i < k; i++ {
+			trace -= float64(n) * math.Log(1-result.Eigenvalues[i])
+		}
+		result.TraceStatistics = append(result.TraceStatistics, trace)
+		result.MaxEigenStatistics = append(result.MaxEigenStatistics, -float64(n)*math.Log(1-result.Eigenvalues[r]))
+		result.TraceCriticalValues = append(result.TraceCriticalValues, johansenTraceCriticalValues[k-r-1])
+		result.MaxEigenCriticalValues = append(result.MaxEigenCriticalValues, johansenMaxEigenCriticalValues[k-r-1])
+	}
+
+	return result, nil
+}
+
+// residualize returns the residuals of regressing every column of Y on Z.
+func residualize(Y, Z *mat.Dense) (*mat.Dense, error) {
+	var qr mat.QR
+	qr.Factorize(Z)
+	_, k := Z.Dims()
+	_, cols := Y.Dims()
+
+	var coef mat.Dense
+	coef.ReuseAs(k, cols)
+	if err := qr.SolveTo(&coef, false, Y); err != nil {
+		return nil, err
+	}
+
+	var fitted, residuals mat.Dense
+	fitted.Mul(Z, &coef)
+	residuals.Sub(Y, &fitted)
+	return &residuals, nil
+}
@@ -0,0 +1,98 @@
+package statarb
+
+import (
+	"fmt"
+	"math"
+)
+
+// KalmanHedge estimates a time-varying hedge ratio y_t = Alpha_t + Beta_t*x_t with a Kalman filter
+// in which intercept and slope follow random walks.
+type KalmanHedge struct {
+	// Delta controls how fast the hedge ratio may drift; the state noise covariance is Delta/(1-Delta) * I.
+	Delta float64
+	// ObservationVariance is the variance of the measurement noise.
+	ObservationVariance float64
+}
+
+// KalmanStep holds the filter output at one point in time.
+type KalmanStep struct {
+	// Hedge is the estimate after observing the current bar.
+	Hedge HedgeEstimate
+	// ForecastError is y_t minus its prediction from the previous estimate, i.e. the spread
+	// before updating, and ForecastVariance is its variance.
+	ForecastError    float64
+	ForecastVariance float64
+}
+
+// ZScore returns the standardized forecast error.
+func (s KalmanStep) ZScore() float64 {
+	if s.ForecastVariance <= 0 {
+		return 0
+	}
+	return s.ForecastError / math.Sqrt(s.ForecastVariance)
+}
+
+// NewKalmanHedge creates a new KalmanHedge.
+//
+// Parameters:
+// - delta: The drift parameter in (0, 1), typically around 1e-4.
+// - observationVariance: The measurement noise variance, positive.
+// Returns a pointer to the newly created KalmanHedge, or an error if the parameters are invalid.
+func NewKalmanHedge(delta, observationVariance float64) (*KalmanHedge, error) {
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("delta must be in (0, 1), got %v", delta)
+	}
+	if observationVariance <= 0 {
+		return nil, fmt.Errorf("observation variance must be positive, got %v", observationVariance)
+	}
+	return &KalmanHedge{Delta: delta, ObservationVariance: observationVariance}, nil
+}
+
+// Filter runs the filter over the series. Every step only uses observations up to and including it.
+func (k *KalmanHedge) Filter(y, x []float64) ([]KalmanStep, error) {
+	if len(y) != len(x) {
+		return nil, fmt.Errorf("series lengths differ: %d and %d", len(y), len(x))
+	}
+
+	// State is [beta, alpha] with covariance P
+	beta, alpha := 0.0, 0.0
+	P := [2][2]float64{}
+	drift := k.Delta / (1 - k.Delta)
+
+	steps := make([]KalmanStep, len(y))
+	for t := range y {
+		// Predict: random walk keeps the state and adds drift noise
+		R := [2][2]float64{
+			{P[0][0] + drift, P[0][1]},
+			{P[1][0], P[1][1] + drift},
+		}
+
+		// Observation vector is [x_t, 1]
+		obs := [2]float64{x[t], 1}
+		forecast := beta*x[t] + alpha
+		err := y[t] - forecast
+
+		Rh := [2]float64{
+			R[0][0]*obs[0] + R[0][1]*obs[1],
+			R[1][0]*obs[0] + R[1][1]*obs[1],
+		}
+		variance := obs[0]*Rh[0] + obs[1]*Rh[1] + k.ObservationVariance
+
+		// Update
+		gain := [2]float64{Rh[0] / variance, Rh[1] / variance}
+		beta += gain[0] * err
+		alpha += gain[1] * err
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 2; j++ {
+				P[i][j] = R[i][j] - gain[i]*Rh[j]
+			}
+		}
+
+		steps[t] = KalmanStep{
+			Hedge:            HedgeEstimate{Alpha: alpha, Beta: beta},
+			ForecastError:    err,
+			ForecastVariance: variance,
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,117 @@
+package statarb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// HedgeEstimate holds the intercept and hedge ratio of y = Alpha + Beta*x at one point in time.
+type HedgeEstimate struct {
+	Alpha float64
+	Beta  float64
+}
+
+// OLS regresses y on x with an intercept.
+//
+// Parameters:
+// - y: The dependent series.
+// - x: The independent series, of the same length as y.
+// Returns the estimated intercept and slope, and an error if the series are too short or x is constant.
+func OLS(y, x []float64) (HedgeEstimate, error) {
+	if len(y) != len(x) {
+		return HedgeEstimate{}, fmt.Errorf("series lengths differ: %d and %d", len(y), len(x))
+	}
+	if len(y) < 2 {
+		return HedgeEstimate{}, errors.New("need at least 2 observations")
+	}
+
+	n := float64(len(y))
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	covXY, varX := 0.0, 0.0
+	for i := range x {
+		covXY += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if varX == 0 {
+		return HedgeEstimate{}, errors.New("independent series is constant")
+	}
+
+	beta := covXY / varX
+	return HedgeEstimate{Alpha: meanY - beta*meanX, Beta: beta}, nil
+}
+
+// RollingOLS estimates the hedge ratio over a rolling window. The estimate at index i only uses
+// observations up to and including i; indices before the first full window hold the zero value.
+func RollingOLS(y, x []float64, window int) ([]HedgeEstimate, error) {
+	if len(y) != len(x) {
+		return nil, fmt.Errorf("series lengths differ: %d and %d", len(y), len(x))
+	}
+	if window < 2 {
+		return nil, fmt.Errorf("window must be at least 2, got %d", window)
+	}
+
+	estimates := make([]HedgeEstimate, len(y))
+	for i := window - 1; i < len(y); i++ {
+		estimate, err := OLS(y[i-window+1:i+1], x[i-window+1:i+1])
+		if err != nil {
+			continue // Constant window, leave the zero value
+		}
+		estimates[i] = estimate
+	}
+	return estimates, nil
+}
+
+// Regress solves the least squares problem y = X b and returns the coefficients, their standard
+// errors and the residuals.
+func Regress(X *mat.Dense, y []float64) ([]float64, []float64, []float64, error) {
+	n, k := X.Dims()
+	if n <= k {
+		return nil, nil, nil, fmt.Errorf("need more than %d observations, got %d", k, n)
+	}
+
+	var qr mat.QR
+	qr.Factorize(X)
+	b := mat.NewDense(k, 1, nil)
+	if err := qr.SolveTo(b, false, mat.NewDense(n, 1, y)); err != nil {
+		return nil, nil, nil, err
+	}
+
+	coef := make([]float64, k)
+	for i := range coef {
+		coef[i] = b.At(i, 0)
+	}
+
+	residuals := make([]float64, n)
+	rss := 0.0
+	for i := 0; i < n; i++ {
+		fitted := 0.0
+		for j := 0; j < k; j++ {
+			fitted += X.At(i, j) * coef[j]
+		}
+		residuals[i] = y[i] - fitted
+		rss += residuals[i] * residuals[i]
+	}
+	sigma2 := rss / float64(n-k)
+
+	var xtx, xtxInv mat.Dense
+	xtx.Mul(X.T(), X)
+	if err := xtxInv.Inverse(&xtx); err != nil {
+		return nil, nil, nil, err
+	}
+	stdErr := make([]float64, k)
+	for i := range stdErr {
+		stdErr[i] = math.Sqrt(sigma2 * xtxInv.At(i, i))
+	}
+
+	return coef, stdErr, residuals, nil
+}
@@ -0,0 +1,78 @@
+package statarb
+
+import (
+	"fmt"
+	"math"
+)
+
+// Spread returns y - (Alpha + Beta*x) for every index using the hedge estimate at that index.
+func Spread(y, x []float64, hedges []HedgeEstimate) ([]float64, error) {
+	if len(y) != len(x) || len(y) != len(hedges) {
+		return nil, fmt.Errorf("lengths differ: %d, %d and %d", len(y), len(x), len(hedges))
+	}
+
+	spread := make([]float64, len(y))
+	for i := range y {
+		spread[i] = y[i] - hedges[i].Alpha - hedges[i].Beta*x[i]
+	}
+	return spread, nil
+}
+
+// RollingZScore standardizes every value by the mean and standard deviation of the trailing window
+// ending at it. Indices before the first full window, or with a constant window, hold zero.
+func RollingZScore(values []float64, window int) ([]float64, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("window must be at least 2, got %d", window)
+	}
+
+	z := make([]float64, len(values))
+	for i := window - 1; i < len(values); i++ {
+		mean := 0.0
+		for _, v := range values[i-window+1 : i+1] {
+			mean += v
+		}
+		mean /= float64(window)
+
+		variance := 0.0
+		for _, v := range values[i-window+1 : i+1] {
+			variance += (v - mean) * (v - mean)
+		}
+		stdDev := math.Sqrt(variance / float64(window-1))
+		if stdDev > 0 {
+			z[i] = (values[i] - mean) / stdDev
+		}
+	}
+	return z, nil
+}
+
+// SpreadPositions turns a z-score series into spread positions with hysteresis: enter short (-1) above
+// entry and long (1) below -entry, exit once the z-score crosses back inside exit, and stop out beyond stop.
+// After a stop-out no new position is entered until the z-score has crossed back inside exit, so a
+// diverging spread is not re-entered on every bar. A stop of zero or less disables the stop. The position
+// at index i only depends on z-scores up to i.
+func SpreadPositions(z []float64, entry, exit, stop float64) []int {
+	positions := make([]int, len(z))
+	position := 0
+	stopped := false
+	for i, score := range z {
+		if stopped && math.Abs(score) <= exit {
+			stopped = false
+		}
+		switch {
+		case stop > 0 && math.Abs(score) >= stop:
+			position = 0
+			stopped = true
+		case stopped:
+		case position == 0 && score >= entry:
+			position = -1
+		case position == 0 && score <= -entry:
+			position = 1
+		case position == -1 && score <= exit:
+			position = 0
+		case position == 1 && score >= -exit:
+			position = 0
+		}
+		positions[i] = position
+	}
+	return positions
+}
@@ -9,12 +9,18 @@ import (
 
 // BollingerBandsReversionStrategy implements the Bollinger Bands reversion strategy
 func BollingerBandsReversionStrategy(df dataframe.DataFrame) backtest_types.StrategyAction {
+	return BollingerBandsReversionSignalStrategy(df).Action()
+}
+
+// BollingerBandsReversionSignalStrategy implements the Bollinger Bands reversion strategy with confidence-weighted signals.
+// A price on a band yields a strength of 0.5, growing to 1 when the price is a full band width beyond it.
+func BollingerBandsReversionSignalStrategy(df dataframe.DataFrame) backtest_types.Signal {
 	period := 20            // Moving average period
 	stdDevMultiplier := 2.0 // Standard deviation multiplier
 
 	// Ensure we have enough data to calculate Bollinger Bands
 	if df.Nrow() < period {
-		return "Hold"
+		return backtest_types.Signal{}
 	}
 
 	// Calculate the moving average and standard deviation for the closing prices
@@ -34,15 +40,24 @@ func BollingerBandsReversionStrategy(df dataframe.DataFrame) backtest_types.Stra
 	currentPrice := prices[len(prices)-1]
 	currentUpperBand := upperBand[len(upperBand)-1]
 	currentLowerBand := lowerBand[len(lowerBand)-1]
+	bandWidth := currentUpperBand - currentLowerBand
 
 	// Generate signals based on the price's position relative to the Bollinger Bands
 	if currentPrice <= currentLowerBand {
-		return "Buy"
+		return backtest_types.NewSignal(bandStrength(currentLowerBand-currentPrice, bandWidth))
 	} else if currentPrice >= currentUpperBand {
-		return "Sell"
+		return backtest_types.NewSignal(-bandStrength(currentPrice-currentUpperBand, bandWidth))
 	}
 
-	return "Hold"
+	return backtest_types.Signal{}
+}
+
+// bandStrength scales the distance of the price beyond a band to a conviction in [0.5, 1].
+func bandStrength(distance, bandWidth float64) float64 {
+	if bandWidth <= 0 {
+		return 1
+	}
+	return math.Min(1, 0.5+0.5*distance/bandWidth)
 }
 
 // standardDeviation calculates the standard deviation for a given period
@@ -1,7 +1,10 @@
 package strategies
 
 import (
+	"errors"
+	"fmt"
 	backtest_types "goquant/pkg/backtest"
+	"math"
 
 	"github.com/go-gota/gota/dataframe"
 )
@@ -9,56 +12,327 @@ import (
 // StrategyFunc is a function type that represents a trading strategy.
 type StrategyFunc func(df dataframe.DataFrame) backtest_types.StrategyAction
 
+// SignalFunc is a function type that represents a trading strategy with confidence-weighted output.
+type SignalFunc func(df dataframe.DataFrame) backtest_types.Signal
+
+// AsSignalFunc adapts a StrategyFunc to a SignalFunc with full conviction.
+func AsSignalFunc(strategy StrategyFunc) SignalFunc {
+	return func(df dataframe.DataFrame) backtest_types.Signal {
+		return backtest_types.SignalFromAction(strategy(df))
+	}
+}
+
+// MetaModel combines the signals of the individual strategies of a stacked ensemble into a final signal.
+// The signals are passed in the same order as the strategies of the ensemble.
+type MetaModel func(signals []backtest_types.Signal) backtest_types.Signal
+
+// VotingMode determines how an EnsembleStrategy combines the signals of its strategies.
+type VotingMode int
+
+const (
+	// WeightedMajority picks the action with the highest total weight.
+	WeightedMajority VotingMode = iota
+	// Unanimous only trades if every strategy agrees on the same action.
+	Unanimous
+	// QuorumThreshold trades if the weight behind an action reaches the ensemble's Threshold.
+	QuorumThreshold
+	// ScoreAverage averages the weighted signal strengths and trades if the absolute average
+	// reaches the ensemble's Threshold.
+	ScoreAverage
+	// Stacked passes all signals to the ensemble's MetaModel.
+	Stacked
+)
+
+// String returns the name of the voting mode.
+func (m VotingMode) String() string {
+	switch m {
+	case WeightedMajority:
+		return "WeightedMajority"
+	case Unanimous:
+		return "Unanimous"
+	case QuorumThreshold:
+		return "QuorumThreshold"
+	case ScoreAverage:
+		return "ScoreAverage"
+	case Stacked:
+		return "Stacked"
+	default:
+		return fmt.Sprintf("VotingMode(%d)", int(m))
+	}
+}
+
+// actionOrder is the fixed order in which actions are considered. Hold comes first so that ties resolve to Hold.
+var actionOrder = []backtest_types.StrategyAction{"Hold", "Buy", "Sell"}
+
 // EnsembleStrategy represents a strategy that combines multiple strategies.
 type EnsembleStrategy struct {
-	Strategies []StrategyFunc
+	Strategies []SignalFunc
 	Weights    []float64
+	Mode       VotingMode
+	// Threshold is the minimum share of the total weight for QuorumThreshold and the minimum
+	// absolute average strength for ScoreAverage. It is ignored by the other modes.
+	Threshold float64
+	// MetaModel is only used by the Stacked mode.
+	MetaModel MetaModel
 }
 
-// NewEnsembleStrategy creates a new EnsembleStrategy instance.
+// NewEnsembleStrategy creates a new EnsembleStrategy instance that uses weighted majority voting.
 //
 // Parameters:
 // - strategies: A slice of StrategyFunc representing the individual strategies to combine.
 // - weights: A slice of float64 representing the weights for each strategy. If empty or nil, equal weights will be used.
-// Returns a pointer to the newly created EnsembleStrategy.
-func NewEnsembleStrategy(strategies []StrategyFunc, weights []float64) *EnsembleStrategy {
+// Returns a pointer to the newly created EnsembleStrategy, or an error if the weights are invalid.
+func NewEnsembleStrategy(strategies []StrategyFunc, weights []float64) (*EnsembleStrategy, error) {
+	return NewEnsembleStrategyWithMode(strategies, weights, WeightedMajority, 0)
+}
+
+// NewEnsembleStrategyWithMode creates a new EnsembleStrategy instance with the given voting mode.
+//
+// Parameters:
+// - strategies: A slice of StrategyFunc representing the individual strategies to combine.
+// - weights: A slice of float64 representing the weights for each strategy. If empty or nil, equal weights will be used.
+// - mode: The VotingMode used to combine the actions. Use NewStackedEnsembleStrategy for the Stacked mode.
+// - threshold: The quorum share for QuorumThreshold or the minimum absolute score for ScoreAverage, in (0, 1].
+// Returns a pointer to the newly created EnsembleStrategy, or an error if the configuration is invalid.
+func NewEnsembleStrategyWithMode(strategies []StrategyFunc, weights []float64, mode VotingMode, threshold float64) (*EnsembleStrategy, error) {
+	signals := make([]SignalFunc, len(strategies))
+	for i, strategy := range strategies {
+		if strategy == nil {
+			return nil, fmt.Errorf("strategy %d is nil", i)
+		}
+		signals[i] = AsSignalFunc(strategy)
+	}
+	return NewSignalEnsembleStrategy(signals, weights, mode, threshold)
+}
+
+// NewSignalEnsembleStrategy creates a new EnsembleStrategy from confidence-weighted strategies.
+//
+// Parameters:
+// - strategies: A slice of SignalFunc representing the individual strategies to combine.
+// - weights: A slice of float64 representing the weights for each strategy. If empty or nil, equal weights will be used.
+// - mode: The VotingMode used to combine the signals. Use NewStackedEnsembleStrategy for the Stacked mode.
+// - threshold: The quorum share for QuorumThreshold or the minimum absolute strength for ScoreAverage, in (0, 1].
+// Returns a pointer to the newly created EnsembleStrategy, or an error if the configuration is invalid.
+func NewSignalEnsembleStrategy(strategies []SignalFunc, weights []float64, mode VotingMode, threshold float64) (*EnsembleStrategy, error) {
+	if mode == Stacked {
+		return nil, errors.New("stacked ensembles require a meta-model, use NewStackedEnsembleStrategy")
+	}
+	if mode < WeightedMajority || mode > Stacked {
+		return nil, fmt.Errorf("unknown voting mode: %v", mode)
+	}
+	if mode == QuorumThreshold || mode == ScoreAverage {
+		if threshold <= 0 || threshold > 1 || math.IsNaN(threshold) {
+			return nil, fmt.Errorf("threshold for %v must be in (0, 1], got %v", mode, threshold)
+		}
+	}
+
+	normalized, err := normalizeWeights(strategies, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnsembleStrategy{
+		Strategies: strategies,
+		Weights:    normalized,
+		Mode:       mode,
+		Threshold:  threshold,
+	}, nil
+}
+
+// NewStackedEnsembleStrategy creates a new EnsembleStrategy that delegates the final decision to a meta-model.
+//
+// Parameters:
+// - strategies: A slice of SignalFunc representing the individual strategies to combine.
+// - metaModel: The MetaModel that maps the individual signals to the final signal.
+// Returns a pointer to the newly created EnsembleStrategy, or an error if the configuration is invalid.
+func NewStackedEnsembleStrategy(strategies []SignalFunc, metaModel MetaModel) (*EnsembleStrategy, error) {
+	if metaModel == nil {
+		return nil, errors.New("meta-model must not be nil")
+	}
+
+	weights, err := normalizeWeights(strategies, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnsembleStrategy{
+		Strategies: strategies,
+		Weights:    weights,
+		Mode:       Stacked,
+		MetaModel:  metaModel,
+	}, nil
+}
+
+// normalizeWeights validates the weights against the strategies and scales them to sum to one.
+// If no weights are provided, equal weights are assigned to all strategies.
+func normalizeWeights(strategies []SignalFunc, weights []float64) ([]float64, error) {
+	if len(strategies) == 0 {
+		return nil, errors.New("ensemble requires at least one strategy")
+	}
+	for i, strategy := range strategies {
+		if strategy == nil {
+			return nil, fmt.Errorf("strategy %d is nil", i)
+		}
+	}
+
 	// If no weights are provided, assign equal weights to all strategies.
-	if len(weights) == 0 || weights == nil {
+	if len(weights) == 0 {
 		weights = make([]float64, len(strategies))
 		for i := range weights {
 			weights[i] = 1.0 / float64(len(strategies))
 		}
+		return weights, nil
 	}
 
-	return &EnsembleStrategy{
-		Strategies: strategies,
-		Weights:    weights,
+	if len(weights) != len(strategies) {
+		return nil, fmt.Errorf("got %d weights for %d strategies", len(weights), len(strategies))
+	}
+
+	total := 0.0
+	for i, w := range weights {
+		if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+			return nil, fmt.Errorf("weight %d must be a non-negative finite number, got %v", i, w)
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, errors.New("weights must not all be zero")
 	}
+
+	normalized := make([]float64, len(weights))
+	for i, w := range weights {
+		normalized[i] = w / total
+	}
+	return normalized, nil
 }
 
 // Run applies the ensemble strategy to make a decision based on the combined strategies.
 func (es *EnsembleStrategy) Run(df dataframe.DataFrame) backtest_types.StrategyAction {
+	return es.RunSignal(df).Action()
+}
+
+// RunSignal applies the ensemble strategy and returns the combined signal.
+// For the voting modes the strength is the share of the total weight behind the chosen action.
+func (es *EnsembleStrategy) RunSignal(df dataframe.DataFrame) backtest_types.Signal {
+	signals := make([]backtest_types.Signal, len(es.Strategies))
+	for i, strategy := range es.Strategies {
+		signals[i] = strategy(df)
+	}
+
+	switch es.Mode {
+	case Unanimous:
+		return es.unanimous(signals)
+	case QuorumThreshold:
+		return es.quorum(signals)
+	case ScoreAverage:
+		return es.scoreAverage(signals)
+	case Stacked:
+		return es.stacked(signals)
+	default:
+		return es.weightedMajority(signals)
+	}
+}
+
+// stacked returns the signal of the meta-model, clamped, with its target weight if it sets one. An
+// ensemble built without a meta-model stays flat.
+func (es *EnsembleStrategy) stacked(signals []backtest_types.Signal) backtest_types.Signal {
+	if es.MetaModel == nil {
+		return backtest_types.Signal{}
+	}
+	signal := es.MetaModel(signals)
+	if signal.HasTargetWeight {
+		return backtest_types.NewSignalWithTarget(signal.Strength, signal.TargetWeight)
+	}
+	return backtest_types.NewSignal(signal.Strength)
+}
+
+// actionScores sums the weights behind the direction of each signal.
+func (es *EnsembleStrategy) actionScores(signals []backtest_types.Signal) map[backtest_types.StrategyAction]float64 {
 	actionScores := map[backtest_types.StrategyAction]float64{
 		"Buy":  0,
 		"Sell": 0,
 		"Hold": 0,
 	}
 
-	// Aggregate the weighted decisions from each strategy.
-	for i, strategy := range es.Strategies {
-		action := strategy(df)
-		actionScores[action] += es.Weights[i]
+	for i, signal := range signals {
+		actionScores[signal.Action()] += es.Weights[i]
+	}
+	return actionScores
+}
+
+// signalFor converts an action and the weight behind it to a Signal.
+func signalFor(action backtest_types.StrategyAction, weight float64) backtest_types.Signal {
+	switch action {
+	case "Buy":
+		return backtest_types.NewSignal(weight)
+	case "Sell":
+		return backtest_types.NewSignal(-weight)
+	default:
+		return backtest_types.Signal{}
 	}
+}
+
+// weightedMajority returns the action with the highest score. If multiple actions share the highest score, Hold is returned.
+func (es *EnsembleStrategy) weightedMajority(signals []backtest_types.Signal) backtest_types.Signal {
+	actionScores := es.actionScores(signals)
 
-	// Determine the action with the highest score. if mmultiple actions have the same highest score, choose hold first
-	var finalAction backtest_types.StrategyAction
+	finalAction := backtest_types.StrategyAction("Hold")
 	maxScore := -1.0
-	for action, score := range actionScores {
+	tied := false
+	for _, action := range actionOrder {
+		score := actionScores[action]
 		if score > maxScore {
 			maxScore = score
 			finalAction = action
+			tied = false
+		} else if score == maxScore {
+			tied = true
 		}
 	}
 
-	return finalAction
+	if tied {
+		return backtest_types.Signal{}
+	}
+	return signalFor(finalAction, maxScore)
+}
+
+// unanimous returns the common action if all strategies agree, otherwise Hold.
+func (es *EnsembleStrategy) unanimous(signals []backtest_types.Signal) backtest_types.Signal {
+	first := signals[0].Action()
+	for _, signal := range signals[1:] {
+		if signal.Action() != first {
+			return backtest_types.Signal{}
+		}
+	}
+	return signalFor(first, 1)
+}
+
+// quorum returns Buy or Sell if its share of the total weight reaches the threshold, otherwise Hold.
+// Buy and Sell can only both reach a quorum at or below 0.5, in which case the larger share wins and a tie holds.
+func (es *EnsembleStrategy) quorum(signals []backtest_types.Signal) backtest_types.Signal {
+	actionScores := es.actionScores(signals)
+	buy, sell := actionScores["Buy"], actionScores["Sell"]
+
+	switch {
+	case buy >= es.Threshold && buy > sell:
+		return signalFor("Buy", buy)
+	case sell >= es.Threshold && sell > buy:
+		return signalFor("Sell", sell)
+	default:
+		return backtest_types.Signal{}
+	}
+}
+
+// scoreAverage averages the weighted signal strengths and returns the average if its magnitude
+// reaches the threshold, otherwise Hold.
+func (es *EnsembleStrategy) scoreAverage(signals []backtest_types.Signal) backtest_types.Signal {
+	score := 0.0
+	for i, signal := range signals {
+		score += es.Weights[i] * signal.Strength
+	}
+
+	if math.Abs(score) < es.Threshold {
+		return backtest_types.Signal{}
+	}
+	return backtest_types.NewSignal(score)
 }
@@ -0,0 +1,230 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"goquant/internal/data"
+	"goquant/internal/ml"
+	backtest_types "goquant/pkg/backtest"
+	"math"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// MLStrategy wraps a learned model. It builds features from the bars it is given, trains the model on
+// the most recent TrainWindow bars whose next-bar direction is already known, and turns the predicted
+// probability of a rising close into a signal.
+//
+// Training is strictly causal: the label of a bar is only used once the following bar is part of the
+// dataframe, so the model never sees the outcome it is asked to predict.
+type MLStrategy struct {
+	Pipeline *ml.Pipeline
+	Model    ml.Model
+	// TrainWindow is the maximum number of labeled rows used for training.
+	TrainWindow int
+	// MinTrainRows is the minimum number of labeled rows before the strategy trades.
+	MinTrainRows int
+	// RetrainEvery is the number of new bars after which the model is retrained.
+	RetrainEvery int
+	// Threshold is the minimum absolute signal strength to trade, in [0, 1).
+	Threshold float64
+
+	trainedRows int
+	trained     bool
+}
+
+// NewMLStrategy creates a new MLStrategy.
+//
+// Parameters:
+// - pipeline: The feature pipeline applied to the bars.
+// - model: The model to train and predict with.
+// - trainWindow: The maximum number of labeled rows used for training.
+// - retrainEvery: The number of new bars after which the model is retrained, at least 1.
+// - threshold: The minimum absolute signal strength 2p-1 to trade, in [0, 1).
+// Returns a pointer to the newly created MLStrategy, or an error if the parameters are invalid.
+func NewMLStrategy(pipeline *ml.Pipeline, model ml.Model, trainWindow, retrainEvery int, threshold float64) (*MLStrategy, error) {
+	if pipeline == nil || len(pipeline.Features) == 0 {
+		return nil, errors.New("pipeline must have at least one feature")
+	}
+	if model == nil {
+		return nil, errors.New("model must not be nil")
+	}
+	if trainWindow < 2 || retrainEvery < 1 {
+		return nil, fmt.Errorf("invalid windows: train window %d, retrain every %d", trainWindow, retrainEvery)
+	}
+	if threshold < 0 || threshold >= 1 {
+		return nil, fmt.Errorf("threshold must be in [0, 1), got %v", threshold)
+	}
+
+	return &MLStrategy{
+		Pipeline:     pipeline,
+		Model:        model,
+		TrainWindow:  trainWindow,
+		MinTrainRows: trainWindow / 2,
+		RetrainEvery: retrainEvery,
+		Threshold:    threshold,
+	}, nil
+}
+
+// Run applies the model to the latest bar and returns the direction of its signal.
+func (s *MLStrategy) Run(df dataframe.DataFrame) backtest_types.StrategyAction {
+	return s.RunSignal(df).Action()
+}
+
+// RunSignal applies the model to the latest bar and returns a signal with strength 2p-1,
+// where p is the predicted probability that the next close is higher.
+func (s *MLStrategy) RunSignal(df dataframe.DataFrame) backtest_types.Signal {
+	bars := data.FromDataFrame(df)
+	if len(bars) < 2 {
+		return backtest_types.Signal{}
+	}
+
+	rows := s.Pipeline.Transform(bars)
+	labels := ml.NextBarLabels(bars)
+
+	if !s.trained || len(bars)-s.trainedRows >= s.RetrainEvery || len(bars) < s.trainedRows {
+		if err := s.train(rows, labels); err != nil {
+			return backtest_types.Signal{}
+		}
+		s.trainedRows = len(bars)
+	}
+
+	latest := rows[len(rows)-1]
+	if !ml.Complete(latest) {
+		return backtest_types.Signal{}
+	}
+	p, err := s.Model.Predict(latest)
+	if err != nil {
+		return backtest_types.Signal{}
+	}
+
+	strength := 2*p - 1
+	if math.Abs(strength) < s.Threshold {
+		return backtest_types.Signal{}
+	}
+	return backtest_types.NewSignal(strength)
+}
+
+// train fits the model on the most recent complete rows with known labels. The last row never has a label.
+func (s *MLStrategy) train(rows [][]float64, labels []float64) error {
+	var X [][]float64
+	var y []float64
+	for i := len(rows) - 2; i >= 0 && len(X) < s.TrainWindow; i-- {
+		if ml.Complete(rows[i]) && !math.IsNaN(labels[i]) {
+			X = append(X, rows[i])
+			y = append(y, labels[i])
+		}
+	}
+	if len(X) < s.MinTrainRows || len(X) == 0 {
+		s.trained = false
+		return fmt.Errorf("need %d labeled rows to train, got %d", s.MinTrainRows, len(X))
+	}
+
+	// Restore chronological order
+	for i, j := 0, len(X)-1; i < j; i, j = i+1, j-1 {
+		X[i], X[j] = X[j], X[i]
+		y[i], y[j] = y[j], y[i]
+	}
+
+	if err := s.Model.Fit(X, y); err != nil {
+		s.trained = false
+		return err
+	}
+	s.trained = true
+	return nil
+}
+
+// PretrainedModelStrategy evaluates a model trained outside goquant, e.g. loaded with ml.LoadPMMLModel
+// or ml.LoadJSONModel, on the features of the latest bar.
+type PretrainedModelStrategy struct {
+	Pipeline *ml.Pipeline
+	Model    ml.PretrainedModel
+	// Scale converts the raw score of a non-probabilistic model to a strength: strength = score / Scale, clamped to [-1, 1].
+	Scale float64
+	// Threshold is the minimum absolute signal strength to trade, in [0, 1).
+	Threshold float64
+
+	// columns maps every model input to its pipeline column.
+	columns []int
+}
+
+// NewPretrainedModelStrategy creates a new PretrainedModelStrategy.
+//
+// Parameters:
+// - pipeline: The feature pipeline; every model input must match a pipeline feature by name.
+// - model: The pre-trained model.
+// - scale: The score that maps to full conviction for non-probabilistic models, positive. Ignored for classifiers.
+// - threshold: The minimum absolute signal strength to trade, in [0, 1).
+// Returns a pointer to the newly created PretrainedModelStrategy, or an error if the model inputs do not match the pipeline.
+func NewPretrainedModelStrategy(pipeline *ml.Pipeline, model ml.PretrainedModel, scale, threshold float64) (*PretrainedModelStrategy, error) {
+	if pipeline == nil || model == nil {
+		return nil, errors.New("pipeline and model must not be nil")
+	}
+	if !model.Probabilistic() && scale <= 0 {
+		return nil, fmt.Errorf("scale must be positive, got %v", scale)
+	}
+	if threshold < 0 || threshold >= 1 {
+		return nil, fmt.Errorf("threshold must be in [0, 1), got %v", threshold)
+	}
+
+	names := pipeline.Names()
+	columns := make([]int, len(model.FeatureNames()))
+	for i, input := range model.FeatureNames() {
+		columns[i] = -1
+		for j, name := range names {
+			if name == input {
+				columns[i] = j
+				break
+			}
+		}
+		if columns[i] < 0 {
+			return nil, fmt.Errorf("model input %q is not produced by the pipeline", input)
+		}
+	}
+
+	return &PretrainedModelStrategy{
+		Pipeline:  pipeline,
+		Model:     model,
+		Scale:     scale,
+		Threshold: threshold,
+		columns:   columns,
+	}, nil
+}
+
+// Run applies the model to the latest bar and returns the direction of its signal.
+func (s *PretrainedModelStrategy) Run(df dataframe.DataFrame) backtest_types.StrategyAction {
+	return s.RunSignal(df).Action()
+}
+
+// RunSignal applies the model to the latest bar. Classifiers yield a strength of 2p-1, where p is the
+// predicted probability of an up move; other models yield their score divided by Scale.
+func (s *PretrainedModelStrategy) RunSignal(df dataframe.DataFrame) backtest_types.Signal {
+	bars := data.FromDataFrame(df)
+	if len(bars) == 0 {
+		return backtest_types.Signal{}
+	}
+
+	rows := s.Pipeline.Transform(bars)
+	latest := rows[len(rows)-1]
+	input := make([]float64, len(s.columns))
+	for i, column := range s.columns {
+		input[i] = latest[column]
+	}
+	if !ml.Complete(input) {
+		return backtest_types.Signal{}
+	}
+
+	prediction, err := s.Model.Predict(input)
+	if err != nil {
+		return backtest_types.Signal{}
+	}
+
+	strength := prediction / s.Scale
+	if s.Model.Probabilistic() {
+		strength = 2*prediction - 1
+	}
+	if math.Abs(strength) < s.Threshold {
+		return backtest_types.Signal{}
+	}
+	return backtest_types.NewSignal(strength)
+}
@@ -1,8 +1,12 @@
 package strategies
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 	"strings"
 
 	backtest_types "goquant/pkg/backtest"
@@ -10,99 +14,395 @@ import (
 	"github.com/go-gota/gota/dataframe"
 )
 
-// MarkovChainStrategy holds the transition matrix and implements the Markov Chain strategy.
+// Discretization determines how price movements are mapped to Markov states.
+type Discretization int
+
+const (
+	// SignDiscretization maps each return to Up, Down or Unchanged.
+	SignDiscretization Discretization = iota
+	// QuantileDiscretization maps each return to one of Buckets equally populated buckets fitted on the training data.
+	QuantileDiscretization
+	// VolatilityDiscretization maps each return to Up or Down if it exceeds VolatilityMultiplier times the
+	// rolling standard deviation of returns over VolatilityWindow bars, and to Unchanged otherwise.
+	VolatilityDiscretization
+)
+
+// PredictionMode determines how the next state is chosen from the transition probabilities.
+type PredictionMode int
+
+const (
+	// ArgmaxPrediction deterministically chooses the most probable next state.
+	ArgmaxPrediction PredictionMode = iota
+	// SamplePrediction draws the next state from the transition probabilities.
+	SamplePrediction
+)
+
+// MarkovConfig holds the configuration of a MarkovChainStrategy.
+type MarkovConfig struct {
+	// Depth is the number of past transitions that make up the current sequence.
+	Depth          int
+	Discretization Discretization
+	// Buckets is the number of return buckets for QuantileDiscretization. The lowest bucket is
+	// treated as Down and the highest as Up when generating signals.
+	Buckets int
+	// VolatilityWindow and VolatilityMultiplier configure VolatilityDiscretization.
+	VolatilityWindow     int
+	VolatilityMultiplier float64
+	// Smoothing is the Laplace smoothing pseudo-count added to every transition.
+	Smoothing float64
+	Mode      PredictionMode
+	// Seed seeds the random source used by SamplePrediction.
+	Seed int64
+}
+
+// DefaultMarkovConfig returns a configuration with sign discretization, Laplace smoothing of one and argmax prediction.
+func DefaultMarkovConfig(depth int) MarkovConfig {
+	return MarkovConfig{
+		Depth:                depth,
+		Discretization:       SignDiscretization,
+		Buckets:              3,
+		VolatilityWindow:     20,
+		VolatilityMultiplier: 0.5,
+		Smoothing:            1,
+		Mode:                 ArgmaxPrediction,
+	}
+}
+
+// MarkovChainStrategy holds the transition counts of an n-th order Markov chain over discretized
+// returns and implements the Markov Chain strategy.
+//
+// Training (Build, Update) only changes the counts; inference (Run, Predict, Probabilities) reads them.
 type MarkovChainStrategy struct {
-	TransitionMatrix map[string]map[string]float64
-	States           []string
-	Depth            int
+	Config MarkovConfig
+	States []string
+	// Counts holds the observed transitions from a sequence of states to the next state.
+	Counts map[string]map[string]float64
+	// QuantileEdges holds the inner bucket edges of QuantileDiscretization after training.
+	QuantileEdges []float64
+	// Tail holds the last prices seen in training, so that Update continues the series instead of
+	// starting a new one.
+	Tail []float64
+
+	rng *rand.Rand
 }
 
-// NewMarkovChainStrategy initializes a new MarkovChainStrategy with a specified depth.
-func NewMarkovChainStrategy(depth int) *MarkovChainStrategy {
-	return &MarkovChainStrategy{
-		TransitionMatrix: make(map[string]map[string]float64),
-		States:           []string{"Up", "Down", "Unchanged"},
-		Depth:            depth,
+// NewMarkovChainStrategy initializes a new MarkovChainStrategy with a specified depth and the default configuration.
+//
+// Parameters:
+// - depth: The number of past transitions that make up the current sequence, at least 1.
+// Returns a pointer to the newly created MarkovChainStrategy, or an error if the depth is invalid.
+func NewMarkovChainStrategy(depth int) (*MarkovChainStrategy, error) {
+	return NewMarkovChainStrategyWithConfig(DefaultMarkovConfig(depth))
+}
+
+// NewMarkovChainStrategyWithConfig initializes a new MarkovChainStrategy with the given configuration.
+//
+// Parameters:
+// - cfg: The MarkovConfig of the strategy.
+// Returns a pointer to the newly created MarkovChainStrategy, or an error if the configuration is invalid.
+func NewMarkovChainStrategyWithConfig(cfg MarkovConfig) (*MarkovChainStrategy, error) {
+	if cfg.Depth < 1 {
+		return nil, fmt.Errorf("depth must be at least 1, got %d", cfg.Depth)
+	}
+	if cfg.Smoothing < 0 || math.IsNaN(cfg.Smoothing) {
+		return nil, fmt.Errorf("smoothing must be non-negative, got %v", cfg.Smoothing)
+	}
+	if cfg.Mode != ArgmaxPrediction && cfg.Mode != SamplePrediction {
+		return nil, fmt.Errorf("unknown prediction mode: %d", cfg.Mode)
+	}
+
+	var states []string
+	switch cfg.Discretization {
+	case SignDiscretization, VolatilityDiscretization:
+		states = []string{"Up", "Down", "Unchanged"}
+		if cfg.Discretization == VolatilityDiscretization && (cfg.VolatilityWindow < 2 || cfg.VolatilityMultiplier <= 0) {
+			return nil, errors.New("volatility discretization requires a window of at least 2 and a positive multiplier")
+		}
+	case QuantileDiscretization:
+		if cfg.Buckets < 2 {
+			return nil, fmt.Errorf("quantile discretization requires at least 2 buckets, got %d", cfg.Buckets)
+		}
+		states = make([]string, cfg.Buckets)
+		for i := range states {
+			states[i] = fmt.Sprintf("Q%d", i+1)
+		}
+	default:
+		return nil, fmt.Errorf("unknown discretization: %d", cfg.Discretization)
 	}
+
+	return &MarkovChainStrategy{
+		Config: cfg,
+		States: states,
+		Counts: make(map[string]map[string]float64),
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}, nil
 }
 
-// Build constructs the transition matrix based on the entire historical dataframe.
+// Build resets the model and trains it on the entire historical dataframe.
 func (mcs *MarkovChainStrategy) Build(df dataframe.DataFrame) {
-	mcs.TransitionMatrix = calculateTransitionMatrix(df, mcs.States, mcs.Depth)
+	prices := df.Col("Close").Float()
+	mcs.Counts = make(map[string]map[string]float64)
+	if mcs.Config.Discretization == QuantileDiscretization {
+		mcs.QuantileEdges = quantileEdges(returns(prices), mcs.Config.Buckets)
+	}
+	mcs.Tail = nil
+	mcs.train(prices)
+}
+
+// Update adds the transitions observed in the given prices to the existing counts, allowing the model
+// to be trained online. The prices continue those of the previous Build or Update, so transitions
+// across batches are counted and the volatility window carries over. The quantile edges of an already
+// built model are kept fixed.
+func (mcs *MarkovChainStrategy) Update(prices []float64) {
+	if mcs.Config.Discretization == QuantileDiscretization && mcs.QuantileEdges == nil {
+		mcs.QuantileEdges = quantileEdges(returns(append(append([]float64(nil), mcs.Tail...), prices...)), mcs.Config.Buckets)
+	}
+	mcs.train(prices)
+}
+
+// train counts the transitions of the prices appended to the tail that the tail alone does not
+// contain, and keeps the prices needed to continue with the next batch.
+func (mcs *MarkovChainStrategy) train(prices []float64) {
+	series := append(append([]float64(nil), mcs.Tail...), prices...)
+	counted := len(mcs.discretize(mcs.Tail))
+	mcs.countTransitions(mcs.discretize(series), counted)
+
+	// The states of the last Depth transitions need Depth+1 prices beyond the volatility window
+	keep := mcs.Config.Depth + mcs.lookback() + 1
+	if len(series) > keep {
+		series = series[len(series)-keep:]
+	}
+	mcs.Tail = series
 }
 
 // Run applies the Markov Chain strategy to predict the next state and generate trading signals.
 func (mcs *MarkovChainStrategy) Run(df dataframe.DataFrame) backtest_types.StrategyAction {
-	if df.Nrow() < mcs.Depth {
+	prices := df.Col("Close").Float()
+	if len(prices) < mcs.Config.Depth+mcs.lookback()+1 {
 		return "Hold"
 	}
-	// Get the current sequence of states
-	currentSequence := getCurrentSequence(df, mcs.Depth)
+	if mcs.Config.Discretization == QuantileDiscretization && mcs.QuantileEdges == nil {
+		return "Hold" // Not trained yet
+	}
+
+	states := mcs.discretize(prices)
+
+	sequence := sequenceKey(states[len(states)-mcs.Config.Depth:])
+
+	// An argmax prediction without a clear winner, e.g. for an unseen sequence, is no signal
+	if mcs.Config.Mode == ArgmaxPrediction {
+		if _, tied := argmax(mcs.Probabilities(sequence)); tied {
+			return "Hold"
+		}
+	}
 
-	// Predict the next state
-	nextState := predictNextState(currentSequence, mcs.States, mcs.TransitionMatrix)
+	// Predict the next state from the most recent sequence of states
+	nextState := mcs.Predict(sequence)
 
 	// Generate a signal based on the predicted next state
 	switch nextState {
-	case "Up":
+	case mcs.upState():
 		return "Buy"
-	case "Down":
+	case mcs.downState():
 		return "Sell"
 	default:
 		return "Hold"
 	}
 }
 
-// calculateTransitionMatrix calculates the transition matrix from historical data with depth n.
-func calculateTransitionMatrix(df dataframe.DataFrame, states []string, depth int) map[string]map[string]float64 {
-	transitionMatrix := make(map[string]map[string]float64)
+// Predict returns the next state for the given sequence according to the configured prediction mode.
+// In argmax mode, ties resolve to the earliest state in States.
+func (mcs *MarkovChainStrategy) Predict(sequence string) string {
+	probabilities := mcs.Probabilities(sequence)
 
-	for _, state := range states {
-		for _, nextState := range states {
-			transitionMatrix[state] = make(map[string]float64)
-			fmt.Printf("State: %s, Next State: %s\n", state, nextState)
+	if mcs.Config.Mode == SamplePrediction {
+		prob := mcs.rng.Float64()
+		cumulativeProb := 0.0
+		for i, state := range mcs.States {
+			cumulativeProb += probabilities[i]
+			if prob < cumulativeProb {
+				return state
+			}
 		}
+		return mcs.States[len(mcs.States)-1]
 	}
 
-	prices := df.Col("Close").Float()
-	totalTransitions := make(map[string]int)
+	best, _ := argmax(probabilities)
+	return mcs.States[best]
+}
 
-	// Build the transition matrix
-	for i := depth; i < len(prices)-1; i++ {
-		currentSequence := getStateSequence(prices[i-depth : i])
-		nextState := getState(prices[i], prices[i+1])
+// argmax returns the index of the largest value and whether another value is equally large.
+// Ties resolve to the earliest index.
+func argmax(values []float64) (int, bool) {
+	best := 0
+	tied := false
+	for i := 1; i < len(values); i++ {
+		if values[i] > values[best] {
+			best = i
+			tied = false
+		} else if values[i] == values[best] {
+			tied = true
+		}
+	}
+	return best, tied
+}
 
-		if transitionMatrix[currentSequence] == nil {
-			transitionMatrix[currentSequence] = make(map[string]float64)
+// Probabilities returns the smoothed transition probabilities from the given sequence, in the order of States.
+// Sequences that were never observed yield a uniform distribution.
+func (mcs *MarkovChainStrategy) Probabilities(sequence string) []float64 {
+	probabilities := make([]float64, len(mcs.States))
+	counts := mcs.Counts[sequence]
+
+	total := 0.0
+	for i, state := range mcs.States {
+		probabilities[i] = counts[state] + mcs.Config.Smoothing
+		total += probabilities[i]
+	}
+
+	for i := range probabilities {
+		if total > 0 {
+			probabilities[i] /= total
+		} else {
+			probabilities[i] = 1 / float64(len(mcs.States))
 		}
-		transitionMatrix[currentSequence][nextState]++
-		totalTransitions[currentSequence]++
 	}
+	return probabilities
+}
 
-	// Normalize the transition matrix to get probabilities
-	for sequence := range transitionMatrix {
-		for _, nextState := range states {
-			if totalTransitions[sequence] > 0 {
-				transitionMatrix[sequence][nextState] /= float64(totalTransitions[sequence])
+// markovJSON is the serialized form of a trained MarkovChainStrategy.
+type markovJSON struct {
+	Config        MarkovConfig                  `json:"config"`
+	States        []string                      `json:"states"`
+	Counts        map[string]map[string]float64 `json:"counts"`
+	QuantileEdges []float64                     `json:"quantile_edges,omitempty"`
+	Tail          []float64                     `json:"tail,omitempty"`
+}
+
+// MarshalJSON serializes the configuration and the trained transition counts.
+func (mcs *MarkovChainStrategy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(markovJSON{
+		Config:        mcs.Config,
+		States:        mcs.States,
+		Counts:        mcs.Counts,
+		QuantileEdges: mcs.QuantileEdges,
+		Tail:          mcs.Tail,
+	})
+}
+
+// UnmarshalJSON restores a trained MarkovChainStrategy serialized with MarshalJSON.
+func (mcs *MarkovChainStrategy) UnmarshalJSON(data []byte) error {
+	var decoded markovJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	restored, err := NewMarkovChainStrategyWithConfig(decoded.Config)
+	if err != nil {
+		return err
+	}
+	if len(decoded.States) != len(restored.States) {
+		return fmt.Errorf("expected %d states, got %d", len(restored.States), len(decoded.States))
+	}
+	if decoded.Counts != nil {
+		restored.Counts = decoded.Counts
+	}
+	if decoded.Config.Discretization == QuantileDiscretization && decoded.QuantileEdges != nil {
+		if len(decoded.QuantileEdges) != decoded.Config.Buckets-1 {
+			return fmt.Errorf("expected %d quantile edges, got %d", decoded.Config.Buckets-1, len(decoded.QuantileEdges))
+		}
+		if !sort.Float64sAreSorted(decoded.QuantileEdges) {
+			return errors.New("quantile edges must be sorted")
+		}
+		for _, edge := range decoded.QuantileEdges {
+			if math.IsNaN(edge) {
+				return errors.New("quantile edges must not be NaN")
 			}
 		}
+		restored.QuantileEdges = decoded.QuantileEdges
 	}
+	restored.Tail = decoded.Tail
 
-	return transitionMatrix
+	*mcs = *restored
+	return nil
 }
 
-// getCurrentSequence determines the current sequence of states based on the last n price points.
-func getCurrentSequence(df dataframe.DataFrame, depth int) string {
-	prices := df.Col("Close").Float()
-	return getStateSequence(prices[len(prices)-depth:])
+// countTransitions counts every sequence of Depth states together with the state that follows it,
+// except for transitions into the first skip states, which were counted before.
+func (mcs *MarkovChainStrategy) countTransitions(states []string, skip int) {
+	depth := mcs.Config.Depth
+	for i := max(depth, skip); i < len(states); i++ {
+		sequence := sequenceKey(states[i-depth : i])
+		if mcs.Counts[sequence] == nil {
+			mcs.Counts[sequence] = make(map[string]float64)
+		}
+		mcs.Counts[sequence][states[i]]++
+	}
 }
 
-// getStateSequence converts a slice of prices into a sequence of states.
-func getStateSequence(prices []float64) string {
-	states := []string{}
-	for i := 1; i < len(prices); i++ {
-		states = append(states, getState(prices[i-1], prices[i]))
+// discretize converts prices into one state per return. With VolatilityDiscretization the first
+// VolatilityWindow returns are consumed to estimate the volatility and produce no state.
+func (mcs *MarkovChainStrategy) discretize(prices []float64) []string {
+	rets := returns(prices)
+
+	switch mcs.Config.Discretization {
+	case QuantileDiscretization:
+		states := make([]string, len(rets))
+		for i, r := range rets {
+			states[i] = mcs.States[sort.SearchFloat64s(mcs.QuantileEdges, r)]
+		}
+		return states
+
+	case VolatilityDiscretization:
+		window := mcs.Config.VolatilityWindow
+		var states []string
+		for i := window; i < len(rets); i++ {
+			threshold := mcs.Config.VolatilityMultiplier * sampleStdDev(rets[i-window:i])
+			switch {
+			case rets[i] > threshold:
+				states = append(states, "Up")
+			case rets[i] < -threshold:
+				states = append(states, "Down")
+			default:
+				states = append(states, "Unchanged")
+			}
+		}
+		return states
+
+	default:
+		states := make([]string, len(rets))
+		for i := 1; i < len(prices); i++ {
+			states[i-1] = getState(prices[i-1], prices[i])
+		}
+		return states
+	}
+}
+
+// lookback returns the number of returns consumed before the first state is produced.
+func (mcs *MarkovChainStrategy) lookback() int {
+	if mcs.Config.Discretization == VolatilityDiscretization {
+		return mcs.Config.VolatilityWindow
+	}
+	return 0
+}
+
+// upState returns the state that generates a Buy signal.
+func (mcs *MarkovChainStrategy) upState() string {
+	if mcs.Config.Discretization == QuantileDiscretization {
+		return mcs.States[len(mcs.States)-1]
+	}
+	return "Up"
+}
+
+// downState returns the state that generates a Sell signal.
+func (mcs *MarkovChainStrategy) downState() string {
+	if mcs.Config.Discretization == QuantileDiscretization {
+		return mcs.States[0]
 	}
+	return "Down"
+}
+
+// sequenceKey joins a sequence of states into the key used by the transition counts.
+func sequenceKey(states []string) string {
 	return strings.Join(states, "-")
 }
 
@@ -116,38 +416,44 @@ func getState(prevPrice, currPrice float64) string {
 	return "Unchanged"
 }
 
-// predictNextState predicts the next state based on the current sequence and the transition matrix.
-func predictNextState(currentSequence string, states []string, transitionMatrix map[string]map[string]float64) string {
-	prob := rand.Float64()
-	cumulativeProb := 0.0
-
-	for _, nextState := range states {
-		cumulativeProb += transitionMatrix[currentSequence][nextState]
-		if prob <= cumulativeProb {
-			return nextState
+// returns calculates the simple returns of a price series.
+func returns(prices []float64) []float64 {
+	if len(prices) < 2 {
+		return nil
+	}
+	rets := make([]float64, len(prices)-1)
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] != 0 {
+			rets[i-1] = prices[i]/prices[i-1] - 1
 		}
 	}
-
-	// Default to current state if prediction fails
-	return strings.Split(currentSequence, "-")[0] // Return the first state in the sequence
+	return rets
 }
 
-// predictNextStateMax predicts the next state with the highest probability based on the current sequence and the transition matrix.
-//
-// currentSequence - The current sequence of states.
-// states - A list of possible next states.
-// transitionMatrix - A map of state transitions with their corresponding probabilities.
-// Returns the next state with the highest probability.
-func predictNextStateMax(currentSequence string, states []string, transitionMatrix map[string]map[string]float64) string {
-	maxProb := 0.0
-	bestState := states[0]
-
-	for _, nextState := range states {
-		if transitionMatrix[currentSequence][nextState] > maxProb {
-			maxProb = transitionMatrix[currentSequence][nextState]
-			bestState = nextState
-		}
+// quantileEdges returns the buckets-1 inner edges that split the values into equally populated buckets.
+func quantileEdges(values []float64, buckets int) []float64 {
+	edges := make([]float64, buckets-1)
+	if len(values) == 0 {
+		return edges
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	for i := range edges {
+		edges[i] = sorted[(i+1)*len(sorted)/buckets]
 	}
+	return edges
+}
 
-	return bestState
+// sampleStdDev calculates the sample standard deviation of a slice of float64 values.
+func sampleStdDev(data []float64) float64 {
+	if len(data) < 2 {
+		return 0
+	}
+	mean := sum(data) / float64(len(data))
+	variance := 0.0
+	for _, v := range data {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(data)-1))
 }
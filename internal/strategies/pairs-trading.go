@@ -0,0 +1,123 @@
+package strategies
+
+import (
+	"fmt"
+	"goquant/internal/statarb"
+	backtest_types "goquant/pkg/backtest"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// HedgeMethod determines how the pairs trading strategy estimates the hedge ratio.
+type HedgeMethod int
+
+const (
+	// RollingOLSHedge regresses A on B over a rolling window.
+	RollingOLSHedge HedgeMethod = iota
+	// KalmanHedge tracks the hedge ratio with a Kalman filter.
+	KalmanHedge
+)
+
+// PairsTradingStrategy trades the z-score of the spread between two instruments.
+type PairsTradingStrategy struct {
+	Method HedgeMethod
+	// Window is the rolling window of the OLS hedge ratio and of the spread z-score.
+	Window int
+	// Kalman is used by the KalmanHedge method.
+	Kalman *statarb.KalmanHedge
+	// EntryZ, ExitZ and StopZ are the z-score levels to enter, exit and stop out of a position.
+	EntryZ float64
+	ExitZ  float64
+	StopZ  float64
+}
+
+// NewPairsTradingStrategy creates a pairs trading strategy that uses a rolling OLS hedge ratio.
+//
+// Parameters:
+// - window: The rolling window of the hedge ratio and the z-score, at least 2.
+// - entryZ: The absolute z-score at which a position is entered.
+// - exitZ: The absolute z-score at which a position is closed, below entryZ.
+// - stopZ: The absolute z-score at which a position is stopped out, above entryZ, or 0 to disable.
+// Returns a pointer to the newly created PairsTradingStrategy, or an error if the parameters are invalid.
+func NewPairsTradingStrategy(window int, entryZ, exitZ, stopZ float64) (*PairsTradingStrategy, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("window must be at least 2, got %d", window)
+	}
+	if entryZ <= 0 || exitZ < 0 || exitZ >= entryZ {
+		return nil, fmt.Errorf("thresholds must satisfy 0 <= exit < entry, got entry %v and exit %v", entryZ, exitZ)
+	}
+	if stopZ != 0 && stopZ <= entryZ {
+		return nil, fmt.Errorf("stop must be above entry or 0, got %v", stopZ)
+	}
+
+	return &PairsTradingStrategy{
+		Method: RollingOLSHedge,
+		Window: window,
+		EntryZ: entryZ,
+		ExitZ:  exitZ,
+		StopZ:  stopZ,
+	}, nil
+}
+
+// NewKalmanPairsTradingStrategy creates a pairs trading strategy that tracks the hedge ratio with a Kalman filter.
+// The z-score is the standardized forecast error of the filter, so window only sets the warm-up period.
+func NewKalmanPairsTradingStrategy(kalman *statarb.KalmanHedge, window int, entryZ, exitZ, stopZ float64) (*PairsTradingStrategy, error) {
+	if kalman == nil {
+		return nil, fmt.Errorf("kalman filter must not be nil")
+	}
+	pts, err := NewPairsTradingStrategy(window, entryZ, exitZ, stopZ)
+	if err != nil {
+		return nil, err
+	}
+	pts.Method = KalmanHedge
+	pts.Kalman = kalman
+	return pts, nil
+}
+
+// Run returns the target spread position for the latest bar of the two aligned dataframes.
+func (pts *PairsTradingStrategy) Run(a, b dataframe.DataFrame) backtest_types.PairSignal {
+	if a.Nrow() < 2*pts.Window || a.Nrow() != b.Nrow() {
+		return backtest_types.PairSignal{}
+	}
+	pricesA := a.Col("Close").Float()
+	pricesB := b.Col("Close").Float()
+
+	var hedges []statarb.HedgeEstimate
+	var z []float64
+	switch pts.Method {
+	case KalmanHedge:
+		steps, err := pts.Kalman.Filter(pricesA, pricesB)
+		if err != nil {
+			return backtest_types.PairSignal{}
+		}
+		hedges = make([]statarb.HedgeEstimate, len(steps))
+		z = make([]float64, len(steps))
+		for i, step := range steps {
+			hedges[i] = step.Hedge
+			if i >= pts.Window {
+				z[i] = step.ZScore()
+			}
+		}
+	default:
+		var err error
+		hedges, err = statarb.RollingOLS(pricesA, pricesB, pts.Window)
+		if err != nil {
+			return backtest_types.PairSignal{}
+		}
+		spread, err := statarb.Spread(pricesA, pricesB, hedges)
+		if err != nil {
+			return backtest_types.PairSignal{}
+		}
+		// Only score the spread once the hedge ratio is defined for the whole window
+		z, err = statarb.RollingZScore(spread[pts.Window-1:], pts.Window)
+		if err != nil {
+			return backtest_types.PairSignal{}
+		}
+	}
+
+	positions := statarb.SpreadPositions(z, pts.EntryZ, pts.ExitZ, pts.StopZ)
+	return backtest_types.PairSignal{
+		Position:   positions[len(positions)-1],
+		HedgeRatio: hedges[len(hedges)-1].Beta,
+	}
+}
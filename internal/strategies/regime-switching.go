@@ -0,0 +1,89 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"goquant/internal/regime"
+	backtest_types "goquant/pkg/backtest"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+// RegimeSwitchingStrategy delegates to a different strategy depending on the market regime
+// detected by a hidden Markov model.
+type RegimeSwitchingStrategy struct {
+	Indicator *regime.Indicator
+	// Strategies holds one strategy per regime, ordered like the regimes of the indicator (ascending volatility).
+	Strategies []SignalFunc
+	// MinProbability is the filtered probability the current regime needs to trade. Below it the strategy holds.
+	MinProbability float64
+}
+
+// NewRegimeSwitchingStrategy creates a two-regime strategy that follows the trend in the calm regime
+// and trades mean reversion in the volatile regime.
+//
+// Parameters:
+// - volatilityWindow: The number of returns used for the rolling volatility feature of the regime model.
+// - trendFollowing: The strategy used in the low-volatility regime.
+// - meanReversion: The strategy used in the high-volatility regime.
+// Returns a pointer to the newly created RegimeSwitchingStrategy, or an error if the parameters are invalid.
+func NewRegimeSwitchingStrategy(volatilityWindow int, trendFollowing, meanReversion StrategyFunc) (*RegimeSwitchingStrategy, error) {
+	if trendFollowing == nil || meanReversion == nil {
+		return nil, errors.New("regime strategies must not be nil")
+	}
+	indicator, err := regime.NewIndicator(2, volatilityWindow)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegimeSwitchingStrategyWithIndicator(indicator, []SignalFunc{AsSignalFunc(trendFollowing), AsSignalFunc(meanReversion)}, 0.5)
+}
+
+// NewRegimeSwitchingStrategyWithIndicator creates a strategy with one sub-strategy per regime of the given indicator.
+//
+// Parameters:
+// - indicator: The regime indicator, trained with Build before running the strategy.
+// - strategies: One strategy per regime, ordered by ascending volatility.
+// - minProbability: The filtered probability the current regime needs to trade, in [0, 1].
+// Returns a pointer to the newly created RegimeSwitchingStrategy, or an error if the parameters are invalid.
+func NewRegimeSwitchingStrategyWithIndicator(indicator *regime.Indicator, strategies []SignalFunc, minProbability float64) (*RegimeSwitchingStrategy, error) {
+	if indicator == nil {
+		return nil, errors.New("indicator must not be nil")
+	}
+	if len(strategies) != indicator.Model.States {
+		return nil, fmt.Errorf("got %d strategies for %d regimes", len(strategies), indicator.Model.States)
+	}
+	for i, strategy := range strategies {
+		if strategy == nil {
+			return nil, fmt.Errorf("strategy for regime %d is nil", i)
+		}
+	}
+	if minProbability < 0 || minProbability > 1 {
+		return nil, fmt.Errorf("minimum probability must be in [0, 1], got %v", minProbability)
+	}
+
+	return &RegimeSwitchingStrategy{
+		Indicator:      indicator,
+		Strategies:     strategies,
+		MinProbability: minProbability,
+	}, nil
+}
+
+// Build trains the regime model on the historical dataframe.
+func (rs *RegimeSwitchingStrategy) Build(df dataframe.DataFrame) error {
+	return rs.Indicator.Fit(df)
+}
+
+// Run applies the strategy of the current regime.
+func (rs *RegimeSwitchingStrategy) Run(df dataframe.DataFrame) backtest_types.StrategyAction {
+	return rs.RunSignal(df).Action()
+}
+
+// RunSignal applies the strategy of the current regime and returns its signal.
+// It holds if the model is not trained, there is not enough data or the regime is uncertain.
+func (rs *RegimeSwitchingStrategy) RunSignal(df dataframe.DataFrame) backtest_types.Signal {
+	current, probability, err := rs.Indicator.Current(df)
+	if err != nil || probability < rs.MinProbability {
+		return backtest_types.Signal{}
+	}
+	return rs.Strategies[current](df)
+}
@@ -14,13 +14,24 @@ import (
 // Returns:
 // - backtest_types.StrategyAction: A trading signal indicating whether to "Buy", "Sell", or "Hold".
 func RSIStrategy(df dataframe.DataFrame) backtest_types.StrategyAction {
+	return RSISignalStrategy(df).Action()
+}
+
+// RSISignalStrategy generates a confidence-weighted trading signal based on the Relative Strength Index (RSI).
+//
+// Parameters:
+// - df (dataframe.DataFrame): A DataFrame containing the financial data to be analyzed.
+// Returns:
+// - backtest_types.Signal: A long signal that grows from 0 at an RSI of 30 to 1 at an RSI of 0, a short signal
+// that grows from 0 at an RSI of 70 to -1 at an RSI of 100, and a flat signal in between.
+func RSISignalStrategy(df dataframe.DataFrame) backtest_types.Signal {
 	period := 2 // Standard RSI period
 
 	// Ensure we have enough data to calculate RSI
 	fmt.Printf("Num of rows in dataframe: %d\n", df.Nrow())
 	if df.Nrow()-1 < period {
 		fmt.Printf("not enough data to calculate RSI\n")
-		return "Hold"
+		return backtest_types.Signal{}
 	}
 
 	// Calculate the RSI
@@ -28,14 +39,14 @@ func RSIStrategy(df dataframe.DataFrame) backtest_types.StrategyAction {
 	// Get the current RSI value
 	currentRSI := rsi[len(rsi)-1]
 	//
-	// Generate signals based on RSI levels
+	// Generate signals based on RSI levels, scaled by the distance beyond the threshold
 	if currentRSI < 30 {
-		return "Buy"
+		return backtest_types.NewSignal((30 - currentRSI) / 30)
 	} else if currentRSI > 70 {
-		return "Sell"
+		return backtest_types.NewSignal(-(currentRSI - 70) / 30)
 	}
 
-	return "Hold"
+	return backtest_types.Signal{}
 }
 
 // calculateRSI calculates the Relative Strength Index (RSI) for a given period
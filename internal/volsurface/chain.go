@@ -0,0 +1,228 @@
+package volsurface
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"goquant/internal/options"
+	data_types "goquant/pkg/data"
+)
+
+// LoadChainCSV loads option quotes from a CSV file with a header row. The columns are matched by name,
+// case-insensitively:
+//   - type (required): C, P, Call or Put
+//   - strike (required)
+//   - expiry (required): a date such as 2006-01-02, an RFC 3339 time or a Unix timestamp
+//   - bid, ask (required)
+//   - symbol, underlying, last, volume, open_interest (optional)
+//
+// Dates without a time expire at 16:00 UTC. All contracts are European with a multiplier of 100.
+func LoadChainCSV(path string) ([]data_types.OptionQuote, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadChainCSV(file)
+}
+
+// ReadChainCSV reads option quotes in the format of LoadChainCSV.
+func ReadChainCSV(r io.Reader) ([]data_types.OptionQuote, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"type", "strike", "expiry", "bid", "ask"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var quotes []data_types.OptionQuote
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		quote, err := parseQuote(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// parseQuote converts one CSV record to an OptionQuote.
+func parseQuote(record []string, columns map[string]int) (data_types.OptionQuote, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	number := func(name string) (float64, error) {
+		value := field(name)
+		if value == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, value)
+		}
+		return parsed, nil
+	}
+
+	quote := data_types.OptionQuote{
+		Contract: data_types.OptionContract{
+			Symbol:     field("symbol"),
+			Underlying: field("underlying"),
+			Style:      data_types.European,
+			Multiplier: 100,
+		},
+	}
+
+	switch strings.ToLower(field("type")) {
+	case "c", "call":
+		quote.Contract.Type = data_types.Call
+	case "p", "put":
+		quote.Contract.Type = data_types.Put
+	default:
+		return quote, fmt.Errorf("invalid type %q", field("type"))
+	}
+
+	expiry, err := parseExpiry(field("expiry"))
+	if err != nil {
+		return quote, err
+	}
+	quote.Contract.Expiry = expiry
+
+	values := map[string]*float64{"strike": &quote.Contract.Strike, "bid": &quote.Bid, "ask": &quote.Ask, "last": &quote.Last}
+	for name, target := range values {
+		if *target, err = number(name); err != nil {
+			return quote, err
+		}
+	}
+	if quote.Contract.Strike <= 0 {
+		return quote, fmt.Errorf("strike must be positive, got %v", quote.Contract.Strike)
+	}
+
+	volume, err := number("volume")
+	if err != nil {
+		return quote, err
+	}
+	openInterest, err := number("open_interest")
+	if err != nil {
+		return quote, err
+	}
+	quote.Volume = int64(volume)
+	quote.OpenInterest = int64(openInterest)
+	return quote, nil
+}
+
+// parseExpiry parses a date, an RFC 3339 time or a Unix timestamp.
+func parseExpiry(value string) (int64, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Add(16 * time.Hour).Unix(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return unix, nil
+	}
+	return 0, fmt.Errorf("invalid expiry %q", value)
+}
+
+// Market holds the state of the underlying used to value a chain.
+type Market struct {
+	// Timestamp is the Unix timestamp of the quotes.
+	Timestamp int64
+	Spot      float64
+	// Rate and Dividend are continuously compounded.
+	Rate     float64
+	Dividend float64
+}
+
+// Forward returns the forward price for a time to expiry in years.
+func (m Market) Forward(expiry float64) float64 {
+	return m.Spot * math.Exp((m.Rate-m.Dividend)*expiry)
+}
+
+// Point is the implied volatility of one quote.
+type Point struct {
+	Strike float64
+	// Expiry is the time to expiry in years.
+	Expiry  float64
+	Forward float64
+	// LogMoneyness is log(Strike / Forward).
+	LogMoneyness float64
+	Volatility   float64
+	// TotalVariance is Volatility² · Expiry.
+	TotalVariance float64
+}
+
+// ImpliedVolatilities computes the implied volatility of the mid price of every out-of-the-money quote,
+// i.e. calls at or above the forward and puts below it, which are the most liquid. Expired quotes,
+// quotes without a two-sided market and quotes whose price cannot be attained are skipped.
+//
+// Parameters:
+// - quotes: The option chain.
+// - market: The spot, rates and time of the quotes.
+// Returns the points, or an error if the market is invalid or no quote yields a volatility.
+func ImpliedVolatilities(quotes []data_types.OptionQuote, market Market) ([]Point, error) {
+	if market.Spot <= 0 {
+		return nil, fmt.Errorf("spot must be positive, got %v", market.Spot)
+	}
+
+	var points []Point
+	for _, quote := range quotes {
+		expiry := quote.Contract.TimeToExpiry(market.Timestamp)
+		if expiry <= 0 || quote.Bid <= 0 || quote.Ask < quote.Bid {
+			continue
+		}
+		forward := market.Forward(expiry)
+		strike := quote.Contract.Strike
+		if (quote.Contract.Type == data_types.Call) != (strike >= forward) {
+			continue
+		}
+
+		in := options.NewInputs(quote.Contract, market.Timestamp, market.Spot, market.Rate, market.Dividend, 0)
+		in.Style = data_types.European
+		vol, err := options.ImpliedVolatility(options.BlackScholesMerton{}, in, quote.Mid())
+		if err != nil {
+			continue
+		}
+		points = append(points, Point{
+			Strike:        strike,
+			Expiry:        expiry,
+			Forward:       forward,
+			LogMoneyness:  math.Log(strike / forward),
+			Volatility:    vol,
+			TotalVariance: vol * vol * expiry,
+		})
+	}
+	if len(points) == 0 {
+		return nil, errors.New("no quote yields an implied volatility")
+	}
+	return points, nil
+}
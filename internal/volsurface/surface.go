@@ -0,0 +1,193 @@
+package volsurface
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Slice is the SVI fit of one expiry.
+type Slice struct {
+	// Expiry is the time to expiry in years.
+	Expiry  float64
+	Forward float64
+	SVI     SVI
+	Points  int
+}
+
+// SkippedExpiry is an expiry that Build left out of a surface.
+type SkippedExpiry struct {
+	// Expiry is the time to expiry in years.
+	Expiry float64
+	Points int
+	Err    error
+}
+
+// Surface is an implied volatility surface built from SVI slices. Across strikes it uses the SVI fit of
+// each expiry and across tenors it interpolates the total variance linearly at constant log-moneyness.
+type Surface struct {
+	Market Market
+	Slices []Slice
+	// Skipped lists the expiries without a slice, because they had too few points or their fit failed.
+	Skipped []SkippedExpiry
+}
+
+// Build fits an SVI slice to every expiry with at least minPoints points and returns the surface.
+//
+// Parameters:
+// - points: The implied volatilities, e.g. from ImpliedVolatilities.
+// - market: The market the points were computed in, used for the forward of arbitrary tenors.
+// - minPoints: The minimum number of points per expiry, at least 5. Expiries with fewer points are skipped.
+// Returns the Surface with the skipped expiries in Skipped, or an error listing why every expiry was
+// skipped if none can be fitted.
+func Build(points []Point, market Market, minPoints int) (*Surface, error) {
+	if minPoints < 5 {
+		return nil, fmt.Errorf("minPoints must be at least 5, got %d", minPoints)
+	}
+	if market.Spot <= 0 {
+		return nil, fmt.Errorf("spot must be positive, got %v", market.Spot)
+	}
+
+	byExpiry := make(map[float64][]Point)
+	for _, p := range points {
+		byExpiry[p.Expiry] = append(byExpiry[p.Expiry], p)
+	}
+
+	surface := &Surface{Market: market}
+	for expiry, group := range byExpiry {
+		if len(group) < minPoints {
+			surface.skip(expiry, len(group), fmt.Errorf("%d points, need %d", len(group), minPoints))
+			continue
+		}
+		k := make([]float64, len(group))
+		w := make([]float64, len(group))
+		for i, p := range group {
+			k[i] = p.LogMoneyness
+			w[i] = p.TotalVariance
+		}
+		svi, err := FitSVI(k, w)
+		if err != nil {
+			surface.skip(expiry, len(group), err)
+			continue
+		}
+		surface.Slices = append(surface.Slices, Slice{Expiry: expiry, Forward: group[0].Forward, SVI: svi, Points: len(group)})
+	}
+	sort.Slice(surface.Skipped, func(a, b int) bool { return surface.Skipped[a].Expiry < surface.Skipped[b].Expiry })
+	if len(surface.Slices) == 0 {
+		errs := []error{errors.New("no expiry has enough points for an SVI fit")}
+		for _, skipped := range surface.Skipped {
+			errs = append(errs, fmt.Errorf("expiry %v: %w", skipped.Expiry, skipped.Err))
+		}
+		return nil, errors.Join(errs...)
+	}
+	sort.Slice(surface.Slices, func(a, b int) bool { return surface.Slices[a].Expiry < surface.Slices[b].Expiry })
+	return surface, nil
+}
+
+// skip records an expiry that has no slice.
+func (s *Surface) skip(expiry float64, points int, err error) {
+	s.Skipped = append(s.Skipped, SkippedExpiry{Expiry: expiry, Points: points, Err: err})
+}
+
+// TotalVariance returns the total implied variance at log-moneyness k and time to expiry t in years.
+// Before the first and after the last slice the implied volatility is held constant.
+func (s *Surface) TotalVariance(k, t float64) (float64, error) {
+	if t <= 0 || math.IsNaN(t) {
+		return 0, fmt.Errorf("time to expiry must be positive, got %v", t)
+	}
+	if len(s.Slices) == 0 {
+		return 0, errors.New("surface has no slices")
+	}
+
+	first, last := s.Slices[0], s.Slices[len(s.Slices)-1]
+	if t <= first.Expiry {
+		return first.SVI.TotalVariance(k) * t / first.Expiry, nil
+	}
+	if t >= last.Expiry {
+		return last.SVI.TotalVariance(k) * t / last.Expiry, nil
+	}
+
+	i := sort.Search(len(s.Slices), func(i int) bool { return s.Slices[i].Expiry >= t })
+	before, after := s.Slices[i-1], s.Slices[i]
+	weight := (t - before.Expiry) / (after.Expiry - before.Expiry)
+	return (1-weight)*before.SVI.TotalVariance(k) + weight*after.SVI.TotalVariance(k), nil
+}
+
+// Volatility returns the implied volatility at strike k and time to expiry t in years.
+func (s *Surface) Volatility(strike, t float64) (float64, error) {
+	if strike <= 0 {
+		return 0, fmt.Errorf("strike must be positive, got %v", strike)
+	}
+	w, err := s.TotalVariance(math.Log(strike/s.Market.Forward(t)), t)
+	if err != nil {
+		return 0, err
+	}
+	if w < 0 {
+		return 0, fmt.Errorf("negative total variance at strike %v and expiry %v", strike, t)
+	}
+	return math.Sqrt(w / t), nil
+}
+
+// ButterflyViolation is a log-moneyness at which the density of a slice is negative.
+type ButterflyViolation struct {
+	Expiry       float64
+	LogMoneyness float64
+	Density      float64
+}
+
+// CalendarViolation is a log-moneyness at which the total variance decreases from one slice to the next.
+type CalendarViolation struct {
+	Expiry       float64
+	NextExpiry   float64
+	LogMoneyness float64
+	Decrease     float64
+}
+
+// ArbitrageReport lists the static arbitrage violations found on a log-moneyness grid.
+type ArbitrageReport struct {
+	Butterfly []ButterflyViolation
+	Calendar  []CalendarViolation
+}
+
+// Free reports whether no violations were found.
+func (r ArbitrageReport) Free() bool {
+	return len(r.Butterfly) == 0 && len(r.Calendar) == 0
+}
+
+// CheckArbitrage checks every slice for butterfly arbitrage and every pair of consecutive slices for
+// calendar arbitrage on an evenly spaced log-moneyness grid.
+//
+// Parameters:
+// - minK, maxK: The range of log-moneyness to check.
+// - steps: The number of grid intervals, at least 1.
+// Returns the ArbitrageReport, or an error if the grid is invalid.
+func (s *Surface) CheckArbitrage(minK, maxK float64, steps int) (ArbitrageReport, error) {
+	if steps < 1 || !(maxK > minK) {
+		return ArbitrageReport{}, fmt.Errorf("invalid grid [%v, %v] with %d steps", minK, maxK, steps)
+	}
+
+	const tolerance = 1e-10
+	var report ArbitrageReport
+	for i := 0; i <= steps; i++ {
+		k := minK + (maxK-minK)*float64(i)/float64(steps)
+		for j, slice := range s.Slices {
+			if g := slice.SVI.Density(k); g < -tolerance {
+				report.Butterfly = append(report.Butterfly, ButterflyViolation{Expiry: slice.Expiry, LogMoneyness: k, Density: g})
+			}
+			if j == 0 {
+				continue
+			}
+			previous := s.Slices[j-1]
+			if decrease := previous.SVI.TotalVariance(k) - slice.SVI.TotalVariance(k); decrease > tolerance {
+				report.Calendar = append(report.Calendar, CalendarViolation{
+					Expiry:       previous.Expiry,
+					NextExpiry:   slice.Expiry,
+					LogMoneyness: k,
+					Decrease:     decrease,
+				})
+			}
+		}
+	}
+	return report, nil
+}
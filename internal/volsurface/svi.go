@@ -0,0 +1,137 @@
+package volsurface
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+)
+
+// SVI is Gatheral's raw stochastic volatility inspired parameterization of the total implied variance
+// of one expiry as a function of the log-moneyness k:
+//
+//	w(k) = A + B (Rho (k - M) + sqrt((k - M)² + Sigma²))
+type SVI struct {
+	A     float64
+	B     float64
+	Rho   float64
+	M     float64
+	Sigma float64
+}
+
+// TotalVariance returns w(k).
+func (s SVI) TotalVariance(k float64) float64 {
+	x := k - s.M
+	return s.A + s.B*(s.Rho*x+math.Sqrt(x*x+s.Sigma*s.Sigma))
+}
+
+// derivatives returns the first and second derivatives of w at k.
+func (s SVI) derivatives(k float64) (float64, float64) {
+	x := k - s.M
+	root := math.Sqrt(x*x + s.Sigma*s.Sigma)
+	return s.B * (s.Rho + x/root), s.B * s.Sigma * s.Sigma / (root * root * root)
+}
+
+// Density returns Gatheral's g(k), which is proportional to the risk-neutral density. A slice is free of
+// butterfly arbitrage if g(k) is non-negative everywhere.
+func (s SVI) Density(k float64) float64 {
+	w := s.TotalVariance(k)
+	if w <= 0 {
+		return math.Inf(-1)
+	}
+	first, second := s.derivatives(k)
+	term := 1 - k*first/(2*w)
+	return term*term - first*first/4*(1/w+0.25) + second/2
+}
+
+// Validate checks the parameter constraints that keep the total variance non-negative.
+func (s SVI) Validate() error {
+	switch {
+	case s.B < 0:
+		return fmt.Errorf("b must be non-negative, got %v", s.B)
+	case math.Abs(s.Rho) >= 1:
+		return fmt.Errorf("rho must be in (-1, 1), got %v", s.Rho)
+	case s.Sigma <= 0:
+		return fmt.Errorf("sigma must be positive, got %v", s.Sigma)
+	case s.A+s.B*s.Sigma*math.Sqrt(1-s.Rho*s.Rho) < 0:
+		return errors.New("minimum total variance is negative")
+	}
+	return nil
+}
+
+// FitSVI fits the SVI parameters to total variances with the quasi-explicit method of Zeliade: for fixed
+// M and Sigma the remaining parameters solve a linear least squares problem, and M and Sigma are
+// found with Nelder-Mead.
+//
+// Parameters:
+// - k: The log-moneyness of every point.
+// - w: The total implied variance of every point.
+// Returns the fitted SVI, or an error if there are fewer than 5 points or the fit fails.
+func FitSVI(k, w []float64) (SVI, error) {
+	if len(k) != len(w) {
+		return SVI{}, fmt.Errorf("got %d log-moneyness values for %d variances", len(k), len(w))
+	}
+	if len(k) < 5 {
+		return SVI{}, fmt.Errorf("need at least 5 points, got %d", len(k))
+	}
+
+	minK, maxK := k[0], k[0]
+	for _, v := range k {
+		minK = math.Min(minK, v)
+		maxK = math.Max(maxK, v)
+	}
+	width := math.Max(maxK-minK, 1e-4)
+
+	// x = (M, log Sigma)
+	problem := optimize.Problem{
+		Func: func(x []float64) float64 {
+			_, residual := fitInner(k, w, x[0], math.Exp(x[1]))
+			return residual
+		},
+	}
+	start := []float64{(minK + maxK) / 2, math.Log(width / 4)}
+	result, err := optimize.Minimize(problem, start, &optimize.Settings{MajorIterations: 2000}, &optimize.NelderMead{})
+	if err != nil && result == nil {
+		return SVI{}, err
+	}
+
+	svi, _ := fitInner(k, w, result.X[0], math.Exp(result.X[1]))
+	if err := svi.Validate(); err != nil {
+		return SVI{}, fmt.Errorf("fit is invalid: %w", err)
+	}
+	return svi, nil
+}
+
+// fitInner solves for A, B and Rho at fixed M and Sigma and returns the SVI with its sum of squared errors.
+// With y = (k - M) / Sigma the model is linear: w = a + d y + c sqrt(y² + 1), c = B Sigma, d = Rho B Sigma.
+func fitInner(k, w []float64, m, sigma float64) (SVI, float64) {
+	n := len(k)
+	design := mat.NewDense(n, 3, nil)
+	for i, v := range k {
+		y := (v - m) / sigma
+		design.SetRow(i, []float64{1, y, math.Sqrt(y*y + 1)})
+	}
+
+	var coef mat.VecDense
+	if err := coef.SolveVec(design, mat.NewVecDense(n, w)); err != nil {
+		return SVI{}, math.Inf(1)
+	}
+	a, d, c := coef.AtVec(0), coef.AtVec(1), coef.AtVec(2)
+
+	// Project onto the constraints c >= 0, |d| <= c and a non-negative minimum variance
+	c = math.Max(c, 1e-12)
+	d = math.Max(-c*(1-1e-9), math.Min(d, c*(1-1e-9)))
+	svi := SVI{A: a, B: c / sigma, Rho: d / c, M: m, Sigma: sigma}
+	if floor := -svi.B * svi.Sigma * math.Sqrt(1-svi.Rho*svi.Rho); svi.A < floor {
+		svi.A = floor
+	}
+
+	residual := 0.0
+	for i, v := range k {
+		e := svi.TotalVariance(v) - w[i]
+		residual += e * e
+	}
+	return svi, residual
+}
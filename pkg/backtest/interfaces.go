@@ -16,6 +16,23 @@ func (s StrategyAction) Valid() bool {
 
 type StrategyFunction func(df dataframe.DataFrame) StrategyAction
 
+// SignalFunction is a strategy that returns a confidence-weighted Signal instead of a discrete action.
+type SignalFunction func(df dataframe.DataFrame) Signal
+
+// SignalFunctionFromStrategy adapts a legacy StrategyFunction to a SignalFunction with full conviction.
+func SignalFunctionFromStrategy(strategy StrategyFunction) SignalFunction {
+	return func(df dataframe.DataFrame) Signal {
+		return SignalFromAction(strategy(df))
+	}
+}
+
+// StrategyFunctionFromSignal adapts a SignalFunction to a legacy StrategyFunction by keeping only its direction.
+func StrategyFunctionFromSignal(signal SignalFunction) StrategyFunction {
+	return func(df dataframe.DataFrame) StrategyAction {
+		return signal(df).Action()
+	}
+}
+
 type BacktestResult struct {
 	TotalProfitLoss float64
 	MaxUp           float64
@@ -29,3 +46,31 @@ type BacktestResult struct {
 	GainStrategy    float64
 	GainVsMarket    float64
 }
+
+// PairStrategyFunction is a strategy on two aligned instruments that returns the target spread position.
+type PairStrategyFunction func(a, b dataframe.DataFrame) PairSignal
+
+type PairBacktestResult struct {
+	TotalProfitLoss float64
+	MaxUp           float64
+	MaxDown         float64
+	TradeLog        dataframe.DataFrame
+	LongCount       int
+	ShortCount      int
+	FlatCount       int
+	GainStrategy    float64
+}
+
+// AllocationFunction returns the target portfolio weights per ticker, given the aligned history of every ticker.
+// Tickers missing from the result get a weight of zero.
+type AllocationFunction func(history map[string]dataframe.DataFrame) map[string]float64
+
+type PortfolioBacktestResult struct {
+	TotalProfitLoss float64
+	MaxUp           float64
+	MaxDown         float64
+	TradeLog        dataframe.DataFrame
+	Rebalances      int
+	Turnover        float64
+	GainStrategy    float64
+}
@@ -1 +1,77 @@
 package backtest_types
+
+import "math"
+
+// Signal is a trading signal that carries a direction together with a conviction.
+type Signal struct {
+	// Strength is the signed conviction in [-1, 1]. Positive values are long, negative values short and zero is flat.
+	Strength float64
+	// TargetWeight is the fraction of capital to allocate, in [-1, 1]. It is only used if HasTargetWeight is set.
+	TargetWeight    float64
+	HasTargetWeight bool
+}
+
+// NewSignal creates a Signal with the given strength, clamped to [-1, 1].
+func NewSignal(strength float64) Signal {
+	return Signal{Strength: clampUnit(strength)}
+}
+
+// NewSignalWithTarget creates a Signal with the given strength and target weight, both clamped to [-1, 1].
+func NewSignalWithTarget(strength, targetWeight float64) Signal {
+	return Signal{
+		Strength:        clampUnit(strength),
+		TargetWeight:    clampUnit(targetWeight),
+		HasTargetWeight: true,
+	}
+}
+
+// SignalFromAction maps a legacy StrategyAction to a full-conviction Signal.
+// Buy maps to 1, Sell to -1 and Hold or any invalid action to 0.
+func SignalFromAction(action StrategyAction) Signal {
+	switch action {
+	case "Buy":
+		return Signal{Strength: 1}
+	case "Sell":
+		return Signal{Strength: -1}
+	default:
+		return Signal{}
+	}
+}
+
+// Action maps the direction of the exposure of the signal to a legacy StrategyAction, so that it names
+// the position a backtest takes for the signal.
+func (s Signal) Action() StrategyAction {
+	exposure := s.Exposure()
+	switch {
+	case exposure > 0:
+		return "Buy"
+	case exposure < 0:
+		return "Sell"
+	default:
+		return "Hold"
+	}
+}
+
+// Exposure returns the fraction of capital the signal asks for: the target weight if set, otherwise the strength.
+func (s Signal) Exposure() float64 {
+	if s.HasTargetWeight {
+		return s.TargetWeight
+	}
+	return s.Strength
+}
+
+// clampUnit clamps a value to [-1, 1]. NaN is mapped to 0.
+func clampUnit(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Max(-1, math.Min(1, v))
+}
+
+// PairSignal is the target position in the spread between two instruments A and B.
+// A long spread buys A and sells HedgeRatio units of B per unit of A; a short spread does the opposite.
+type PairSignal struct {
+	// Position is 1 for a long spread, -1 for a short spread and 0 for flat.
+	Position   int
+	HedgeRatio float64
+}
@@ -1,13 +1,39 @@
 package data_types
 
-import "github.com/go-gota/gota/dataframe"
+import (
+	"context"
 
+	"github.com/go-gota/gota/dataframe"
+)
+
+// DataSource fetches daily bars. FetchContext stops as soon as the context is done, including between
+// pages and retries; Fetch is FetchContext with context.Background().
 type DataSource interface {
 	Fetch(symbol string, start, end int64) ([]MarketData, error)
+	FetchContext(ctx context.Context, symbol string, start, end int64) ([]MarketData, error)
+}
+
+// BarSource fetches bars at any supported interval and describes which intervals it supports.
+type BarSource interface {
+	FetchBars(symbol string, start, end int64, interval Interval) ([]MarketData, error)
+	FetchBarsContext(ctx context.Context, symbol string, start, end int64, interval Interval) ([]MarketData, error)
+	Capabilities() Capabilities
+}
+
+// ReportingSource fetches bars together with a report of the rows it received and skipped.
+type ReportingSource interface {
+	FetchBarsReport(ctx context.Context, symbol string, start, end int64, interval Interval) ([]MarketData, FetchReport, error)
+}
+
+// SeriesSource fetches economic series with every release of their observations, so that they can be
+// aligned to bars as they were known at the time.
+type SeriesSource interface {
+	FetchSeries(ctx context.Context, id string, start, end int64) (Series, error)
 }
 
 type DataStorage interface {
     Save(data []MarketData) error
+    SaveContext(ctx context.Context, data []MarketData) error
     Load(symbol string, start, end int64) ([]MarketData, error)
     ToDataFrame(data []MarketData) dataframe.DataFrame
 }
\ No newline at end of file
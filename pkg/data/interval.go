@@ -0,0 +1,103 @@
+package data_types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval is the length of a bar.
+type Interval string
+
+const (
+	Interval1m  Interval = "1m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval1h  Interval = "1h"
+	Interval1d  Interval = "1d"
+	Interval1w  Interval = "1w"
+)
+
+// Intervals lists every supported interval from shortest to longest.
+var Intervals = []Interval{Interval1m, Interval5m, Interval15m, Interval1h, Interval1d, Interval1w}
+
+// ErrUnsupportedInterval is returned when a provider cannot serve bars at the requested interval or that far back.
+var ErrUnsupportedInterval = errors.New("unsupported interval")
+
+// Duration returns the length of the interval, or zero for an unknown interval.
+func (i Interval) Duration() time.Duration {
+	switch i {
+	case Interval1m:
+		return time.Minute
+	case Interval5m:
+		return 5 * time.Minute
+	case Interval15m:
+		return 15 * time.Minute
+	case Interval1h:
+		return time.Hour
+	case Interval1d:
+		return 24 * time.Hour
+	case Interval1w:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Intraday reports whether the interval is shorter than a day.
+func (i Interval) Intraday() bool {
+	return i.Duration() > 0 && i.Duration() < 24*time.Hour
+}
+
+// ParseInterval parses an interval, accepting the common provider spellings such as "15min", "60min",
+// "1day", "daily" or "1wk".
+func ParseInterval(s string) (Interval, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1m", "1min", "1minute":
+		return Interval1m, nil
+	case "5m", "5min", "5minute":
+		return Interval5m, nil
+	case "15m", "15min", "15minute":
+		return Interval15m, nil
+	case "1h", "60m", "60min", "1hour", "hourly":
+		return Interval1h, nil
+	case "1d", "1day", "day", "daily":
+		return Interval1d, nil
+	case "1w", "1wk", "1week", "week", "weekly":
+		return Interval1w, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedInterval, s)
+	}
+}
+
+// Capabilities describes which bars a provider can serve.
+type Capabilities struct {
+	// Intervals lists the supported intervals.
+	Intervals []Interval
+	// MaxHistory is how far back from now bars of an interval are available. Intervals without an
+	// entry have no limit.
+	MaxHistory map[Interval]time.Duration
+}
+
+// Supports reports whether bars of the interval are available.
+func (c Capabilities) Supports(interval Interval) bool {
+	for _, supported := range c.Intervals {
+		if supported == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error wrapping ErrUnsupportedInterval if bars of the interval are not available
+// from the Unix timestamp start onwards, as of now.
+func (c Capabilities) Check(interval Interval, start int64, now time.Time) error {
+	if !c.Supports(interval) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedInterval, interval)
+	}
+	if depth, ok := c.MaxHistory[interval]; ok && time.Unix(start, 0).Before(now.Add(-depth)) {
+		return fmt.Errorf("%w: %s bars are only available for the last %v", ErrUnsupportedInterval, interval, depth)
+	}
+	return nil
+}
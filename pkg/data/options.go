@@ -0,0 +1,95 @@
+package data_types
+
+import "fmt"
+
+// OptionType is the right of an option contract.
+type OptionType int
+
+const (
+	Call OptionType = iota
+	Put
+)
+
+// String returns the name of the option type.
+func (t OptionType) String() string {
+	switch t {
+	case Call:
+		return "Call"
+	case Put:
+		return "Put"
+	default:
+		return fmt.Sprintf("OptionType(%d)", int(t))
+	}
+}
+
+// ExerciseStyle determines when an option can be exercised.
+type ExerciseStyle int
+
+const (
+	European ExerciseStyle = iota
+	American
+)
+
+// String returns the name of the exercise style.
+func (s ExerciseStyle) String() string {
+	switch s {
+	case European:
+		return "European"
+	case American:
+		return "American"
+	default:
+		return fmt.Sprintf("ExerciseStyle(%d)", int(s))
+	}
+}
+
+// secondsPerYear is the length of an ACT/365 year.
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// OptionContract describes a listed option on the instrument identified by Underlying, which matches the
+// Ticker of its MarketData.
+type OptionContract struct {
+	Symbol     string
+	Underlying string
+	Type       OptionType
+	Style      ExerciseStyle
+	Strike     float64
+	// Expiry is the Unix timestamp of expiration.
+	Expiry int64
+	// Multiplier is the number of units of the underlying per contract, e.g. 100 for US equity options.
+	Multiplier float64
+}
+
+// TimeToExpiry returns the time from the Unix timestamp to expiry in ACT/365 years, or zero after expiry.
+func (c OptionContract) TimeToExpiry(timestamp int64) float64 {
+	if timestamp >= c.Expiry {
+		return 0
+	}
+	return float64(c.Expiry-timestamp) / secondsPerYear
+}
+
+// Payoff returns the value of the contract per unit of the underlying at expiry.
+func (c OptionContract) Payoff(spot float64) float64 {
+	if c.Type == Put {
+		return max(c.Strike-spot, 0)
+	}
+	return max(spot-c.Strike, 0)
+}
+
+// OptionQuote is a market quote of an option contract at a point in time.
+type OptionQuote struct {
+	Contract     OptionContract
+	Timestamp    int64
+	Bid          float64
+	Ask          float64
+	Last         float64
+	Volume       int64
+	OpenInterest int64
+}
+
+// Mid returns the midpoint of bid and ask, or the last price if either side is missing.
+func (q OptionQuote) Mid() float64 {
+	if q.Bid <= 0 || q.Ask <= 0 {
+		return q.Last
+	}
+	return (q.Bid + q.Ask) / 2
+}
@@ -0,0 +1,86 @@
+package data_types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors returned by data sources. Provider specific errors wrap one of them so callers can react with
+// errors.Is regardless of the provider.
+var (
+	// ErrRateLimited is returned when the provider rejects requests because a quota is exhausted.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrInvalidSymbol is returned when the provider does not know the symbol.
+	ErrInvalidSymbol = errors.New("invalid symbol")
+	// ErrAuth is returned when the API key is missing, invalid or lacks access to the data.
+	ErrAuth = errors.New("authentication failed")
+	// ErrMalformedPayload is returned when a response cannot be parsed or contains no usable bars.
+	ErrMalformedPayload = errors.New("malformed payload")
+)
+
+// SkippedRow is a row of a response that could not be turned into a bar.
+type SkippedRow struct {
+	// Row is the index of the row within its response.
+	Row int
+	// Raw is the row as received, or its timestamp if the row is not text.
+	Raw string
+	Err error
+}
+
+// FetchReport describes what a fetch received and which rows it skipped.
+type FetchReport struct {
+	Source   string
+	Symbol   string
+	Interval Interval
+	// Requests is the number of responses that were parsed.
+	Requests int
+	// Rows is the number of rows received and Bars the number of bars returned.
+	Rows int
+	Bars int
+	// Duplicates is the number of bars dropped because several responses contained them.
+	Duplicates int
+	Skipped    []SkippedRow
+}
+
+// NewFetchReport creates an empty report for a fetch.
+func NewFetchReport(source, symbol string, interval Interval) FetchReport {
+	return FetchReport{Source: source, Symbol: symbol, Interval: interval}
+}
+
+// Skip records a row that could not be turned into a bar.
+func (r *FetchReport) Skip(row int, raw string, err error) {
+	r.Skipped = append(r.Skipped, SkippedRow{Row: row, Raw: raw, Err: err})
+}
+
+// Merge adds the counts and skipped rows of another report of the same fetch.
+func (r *FetchReport) Merge(other FetchReport) {
+	r.Requests += other.Requests
+	r.Rows += other.Rows
+	r.Bars += other.Bars
+	r.Duplicates += other.Duplicates
+	r.Skipped = append(r.Skipped, other.Skipped...)
+}
+
+// String summarizes the report.
+func (r FetchReport) String() string {
+	return fmt.Sprintf("%s %s %s: %d requests, %d rows, %d bars, %d duplicates, %d skipped",
+		r.Source, r.Symbol, r.Interval, r.Requests, r.Rows, r.Bars, r.Duplicates, len(r.Skipped))
+}
+
+// Validate checks that a bar has finite, positive prices, a high at or above its low and a finite,
+// non-negative volume.
+func (m MarketData) Validate() error {
+	for _, price := range []float64{m.Open, m.High, m.Low, m.Close} {
+		if math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+			return fmt.Errorf("invalid price %v", price)
+		}
+	}
+	if m.High < m.Low {
+		return fmt.Errorf("high %v below low %v", m.High, m.Low)
+	}
+	if math.IsNaN(m.Volume) || math.IsInf(m.Volume, 0) || m.Volume < 0 {
+		return fmt.Errorf("invalid volume %v", m.Volume)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package data_types
+
+import (
+	"math"
+	"sort"
+)
+
+// Observation is the value of an economic series for a period as published in one release.
+type Observation struct {
+	// Timestamp is midnight UTC of the first day of the period the value refers to.
+	Timestamp int64
+	Value     float64
+	// Available is the Unix time from which the value was known, after its release. Revisions of a
+	// period are separate observations with later availability.
+	Available int64
+}
+
+// Series is a timestamped value series, such as a macroeconomic indicator, that keeps every release
+// of every period so that it can be read as it was known at any point in time.
+type Series struct {
+	ID           string
+	Observations []Observation
+}
+
+// AsOf returns the value known at time t: of the periods released by t the latest one, in its latest
+// revision released by t.
+//
+// Parameters:
+// - t: The Unix time at which the series is read.
+// Returns the observation and true, or false if nothing was released by t.
+func (s Series) AsOf(t int64) (Observation, bool) {
+	var known Observation
+	found := false
+	for _, o := range s.Observations {
+		if o.Available > t {
+			continue
+		}
+		if !found || o.Timestamp > known.Timestamp || o.Timestamp == known.Timestamp && o.Available >= known.Available {
+			known, found = o, true
+		}
+	}
+	return known, found
+}
+
+// Latest returns the latest revision of every period, oldest period first, as the series is known today.
+func (s Series) Latest() []Observation {
+	latest := make(map[int64]Observation)
+	for _, o := range s.Observations {
+		if known, ok := latest[o.Timestamp]; !ok || o.Available >= known.Available {
+			latest[o.Timestamp] = o
+		}
+	}
+	result := make([]Observation, 0, len(latest))
+	for _, o := range latest {
+		result = append(result, o)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp < result[j].Timestamp })
+	return result
+}
+
+// AlignToBars returns the value of the series known when each bar starts, as AsOf does. Values are
+// carried forward only from their availability, never from the period they refer to, so they hold no
+// information a backtest could not have had at the bar.
+//
+// Parameters:
+// - bars: The bars to align to, in any order.
+// Returns one value per bar, NaN for bars before the first release.
+func (s Series) AlignToBars(bars []MarketData) []float64 {
+	observations := append([]Observation(nil), s.Observations...)
+	sort.SliceStable(observations, func(i, j int) bool { return observations[i].Available < observations[j].Available })
+	order := make([]int, len(bars))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return bars[order[i]].Timestamp < bars[order[j]].Timestamp })
+
+	// Sweep the bars in time, applying every release up to each bar's start
+	values := make([]float64, len(bars))
+	var current Observation
+	found := false
+	next := 0
+	for _, i := range order {
+		for ; next < len(observations) && observations[next].Available <= bars[i].Timestamp; next++ {
+			o := observations[next]
+			if !found || o.Timestamp >= current.Timestamp {
+				current, found = o, true
+			}
+		}
+		values[i] = math.NaN()
+		if found {
+			values[i] = current.Value
+		}
+	}
+	return values
+}
@@ -0,0 +1,112 @@
+package data_types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// day returns the Unix time of midnight UTC of a date.
+func day(t *testing.T, date string) int64 {
+	t.Helper()
+	v, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.Unix()
+}
+
+// monthly is a monthly series released in the middle of the next month and revised later.
+func monthly(t *testing.T) Series {
+	return Series{ID: "CPI", Observations: []Observation{
+		{Timestamp: day(t, "2024-01-01"), Value: 1, Available: day(t, "2024-02-13")},
+		// The first release of February comes with a revision of January
+		{Timestamp: day(t, "2024-02-01"), Value: 2, Available: day(t, "2024-03-12")},
+		{Timestamp: day(t, "2024-01-01"), Value: 1.5, Available: day(t, "2024-03-12")},
+		// A revision of February, the latest period
+		{Timestamp: day(t, "2024-02-01"), Value: 2.5, Available: day(t, "2024-04-10")},
+		// A late revision of January
+		{Timestamp: day(t, "2024-01-01"), Value: 1.7, Available: day(t, "2024-04-20")},
+	}}
+}
+
+func TestSeriesAlignToBars(t *testing.T) {
+	series := monthly(t)
+	// Bars out of order, with their expected values
+	tests := []struct {
+		date string
+		want float64
+	}{
+		{"2024-04-10", 2.5},        // the revision of February replaces its value from its release
+		{"2024-02-01", math.NaN()}, // before the first release, even on the first day of a released period
+		{"2024-03-11", 1},
+		{"2024-02-13", 1},   // a release at exactly the start of a bar is known at the bar
+		{"2024-04-30", 2.5}, // the late revision of January does not replace February
+		{"2024-03-12", 2},   // February, not the revision of January released with it
+		{"2024-04-09", 2},
+		{"2024-01-15", math.NaN()},
+	}
+	bars := make([]MarketData, len(tests))
+	for i, tt := range tests {
+		bars[i] = MarketData{Ticker: "SPY", Timestamp: day(t, tt.date)}
+	}
+	values := series.AlignToBars(bars)
+	if len(values) != len(bars) {
+		t.Fatalf("got %d values for %d bars", len(values), len(bars))
+	}
+	for i, tt := range tests {
+		if math.IsNaN(tt.want) != math.IsNaN(values[i]) || !math.IsNaN(tt.want) && values[i] != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.date, values[i], tt.want)
+		}
+		// AsOf reads the series the same way
+		o, ok := series.AsOf(bars[i].Timestamp)
+		if ok != !math.IsNaN(tt.want) || ok && o.Value != tt.want {
+			t.Errorf("%s: AsOf got %v, %v, want %v", tt.date, o.Value, ok, tt.want)
+		}
+	}
+
+	if values := (Series{}).AlignToBars(bars[:2]); !math.IsNaN(values[0]) || !math.IsNaN(values[1]) {
+		t.Errorf("empty series: got %v, want NaN", values)
+	}
+}
+
+func TestSeriesAsOf(t *testing.T) {
+	series := monthly(t)
+	tests := []struct {
+		date      string
+		period    string
+		value     float64
+		available string
+	}{
+		{"2024-02-13", "2024-01-01", 1, "2024-02-13"},
+		{"2024-03-12", "2024-02-01", 2, "2024-03-12"},
+		{"2024-04-10", "2024-02-01", 2.5, "2024-04-10"},
+		{"2024-05-01", "2024-02-01", 2.5, "2024-04-10"},
+	}
+	for _, tt := range tests {
+		o, ok := series.AsOf(day(t, tt.date))
+		if !ok || o.Timestamp != day(t, tt.period) || o.Value != tt.value || o.Available != day(t, tt.available) {
+			t.Errorf("%s: got %+v, %v, want %v of %s released %s", tt.date, o, ok, tt.value, tt.period, tt.available)
+		}
+	}
+	if o, ok := series.AsOf(day(t, "2024-02-12")); ok {
+		t.Errorf("before the first release: got %+v", o)
+	}
+}
+
+func TestSeriesLatest(t *testing.T) {
+	// The latest revision of every period, oldest period first
+	latest := monthly(t).Latest()
+	want := []Observation{
+		{Timestamp: day(t, "2024-01-01"), Value: 1.7, Available: day(t, "2024-04-20")},
+		{Timestamp: day(t, "2024-02-01"), Value: 2.5, Available: day(t, "2024-04-10")},
+	}
+	if len(latest) != len(want) {
+		t.Fatalf("got %+v, want %+v", latest, want)
+	}
+	for i := range want {
+		if latest[i] != want[i] {
+			t.Errorf("period %d: got %+v, want %+v", i, latest[i], want[i])
+		}
+	}
+}
@@ -2,10 +2,13 @@ package data_types
 
 type MarketData struct {
 	Ticker    string
+	// Timestamp is the Unix time at which the bar starts. Daily and weekly bars are timestamped at
+	// midnight UTC of the exchange-local date they cover.
 	Timestamp int64
 	Open      float64
 	High      float64
 	Low       float64
 	Close     float64
-	Volume    int64
+	// Volume is the traded quantity, which is fractional for assets such as cryptocurrencies.
+	Volume    float64
 }
\ No newline at end of file